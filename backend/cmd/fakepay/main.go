@@ -0,0 +1,228 @@
+// Command fakepay serves the parts of the Paystack API the server uses, for
+// testing mobile money payments locally. Start it, then run the server with
+// PAYSTACK_BASE_URL pointing at it and the same PAYSTACK_SECRET_KEY.
+//
+// Charges are approved after a delay and reported to the webhook URL. The
+// last digit of the payer's phone number picks another outcome:
+//
+//	1  the charge is declined
+//	2  the payer never approves it, so it is left for reconciliation
+//	3  it is approved but no webhook is sent, so reconciliation must verify it
+//
+// Telecel (vod) charges first ask for an OTP; 123456 is accepted.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"campus-connect/internal/logging"
+	"campus-connect/internal/services"
+)
+
+const validOTP = "123456"
+
+type charge struct {
+	Reference       string            `json:"reference"`
+	Status          string            `json:"status"`
+	Amount          int64             `json:"amount"`
+	Currency        string            `json:"currency"`
+	DisplayText     string            `json:"display_text,omitempty"`
+	GatewayResponse string            `json:"gateway_response"`
+	PaidAt          *time.Time        `json:"paid_at"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+
+	phone string
+}
+
+type chargeRequest struct {
+	Email       string `json:"email"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Reference   string `json:"reference"`
+	MobileMoney struct {
+		Phone    string `json:"phone"`
+		Provider string `json:"provider"`
+	} `json:"mobile_money"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type server struct {
+	secret       string
+	webhookURL   string
+	approveAfter time.Duration
+	logger       *slog.Logger
+
+	mu      sync.Mutex
+	charges map[string]*charge
+}
+
+func main() {
+	addr := flag.String("addr", ":4010", "listen `address`")
+	secret := flag.String("secret", os.Getenv("PAYSTACK_SECRET_KEY"), "secret key clients must send and webhooks are signed with")
+	webhookURL := flag.String("webhook", "http://localhost:8080/api/payments/webhook", "`URL` to send charge webhooks to")
+	approveAfter := flag.Duration("approve-after", 5*time.Second, "how long the payer takes to approve a charge")
+	flag.Parse()
+
+	logger := logging.New(os.Stdout, slog.LevelInfo)
+	if *secret == "" {
+		logger.Error("set -secret or PAYSTACK_SECRET_KEY")
+		os.Exit(1)
+	}
+
+	s := &server{
+		secret:       *secret,
+		webhookURL:   *webhookURL,
+		approveAfter: *approveAfter,
+		logger:       logger,
+		charges:      map[string]*charge{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /charge", s.authorized(s.createCharge))
+	mux.HandleFunc("POST /charge/submit_otp", s.authorized(s.submitOTP))
+	mux.HandleFunc("GET /transaction/verify/{reference}", s.authorized(s.verify))
+
+	logger.Info("fake payment provider listening", "addr", *addr, "webhook", *webhookURL)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		logger.Error("server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func (s *server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+s.secret {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"status": false, "message": "Invalid key"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *server) createCharge(w http.ResponseWriter, r *http.Request) {
+	var req chargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Reference == "" || req.Amount <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"status": false, "message": "Invalid charge request"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.charges[req.Reference]; ok {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"status": false, "message": "Duplicate Transaction Reference"})
+		return
+	}
+
+	c := &charge{
+		Reference: req.Reference,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+		Metadata:  req.Metadata,
+		phone:     req.MobileMoney.Phone,
+	}
+	s.charges[c.Reference] = c
+
+	switch {
+	case strings.HasSuffix(c.phone, "1"):
+		c.Status, c.GatewayResponse = "failed", "Insufficient funds"
+	case req.MobileMoney.Provider == "vod":
+		c.Status, c.DisplayText = "send_otp", "Enter the code sent to "+c.phone
+	default:
+		s.awaitApproval(c)
+	}
+	s.logger.Info("charge created", "reference", c.Reference, "amount", c.Amount, "phone", c.phone, "status", c.Status)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Charge attempted", "data": c})
+}
+
+func (s *server) submitOTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reference string `json:"reference"`
+		OTP       string `json:"otp"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"status": false, "message": "Invalid request"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.charges[req.Reference]
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"status": false, "message": "Transaction reference not found"})
+	case c.Status != "send_otp":
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"status": false, "message": "Charge is not awaiting an OTP"})
+	case req.OTP != validOTP:
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"status": false, "message": "Invalid OTP"})
+	default:
+		s.awaitApproval(c)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Charge attempted", "data": c})
+	}
+}
+
+func (s *server) verify(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.charges[r.PathValue("reference")]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"status": false, "message": "Transaction reference not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": true, "message": "Verification successful", "data": c})
+}
+
+// awaitApproval leaves c waiting for the payer, who approves it after
+// approveAfter unless the phone number says otherwise. s.mu must be held.
+func (s *server) awaitApproval(c *charge) {
+	c.Status, c.DisplayText = "pay_offline", "Approve the prompt on "+c.phone
+	if strings.HasSuffix(c.phone, "2") {
+		return
+	}
+
+	time.AfterFunc(s.approveAfter, func() {
+		s.mu.Lock()
+		now := time.Now().UTC()
+		c.Status, c.GatewayResponse, c.DisplayText, c.PaidAt = "success", "Approved", "", &now
+		event, _ := json.Marshal(map[string]interface{}{"event": "charge.success", "data": c})
+		s.mu.Unlock()
+
+		s.logger.Info("charge approved", "reference", c.Reference)
+		if strings.HasSuffix(c.phone, "3") {
+			s.logger.Info("skipping webhook", "reference", c.Reference)
+			return
+		}
+		s.sendWebhook(c.Reference, event)
+	})
+}
+
+func (s *server) sendWebhook(reference string, body []byte) {
+	req, err := http.NewRequest(http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		s.logger.Error("failed to build webhook", "reference", reference, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(services.PaystackSignatureHeader, services.SignPaystackWebhook(s.secret, body))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.logger.Error("webhook failed", "reference", reference, "error", err)
+		return
+	}
+	resp.Body.Close()
+	s.logger.Info("webhook sent", "reference", reference, "status", resp.StatusCode)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
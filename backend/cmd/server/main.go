@@ -1,54 +1,168 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"strings"
 
 	"campus-connect/internal/auth"
 	"campus-connect/internal/config"
 	"campus-connect/internal/database"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/repositories"
 	"campus-connect/internal/routes"
 	"campus-connect/internal/services"
+	"campus-connect/internal/tracing"
+	"campus-connect/internal/version"
 )
 
 func main() {
-	cfg, err := config.Load()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML config `file`; environment variables override it")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		// errors.Join separates the problems with newlines; log them as a list
+		logger := logging.New(os.Stderr, slog.LevelInfo)
+		logger.Error("invalid configuration", "errors", strings.Split(err.Error(), "\n"))
+		os.Exit(1)
+	}
+	if *printConfig {
+		if err := cfg.WriteEffective(os.Stdout); err != nil {
+			fatal(logging.New(os.Stderr, slog.LevelInfo), "failed to print configuration", err)
+		}
+		return
+	}
+
+	logger := logging.New(os.Stdout, cfg.Log.Level)
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		fatal(logger, "failed to set up tracing", err)
 	}
+	defer shutdownTracing(context.Background())
 
 	db, err := database.NewConnection(cfg.Database)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal(logger, "failed to connect to database", err)
 	}
 	defer db.Close()
+	metrics.RegisterDB(db.DB, cfg.Database.DBName)
+
+	if err := db.RunMigrations(cfg.Database.MigrationsPath); err != nil {
+		fatal(logger, "failed to run database migrations", err)
+	}
+
+	keyring, err := loadKeyring(cfg.JWT)
+	if err != nil {
+		fatal(logger, "failed to load JWT signing keys", err)
+	}
+	authService := auth.NewAuthService(keyring)
 
-	if err := db.RunMigrations("./migrations"); err != nil {
-		log.Fatal("Failed to run database migrations:", err)
+	store, err := services.NewObjectStore(cfg.Storage, cfg.Cloudinary)
+	if err != nil {
+		fatal(logger, "failed to initialize file storage", err)
 	}
 
-	authService := auth.NewAuthService(cfg.JWT.Secret)
+	redisClient := services.NewRedisClient(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+	defer redisClient.Close()
 
-	var cloudinaryService *services.CloudinaryService
-	if cfg.Cloudinary.CloudName != "" && cfg.Cloudinary.APIKey != "" && cfg.Cloudinary.APISecret != "" {
-		cloudinaryService, err = services.NewCloudinaryService(cfg.Cloudinary)
-		if err != nil {
-			log.Printf("Warning: Failed to initialize Cloudinary service: %v", err)
-		} else {
-			log.Println("Cloudinary service initialized successfully")
-		}
+	var payments *services.PaymentService
+	if cfg.Paystack.SecretKey != "" {
+		payments = services.NewPaymentService(
+			services.NewPaystackProvider(cfg.Paystack.BaseURL, cfg.Paystack.SecretKey),
+			repositories.NewPaymentRepository(db),
+			repositories.NewDeliveryRepository(db),
+			repositories.NewTripRepository(db),
+			repositories.NewLedgerRepository(db, cfg.Ledger.PlatformFeeBPS),
+			db,
+			cfg.Payments,
+		)
+		go payments.RunReconciler(logging.NewContext(context.Background(), logger))
 	} else {
-		log.Println("Warning: Cloudinary credentials not provided, image uploads will not work")
+		logger.Warn("payments disabled: set PAYSTACK_SECRET_KEY to accept mobile money")
 	}
 
-	handler := routes.SetupRoutes(db, authService, cloudinaryService, cfg)
+	disputes := services.NewDisputeService(
+		repositories.NewDisputeRepository(db),
+		repositories.NewDeliveryRepository(db),
+		repositories.NewTripRepository(db),
+		repositories.NewLedgerRepository(db, cfg.Ledger.PlatformFeeBPS),
+		repositories.NewUserRepository(db),
+		db,
+		cfg.Disputes,
+	)
+	go disputes.RunDeadlineEnforcer(logging.NewContext(context.Background(), logger))
+
+	handler := routes.SetupRoutes(db, authService, store, redisClient, payments, disputes, cfg, logger)
+
+	switch {
+	case cfg.Metrics.Addr != "":
+		go serveMetrics(logger, cfg.Metrics)
+	case cfg.Metrics.Token == "":
+		logger.Warn("metrics disabled: set METRICS_ADDR or METRICS_TOKEN to expose /metrics")
+	}
 
 	serverAddr := cfg.Server.Host + ":" + cfg.Server.Port
-	log.Printf("Starting server on %s", serverAddr)
-	log.Printf("Environment: %s", cfg.Server.Env)
-	log.Printf("Database: %s:%s/%s", cfg.Database.Host, cfg.Database.Port, cfg.Database.DBName)
+	logger.Info("starting server",
+		"addr", serverAddr,
+		"env", cfg.Server.Env,
+		"version", version.Version,
+		"commit", version.Commit,
+		"file_storage", fmt.Sprintf("%T", store),
+		"log_level", cfg.Log.Level.String(),
+		"tracing_exporter", cfg.Tracing.Exporter,
+	)
 
 	if err := http.ListenAndServe(serverAddr, handler); err != nil {
-		log.Fatal("Server failed to start:", err)
+		fatal(logger, "server failed to start", err)
+	}
+}
+
+// loadKeyring builds the JWT keyring from the current key and, during a
+// rotation, the previous one.
+func loadKeyring(cfg config.JWTConfig) (*auth.Keyring, error) {
+	loadKey := func(id, secret, file string) (*auth.Key, error) {
+		switch {
+		case file != "":
+			return auth.LoadKeyFile(id, file)
+		case secret != "":
+			return auth.NewHMACKey(id, []byte(secret)), nil
+		}
+		return nil, nil
+	}
+
+	current, err := loadKey(cfg.KeyID, cfg.Secret, cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, err
 	}
+	previous, err := loadKey(cfg.PreviousKeyID, cfg.PreviousSecret, cfg.PreviousKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewKeyring(current, previous, cfg.PreviousKeyExpiresAt)
+}
+
+// serveMetrics serves /metrics on its own listener so it can be kept off the
+// public interface.
+func serveMetrics(logger *slog.Logger, cfg config.MetricsConfig) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(cfg.Token))
+
+	logger.Info("serving metrics", "addr", cfg.Addr)
+	if err := http.ListenAndServe(cfg.Addr, mux); err != nil {
+		fatal(logger, "metrics server failed", err)
+	}
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
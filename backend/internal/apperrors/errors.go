@@ -0,0 +1,147 @@
+// Package apperrors defines the domain errors shared by repositories,
+// services and handlers. Each error carries a kind, which decides the HTTP
+// status, and a stable code that clients can match on instead of messages.
+package apperrors
+
+import (
+	"errors"
+)
+
+// Error kinds. Match them with errors.Is.
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// Code is a stable, machine-readable error identifier.
+type Code string
+
+const (
+	CodeBadRequest           Code = "BAD_REQUEST"
+	CodeValidationFailed     Code = "VALIDATION_FAILED"
+	CodeInvalidJSON          Code = "INVALID_JSON"
+	CodeUnknownField         Code = "UNKNOWN_FIELD"
+	CodePayloadTooLarge      Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeUnauthorized         Code = "UNAUTHORIZED"
+	CodeForbidden            Code = "FORBIDDEN"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeConflict             Code = "CONFLICT"
+	CodeGone                 Code = "GONE"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodeAccountLocked        Code = "ACCOUNT_LOCKED"
+	CodeInternal             Code = "INTERNAL_ERROR"
+	CodeUpstream             Code = "UPSTREAM_ERROR"
+
+	CodeAuthRequired         Code = "AUTH_REQUIRED"
+	CodeTokenExpired         Code = "TOKEN_EXPIRED"
+	CodeInvalidToken         Code = "INVALID_TOKEN"
+	CodeAdminRequired        Code = "ADMIN_REQUIRED"
+	CodeInvalidCredentials   Code = "INVALID_CREDENTIALS"
+	CodeEmailNotVerified     Code = "EMAIL_NOT_VERIFIED"
+	CodeInvalidEmailDomain   Code = "INVALID_EMAIL_DOMAIN"
+	CodeEmailTaken           Code = "EMAIL_TAKEN"
+	CodeStudentIDTaken       Code = "STUDENT_ID_TAKEN"
+	CodeInvalidPhoneNumber   Code = "INVALID_PHONE_NUMBER"
+	CodePhoneAlreadyVerified Code = "PHONE_ALREADY_VERIFIED"
+	CodeInvalidCode          Code = "INVALID_VERIFICATION_CODE"
+	CodeUserNotFound         Code = "USER_NOT_FOUND"
+	CodeDocumentNotFound     Code = "DOCUMENT_NOT_FOUND"
+
+	CodeTripNotFound          Code = "TRIP_NOT_FOUND"
+	CodeTripFull              Code = "TRIP_FULL"
+	CodeAlreadyJoined         Code = "ALREADY_JOINED"
+	CodeNotParticipant        Code = "NOT_A_PARTICIPANT"
+	CodeNotTripOwner          Code = "NOT_TRIP_OWNER"
+	CodeRequestNotFound       Code = "REQUEST_NOT_FOUND"
+	CodeRequestAlreadyMatched Code = "REQUEST_ALREADY_MATCHED"
+	CodeRequestNotPending     Code = "REQUEST_NOT_PENDING"
+	CodeRequestNotMatched     Code = "REQUEST_NOT_MATCHED"
+	CodeNotRequestOwner       Code = "NOT_REQUEST_OWNER"
+	CodeInvalidTransition     Code = "INVALID_STATUS_TRANSITION"
+
+	CodePaymentNotFound Code = "PAYMENT_NOT_FOUND"
+	CodePaymentExists   Code = "PAYMENT_EXISTS"
+	CodePaymentFinished Code = "PAYMENT_FINISHED"
+	CodePaymentDeclined Code = "PAYMENT_DECLINED"
+
+	CodePayoutNotFound      Code = "PAYOUT_NOT_FOUND"
+	CodePayoutReviewed      Code = "PAYOUT_ALREADY_REVIEWED"
+	CodeInsufficientBalance Code = "INSUFFICIENT_BALANCE"
+
+	CodeLocationNotFound Code = "LOCATION_NOT_FOUND"
+	CodeNotInTransit     Code = "DELIVERY_NOT_IN_TRANSIT"
+
+	CodeHandoverCodeNotFound Code = "HANDOVER_CODE_NOT_FOUND"
+	CodeInvalidHandoverCode  Code = "INVALID_HANDOVER_CODE"
+	CodeHandoverCodeLocked   Code = "HANDOVER_CODE_LOCKED"
+
+	CodeDisputeNotFound     Code = "DISPUTE_NOT_FOUND"
+	CodeDisputeExists       Code = "DISPUTE_EXISTS"
+	CodeDisputeFinished     Code = "DISPUTE_FINISHED"
+	CodeDisputeWindowClosed Code = "DISPUTE_WINDOW_CLOSED"
+	CodeNothingToRefund     Code = "NOTHING_TO_REFUND"
+	CodeAccountBanned       Code = "ACCOUNT_BANNED"
+
+	CodeUserBlocked    Code = "USER_BLOCKED"
+	CodeReportNotFound Code = "REPORT_NOT_FOUND"
+	CodeReportExists   Code = "REPORT_EXISTS"
+	CodeReportReviewed Code = "REPORT_ALREADY_REVIEWED"
+)
+
+// Error is a domain error with a kind, a stable code and a client-safe
+// message. Err optionally records the underlying cause.
+type Error struct {
+	Kind    error
+	Code    Code
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+func New(kind error, code Code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func InvalidInput(code Code, message string) *Error {
+	return New(ErrInvalidInput, code, message)
+}
+
+func Unauthorized(code Code, message string) *Error {
+	return New(ErrUnauthorized, code, message)
+}
+
+func Forbidden(code Code, message string) *Error {
+	return New(ErrForbidden, code, message)
+}
+
+func NotFound(code Code, message string) *Error {
+	return New(ErrNotFound, code, message)
+}
+
+func Conflict(code Code, message string) *Error {
+	return New(ErrConflict, code, message)
+}
+
+// HasCode reports whether err is, or wraps, an Error with code.
+func HasCode(err error, code Code) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
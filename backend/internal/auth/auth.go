@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"sync"
 	"time"
 
 	"campus-connect/internal/models"
@@ -20,19 +21,25 @@ type Claims struct {
 	UserID             uuid.UUID                 `json:"userId"`
 	Email              string                    `json:"email"`
 	VerificationStatus models.VerificationStatus `json:"verificationStatus"`
+	Role               models.UserRole           `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type AuthService struct {
-	jwtSecret []byte
+	keyring *Keyring
 }
 
-func NewAuthService(jwtSecret string) *AuthService {
+func NewAuthService(keyring *Keyring) *AuthService {
 	return &AuthService{
-		jwtSecret: []byte(jwtSecret),
+		keyring: keyring,
 	}
 }
 
+// Keyring returns the keys tokens are signed and verified with.
+func (a *AuthService) Keyring() *Keyring {
+	return a.keyring
+}
+
 func (a *AuthService) HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -45,6 +52,22 @@ func (a *AuthService) ComparePassword(password, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
+// dummyPasswordHash is a hash of no user's password, made on first use.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("campus-connect/no-such-user"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// CompareNoPassword does the work of ComparePassword for a sign-in with no
+// matching account, so it takes as long as a wrong password and does not
+// reveal which accounts exist.
+func (a *AuthService) CompareNoPassword(password string) {
+	bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+}
+
 func (a *AuthService) GenerateToken(user *models.User) (string, error) {
 	expirationTime := time.Now().Add(7 * 24 * time.Hour) // 7 days
 
@@ -52,6 +75,7 @@ func (a *AuthService) GenerateToken(user *models.User) (string, error) {
 		UserID:             user.ID,
 		Email:              user.Email,
 		VerificationStatus: user.VerificationStatus,
+		Role:               user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -59,17 +83,13 @@ func (a *AuthService) GenerateToken(user *models.User) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(a.jwtSecret)
+	return a.keyring.Sign(claims)
 }
 
 func (a *AuthService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return a.jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, a.keyring.keyFunc,
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}),
+	)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
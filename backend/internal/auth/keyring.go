@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a JWT signing key. HMAC keys sign and verify with the same secret;
+// RSA and Ed25519 keys sign with the private key, and their public halves are
+// published through the JWKS endpoint.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	signKey   any
+	verifyKey any
+}
+
+// NewHMACKey returns an HS256 key. When id is empty, it is derived from the
+// secret.
+func NewHMACKey(id string, secret []byte) *Key {
+	if id == "" {
+		sum := sha256.Sum256(secret)
+		id = "hs-" + hex.EncodeToString(sum[:8])
+	}
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// LoadKeyFile reads a PEM encoded RSA (PKCS #1 or #8) or Ed25519 (PKCS #8)
+// private key and returns an RS256 or EdDSA key. When id is empty, it is
+// derived from the public key.
+func LoadKeyFile(id, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+
+	var private any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("signing key %s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+
+	key := &Key{ID: id, signKey: private}
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("signing key %s: RSA keys must be at least 2048 bits", path)
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.verifyKey = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.verifyKey = k.Public()
+	default:
+		return nil, fmt.Errorf("signing key %s: only RSA and Ed25519 keys are supported", path)
+	}
+
+	if key.ID == "" {
+		der, err := x509.MarshalPKIXPublicKey(key.verifyKey)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(der)
+		key.ID = base64.RawURLEncoding.EncodeToString(sum[:12])
+	}
+	return key, nil
+}
+
+// Keyring signs tokens with the current key and verifies tokens signed by the
+// current or previous key. The previous key is retired at a fixed time:
+// until then it verifies tokens that expire by that time, and afterwards
+// nothing. Claims such as iat are chosen by whoever holds the key, so only
+// this configured time bounds what a leaked previous key can sign.
+type Keyring struct {
+	current           *Key
+	previous          *Key
+	previousExpiresAt time.Time
+	now               func() time.Time
+}
+
+// NewKeyring returns a keyring signing with current. previous may be nil;
+// when set, previousExpiresAt is required.
+func NewKeyring(current, previous *Key, previousExpiresAt time.Time) (*Keyring, error) {
+	if current == nil {
+		return nil, errors.New("a current signing key is required")
+	}
+	if previous != nil && previous.ID == current.ID {
+		return nil, fmt.Errorf("previous signing key has the same key ID %q as the current key", current.ID)
+	}
+	if previous != nil && previousExpiresAt.IsZero() {
+		return nil, errors.New("previous signing key needs an expiry time")
+	}
+	return &Keyring{current: current, previous: previous, previousExpiresAt: previousExpiresAt, now: time.Now}, nil
+}
+
+// Sign returns a token for claims signed with the current key, with the key
+// ID in its kid header.
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.current.Method, claims)
+	token.Header["kid"] = k.current.ID
+	return token.SignedString(k.current.signKey)
+}
+
+// keyFunc picks the verification key for token by its kid header and checks
+// the algorithm matches the key. Tokens without a kid were issued before
+// keys had IDs and are checked against the HMAC keys.
+func (k *Keyring) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	var set jwt.VerificationKeySet
+	for _, key := range []*Key{k.current, k.previous} {
+		if key == nil || key.Method.Alg() != token.Method.Alg() {
+			continue
+		}
+		if kid != "" && key.ID != kid {
+			continue
+		}
+		if key == k.previous && !k.previousAccepts(token) {
+			continue
+		}
+		set.Keys = append(set.Keys, key.verifyKey)
+	}
+	if len(set.Keys) == 0 {
+		return nil, ErrInvalidToken
+	}
+	return set, nil
+}
+
+// previousActive reports whether the previous key is set and not yet retired.
+func (k *Keyring) previousActive() bool {
+	return k.previous != nil && k.now().Before(k.previousExpiresAt)
+}
+
+// previousAccepts reports whether a token signed by the previous key may be
+// verified with it: the key must still be active and the token must expire
+// no later than the key.
+func (k *Keyring) previousAccepts(token *jwt.Token) bool {
+	if !k.previousActive() {
+		return false
+	}
+	expiresAt, err := token.Claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return false
+	}
+	return !expiresAt.After(k.previousExpiresAt)
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that verify tokens from this keyring. HMAC
+// keys are secret and never included, nor is a retired previous key.
+func (k *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range []*Key{k.current, k.previous} {
+		if key == nil || (key == k.previous && !k.previousActive()) {
+			continue
+		}
+		jwk := JWK{Use: "sig", Kid: key.ID, Alg: key.Method.Alg()}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// writeKeyFile writes a PEM block of the given type to a temporary file.
+func writeKeyFile(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func ed25519KeyFile(t *testing.T) string {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeKeyFile(t, "PRIVATE KEY", der)
+}
+
+func rsaKeyFile(t *testing.T, bits int) string {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeKeyFile(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private))
+}
+
+func loadKey(t *testing.T, id, path string) *Key {
+	t.Helper()
+	key, err := LoadKeyFile(id, path)
+	if err != nil {
+		t.Fatalf("LoadKeyFile() error = %v", err)
+	}
+	return key
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	edPath := ed25519KeyFile(t)
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		id         string
+		path       string
+		wantMethod string
+		wantID     string
+		wantErr    bool
+	}{
+		{name: "Ed25519", path: edPath, wantMethod: "EdDSA"},
+		{name: "Ed25519 with ID", id: "2026-10", path: edPath, wantMethod: "EdDSA", wantID: "2026-10"},
+		{name: "RSA", path: rsaKeyFile(t, 2048), wantMethod: "RS256"},
+		{name: "short RSA", path: rsaKeyFile(t, 1024), wantErr: true},
+		{name: "not PEM", path: notPEM, wantErr: true},
+		{name: "missing file", path: filepath.Join(t.TempDir(), "missing.pem"), wantErr: true},
+		{name: "wrong PEM block", path: writeKeyFile(t, "CERTIFICATE", []byte{1}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := LoadKeyFile(tt.id, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadKeyFile() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if key.Method.Alg() != tt.wantMethod {
+				t.Errorf("Method = %s, want %s", key.Method.Alg(), tt.wantMethod)
+			}
+			if tt.wantID != "" && key.ID != tt.wantID {
+				t.Errorf("ID = %q, want %q", key.ID, tt.wantID)
+			}
+			if key.ID == "" {
+				t.Error("ID is empty, want one derived from the public key")
+			}
+		})
+	}
+
+	// A derived ID is stable, so every instance publishes the same kid
+	if a, b := loadKey(t, "", edPath), loadKey(t, "", edPath); a.ID != b.ID {
+		t.Errorf("derived IDs differ: %q and %q", a.ID, b.ID)
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	current := NewHMACKey("a", []byte("0123456789abcdef0123456789abcdef"))
+	if _, err := NewKeyring(nil, nil, time.Time{}); err == nil {
+		t.Error("NewKeyring(no current key) error = nil")
+	}
+	if _, err := NewKeyring(current, NewHMACKey("a", []byte("fedcba9876543210fedcba9876543210")), time.Now().Add(time.Hour)); err == nil {
+		t.Error("NewKeyring(same key IDs) error = nil")
+	}
+	if _, err := NewKeyring(current, NewHMACKey("b", []byte("fedcba9876543210fedcba9876543210")), time.Time{}); err == nil {
+		t.Error("NewKeyring(previous key without expiry) error = nil")
+	}
+}
+
+// signWith signs claims expiring after ttl with key, setting the kid header
+// to kid; an empty kid leaves the header out.
+func signWith(t *testing.T, key *Key, kid string, ttl time.Duration) string {
+	t.Helper()
+	claims := &Claims{
+		UserID: uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestKeyringVerify(t *testing.T) {
+	current := loadKey(t, "current", ed25519KeyFile(t))
+	previous := NewHMACKey("previous", []byte("0123456789abcdef0123456789abcdef"))
+	other := NewHMACKey("other", []byte("fedcba9876543210fedcba9876543210"))
+	retiresAt := time.Now().Add(time.Hour)
+
+	// A token signed with HS256 using the current key's public half, as in an
+	// algorithm confusion attack
+	public, err := x509.MarshalPKIXPublicKey(current.verifyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	confused := NewHMACKey("current", public)
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "current key", token: func(t *testing.T) string { return signWith(t, current, "current", time.Minute) }},
+		{name: "current key without kid", token: func(t *testing.T) string { return signWith(t, current, "", time.Minute) }},
+		{name: "previous key", token: func(t *testing.T) string { return signWith(t, previous, "previous", 30*time.Minute) }},
+		{name: "previous key without kid", token: func(t *testing.T) string { return signWith(t, previous, "", 30*time.Minute) }},
+		{name: "previous key outliving it", token: func(t *testing.T) string { return signWith(t, previous, "previous", 2*time.Hour) },
+			wantErr: ErrInvalidToken},
+		{name: "previous key once retired", token: func(t *testing.T) string { return signWith(t, previous, "previous", 30*time.Minute) },
+			now: retiresAt.Add(time.Minute), wantErr: ErrInvalidToken},
+		{name: "kid of another key", token: func(t *testing.T) string { return signWith(t, previous, "current", 30*time.Minute) },
+			wantErr: ErrInvalidToken},
+		{name: "unknown key", token: func(t *testing.T) string { return signWith(t, other, "other", time.Minute) },
+			wantErr: ErrInvalidToken},
+		{name: "algorithm confusion", token: func(t *testing.T) string { return signWith(t, confused, "current", time.Minute) },
+			wantErr: ErrInvalidToken},
+		{name: "expired", token: func(t *testing.T) string { return signWith(t, current, "current", -time.Minute) },
+			wantErr: ErrTokenExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(current, previous, retiresAt)
+			if err != nil {
+				t.Fatalf("NewKeyring() error = %v", err)
+			}
+			if !tt.now.IsZero() {
+				keyring.now = func() time.Time { return tt.now }
+			}
+
+			_, err = NewAuthService(keyring).ValidateToken(tt.token(t))
+			if err != tt.wantErr {
+				t.Errorf("ValidateToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyringSign(t *testing.T) {
+	current := loadKey(t, "current", ed25519KeyFile(t))
+	keyring, err := NewKeyring(current, nil, time.Time{})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	signed, err := keyring.Sign(jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	token, _, err := jwt.NewParser().ParseUnverified(signed, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("parse signed token: %v", err)
+	}
+	if token.Header["kid"] != "current" || token.Header["alg"] != "EdDSA" {
+		t.Errorf("token header = %v, want kid current and alg EdDSA", token.Header)
+	}
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	ed := loadKey(t, "ed", ed25519KeyFile(t))
+	rs := loadKey(t, "rs", rsaKeyFile(t, 2048))
+	hmac := NewHMACKey("hs", []byte("0123456789abcdef0123456789abcdef"))
+	retiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		current  *Key
+		previous *Key
+		now      time.Time
+		wantKids []string
+	}{
+		{name: "HMAC only", current: hmac},
+		{name: "current only", current: ed, wantKids: []string{"ed"}},
+		{name: "with previous", current: ed, previous: rs, wantKids: []string{"ed", "rs"}},
+		{name: "previous retired", current: ed, previous: rs, now: retiresAt, wantKids: []string{"ed"}},
+		{name: "HMAC previous", current: rs, previous: hmac, wantKids: []string{"rs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.current, tt.previous, retiresAt)
+			if err != nil {
+				t.Fatalf("NewKeyring() error = %v", err)
+			}
+			if !tt.now.IsZero() {
+				keyring.now = func() time.Time { return tt.now }
+			}
+
+			set := keyring.JWKS()
+			var kids []string
+			for _, key := range set.Keys {
+				kids = append(kids, key.Kid)
+			}
+			if len(kids) != len(tt.wantKids) {
+				t.Fatalf("JWKS() kids = %v, want %v", kids, tt.wantKids)
+			}
+			for i := range kids {
+				if kids[i] != tt.wantKids[i] {
+					t.Errorf("JWKS() kids = %v, want %v", kids, tt.wantKids)
+				}
+			}
+			for _, key := range set.Keys {
+				if (key.Kty == "RSA" && (key.N == "" || key.E == "")) || (key.Kty == "OKP" && key.X == "") {
+					t.Errorf("JWKS() key %s is missing its public key", key.Kid)
+				}
+			}
+		})
+	}
+}
@@ -1,11 +1,20 @@
 package config
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"campus-connect/internal/database"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/ratelimit"
 	"campus-connect/internal/services"
+	"campus-connect/internal/tracing"
 
 	"github.com/joho/godotenv"
 )
@@ -15,8 +24,25 @@ type Config struct {
 	Database   database.Config
 	JWT        JWTConfig
 	Cloudinary services.CloudinaryConfig
+	Storage    services.StorageConfig
 	Redis      RedisConfig
 	Brevo      BrevoConfig
+	SMS        SMSConfig
+	Log        LogConfig
+	Metrics    MetricsConfig
+	Tracing    tracing.Config
+	RateLimit  RateLimitConfig
+	Lockout    services.LockoutConfig
+	CORS       CORSConfig
+	Security   SecurityConfig
+	Ledger     LedgerConfig
+	Paystack   PaystackConfig
+	Payments   services.PaymentConfig
+	Pricing    services.PricingConfig
+	Tracking   services.TrackingConfig
+	Disputes   services.DisputeConfig
+
+	settings []setting
 }
 
 type ServerConfig struct {
@@ -25,8 +51,19 @@ type ServerConfig struct {
 	Env  string
 }
 
+// JWTConfig selects the token signing key: an HS256 Secret or a PEM RSA or
+// Ed25519 PrivateKeyFile. During a rotation, the previous key is set through
+// the Previous fields and keeps verifying tokens that expire by
+// PreviousKeyExpiresAt, until that time. Key IDs are derived from the keys
+// when not set.
 type JWTConfig struct {
-	Secret string
+	Secret               string
+	PrivateKeyFile       string
+	KeyID                string
+	PreviousSecret       string
+	PreviousKeyFile      string
+	PreviousKeyID        string
+	PreviousKeyExpiresAt time.Time
 }
 
 type RedisConfig struct {
@@ -41,59 +78,431 @@ type BrevoConfig struct {
 	SenderEmail string
 }
 
-func Load() (*Config, error) {
+type SMSConfig struct {
+	SenderID string
+}
+
+type LogConfig struct {
+	Level slog.Level
+}
+
+// MetricsConfig controls how /metrics is exposed. With Addr set, metrics are
+// served on a separate listener, typically bound to a private interface.
+// Otherwise they are served on the main router only when Token is set, and
+// scrapers must send it as a bearer token.
+type MetricsConfig struct {
+	Addr  string
+	Token string
+}
+
+// RateLimitConfig sets per-route-group limits in "requests/window" form.
+// Store is "redis" or "memory"; the memory store is per process and meant for
+// tests and single-instance development.
+type RateLimitConfig struct {
+	Store     string
+	Auth      ratelimit.Limit
+	Verify    ratelimit.Limit
+	PhoneCode ratelimit.Limit
+	Tracking  ratelimit.Limit
+}
+
+// LedgerConfig controls delivery fee settlement. PlatformFeeBPS is the share
+// of each released fee kept by the platform, in basis points.
+type LedgerConfig struct {
+	PlatformFeeBPS int
+}
+
+// PaystackConfig enables mobile money payments when SecretKey is set. Point
+// BaseURL at cmd/fakepay to test payments locally.
+type PaystackConfig struct {
+	SecretKey string
+	BaseURL   string
+}
+
+// CORSConfig lists the browser origins allowed to call the API. Origins may
+// use a single wildcard for subdomains, e.g. https://*.example.com.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// Validate rejects malformed origins and settings that would let any site
+// make credentialed requests. Origins must be scheme://host[:port]; a
+// wildcard may only stand for the leftmost label of a domain with at least
+// two labels, as in https://*.example.com. The bare "*" allows every origin
+// and cannot be combined with credentials.
+func (c CORSConfig) Validate() error {
+	if c.AllowCredentials && len(c.AllowedOrigins) == 0 {
+		return errors.New("CORS_ALLOWED_ORIGINS must be set when CORS_ALLOW_CREDENTIALS is true")
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return errors.New("CORS_ALLOWED_ORIGINS: wildcard origin \"*\" cannot be used with CORS_ALLOW_CREDENTIALS")
+			}
+			continue
+		}
+		if err := validateOrigin(origin); err != nil {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateOrigin(origin string) error {
+	scheme, hostPort, ok := strings.Cut(origin, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return fmt.Errorf("origin %q must start with http:// or https://", origin)
+	}
+	host := hostPort
+	if h, port, err := net.SplitHostPort(hostPort); err == nil {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("origin %q has an invalid port", origin)
+		}
+		host = h
+	}
+	if host == "" || strings.ContainsAny(host, "/?#@") {
+		return fmt.Errorf("origin %q must be scheme://host[:port]", origin)
+	}
+	if !strings.Contains(host, "*") {
+		return nil
+	}
+
+	domain, ok := strings.CutPrefix(host, "*.")
+	labels := strings.Split(domain, ".")
+	if !ok || len(labels) < 2 || strings.Contains(domain, "*") {
+		return fmt.Errorf("origin %q may only use a wildcard for subdomains of a domain, e.g. https://*.example.com", origin)
+	}
+	for _, label := range labels {
+		if label == "" {
+			return fmt.Errorf("origin %q has an empty domain label", origin)
+		}
+	}
+	return nil
+}
+
+// SecurityConfig sets the headers added to every response. Empty values and
+// a zero HSTSMaxAge leave the corresponding header out.
+type SecurityConfig struct {
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	FrameOptions          string
+	ReferrerPolicy        string
+	ContentSecurityPolicy string
+}
+
+// Environments the server knows about. Development relaxes the settings
+// that are required everywhere else.
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// MinSecretLength is the shortest JWT_SECRET or STORAGE_SIGNING_SECRET
+// accepted.
+const MinSecretLength = 32
+
+// Load reads the configuration from the environment (including a .env file),
+// falling back to the YAML file at path when it is not empty, then to
+// defaults. It reports every invalid or missing setting in one error.
+func Load(path string) (*Config, error) {
 
 	_ = godotenv.Load()
 
+	src, err := newSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	port := src.string("PORT", "8080")
+	env := src.string("GO_ENV", EnvDevelopment)
+	jwtSecret := src.secret("JWT_SECRET", "")
+
+	logLevel, err := logging.ParseLevel(src.string("LOG_LEVEL", "info"))
+	if err != nil {
+		src.fail("LOG_LEVEL", err)
+	}
+
+	rateLimit := RateLimitConfig{Store: src.string("RATE_LIMIT_STORE", "redis")}
+	for _, l := range []struct {
+		key, def string
+		dst      *ratelimit.Limit
+	}{
+		{"RATE_LIMIT_AUTH", "20/1m", &rateLimit.Auth},
+		{"RATE_LIMIT_VERIFY", "10/10m", &rateLimit.Verify},
+		{"RATE_LIMIT_PHONE_CODE", "3/10m", &rateLimit.PhoneCode},
+		{"RATE_LIMIT_TRACKING", "60/1m", &rateLimit.Tracking},
+	} {
+		if *l.dst, err = ratelimit.ParseLimit(src.string(l.key, l.def)); err != nil {
+			src.fail(l.key, err)
+		}
+	}
+
+	// Local frontends are allowed by default in development only; other
+	// environments must list their origins
+	defaultOrigins := ""
+	defaultHSTS := 365 * 24 * time.Hour
+	if env == EnvDevelopment {
+		defaultOrigins = "http://localhost:3000"
+		defaultHSTS = 0
+	}
+
 	config := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Host: getEnv("HOST", "0.0.0.0"),
-			Env:  getEnv("GO_ENV", "development"),
+			Port: port,
+			Host: src.string("HOST", "0.0.0.0"),
+			Env:  env,
 		},
 		Database: database.Config{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "campus_connect"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+			Host:     src.string("DB_HOST", "localhost"),
+			Port:     src.string("DB_PORT", "5432"),
+			User:     src.string("DB_USER", "postgres"),
+			Password: src.secret("DB_PASSWORD", ""),
+			DBName:   src.string("DB_NAME", "campus_connect"),
+			SSLMode:  src.string("DB_SSL_MODE", "disable"),
+
+			MigrationsPath: src.string("MIGRATIONS_PATH", "./migrations"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+			Secret:               jwtSecret,
+			PrivateKeyFile:       src.string("JWT_PRIVATE_KEY_FILE", ""),
+			KeyID:                src.string("JWT_KEY_ID", ""),
+			PreviousSecret:       src.secret("JWT_PREVIOUS_SECRET", ""),
+			PreviousKeyFile:      src.string("JWT_PREVIOUS_KEY_FILE", ""),
+			PreviousKeyID:        src.string("JWT_PREVIOUS_KEY_ID", ""),
+			PreviousKeyExpiresAt: src.time("JWT_PREVIOUS_KEY_EXPIRES_AT"),
 		},
 		Cloudinary: services.CloudinaryConfig{
-			CloudName: getEnv("CLOUDINARY_CLOUD_NAME", ""),
-			APIKey:    getEnv("CLOUDINARY_API_KEY", ""),
-			APISecret: getEnv("CLOUDINARY_API_SECRET", ""),
+			CloudName: src.string("CLOUDINARY_CLOUD_NAME", ""),
+			APIKey:    src.string("CLOUDINARY_API_KEY", ""),
+			APISecret: src.secret("CLOUDINARY_API_SECRET", ""),
+		},
+		Storage: services.StorageConfig{
+			Driver:        src.string("STORAGE_DRIVER", ""),
+			LocalDir:      src.string("STORAGE_LOCAL_DIR", "./uploads"),
+			PublicBaseURL: src.string("STORAGE_PUBLIC_BASE_URL", "http://localhost:"+port),
+			SigningSecret: src.secret("STORAGE_SIGNING_SECRET", ""),
+			URLTTL:        src.renamedDuration("STORAGE_URL_TTL", "STORAGE_URL_TTL_MINUTES", time.Minute, time.Hour),
 		},
 		Redis: RedisConfig{
-			Addr:     getEnv("REDIS_ADDR", "127.0.0.1:6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 0),
+			Addr:     src.string("REDIS_ADDR", "127.0.0.1:6379"),
+			Password: src.secret("REDIS_PASSWORD", ""),
+			DB:       src.int("REDIS_DB", 0),
 		},
 		Brevo: BrevoConfig{
-			APIKey:      getEnv("BREVO_API_KEY", ""),
-			SenderName:  getEnv("BREVO_SENDER_NAME", "CampusConnect"),
-			SenderEmail: getEnv("BREVO_SENDER_EMAIL", "no-reply@campusconnect.knust.edu.gh"),
+			APIKey:      src.secret("BREVO_API_KEY", ""),
+			SenderName:  src.string("BREVO_SENDER_NAME", "CampusConnect"),
+			SenderEmail: src.string("BREVO_SENDER_EMAIL", "no-reply@campusconnect.knust.edu.gh"),
+		},
+		SMS: SMSConfig{
+			SenderID: src.string("SMS_SENDER_ID", "CampusConn"),
+		},
+		Log: LogConfig{
+			Level: logLevel,
 		},
+		Metrics: MetricsConfig{
+			Addr:  src.string("METRICS_ADDR", ""),
+			Token: src.secret("METRICS_TOKEN", ""),
+		},
+		Tracing: tracing.Config{
+			Exporter:    src.string("TRACING_EXPORTER", tracing.ExporterNone),
+			Endpoint:    src.string("TRACING_OTLP_ENDPOINT", ""),
+			ServiceName: src.string("OTEL_SERVICE_NAME", "campus-connect-api"),
+			Environment: env,
+			SampleRatio: src.float("TRACING_SAMPLE_RATIO", 1.0),
+		},
+		RateLimit: rateLimit,
+		Lockout: services.LockoutConfig{
+			FreeAttempts:    src.int("LOGIN_FREE_ATTEMPTS", 3),
+			BaseDelay:       src.renamedDuration("LOGIN_BASE_DELAY", "LOGIN_BASE_DELAY_SECONDS", time.Second, time.Second),
+			MaxDelay:        src.renamedDuration("LOGIN_MAX_DELAY", "LOGIN_MAX_DELAY_SECONDS", time.Second, time.Minute),
+			MaxFailures:     src.int("LOGIN_MAX_FAILURES", 10),
+			IPMaxFailures:   src.int("LOGIN_IP_MAX_FAILURES", 50),
+			LockoutDuration: src.renamedDuration("LOGIN_LOCKOUT", "LOGIN_LOCKOUT_MINUTES", time.Minute, 15*time.Minute),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   src.list("CORS_ALLOWED_ORIGINS", defaultOrigins),
+			AllowedMethods:   src.list("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
+			AllowCredentials: src.bool("CORS_ALLOW_CREDENTIALS", true),
+			MaxAge:           src.durationOrSeconds("CORS_MAX_AGE", 5*time.Minute),
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            src.durationOrSeconds("SECURITY_HSTS_MAX_AGE", defaultHSTS),
+			HSTSIncludeSubdomains: src.bool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", false),
+			FrameOptions:          src.string("SECURITY_FRAME_OPTIONS", "DENY"),
+			ReferrerPolicy:        src.string("SECURITY_REFERRER_POLICY", "no-referrer"),
+			ContentSecurityPolicy: src.string("SECURITY_CSP", "default-src 'none'; frame-ancestors 'none'"),
+		},
+		Ledger: LedgerConfig{
+			PlatformFeeBPS: src.int("LEDGER_PLATFORM_FEE_BPS", 0),
+		},
+		Paystack: PaystackConfig{
+			SecretKey: src.secret("PAYSTACK_SECRET_KEY", ""),
+			BaseURL:   src.string("PAYSTACK_BASE_URL", services.DefaultPaystackURL),
+		},
+		Payments: services.PaymentConfig{
+			Currency:          src.string("PAYMENT_CURRENCY", "GHS"),
+			ReconcileInterval: src.duration("PAYMENT_RECONCILE_INTERVAL", time.Minute),
+			ReconcileAfter:    src.duration("PAYMENT_RECONCILE_AFTER", 5*time.Minute),
+			AbandonAfter:      src.duration("PAYMENT_ABANDON_AFTER", 30*time.Minute),
+		},
+		Pricing: services.PricingConfig{
+			Lookback:   src.duration("PRICING_LOOKBACK", 180*24*time.Hour),
+			MinSamples: src.int("PRICING_MIN_SAMPLES", 5),
+		},
+		Tracking: services.TrackingConfig{
+			PingTTL: src.duration("TRACKING_PING_TTL", 10*time.Minute),
+		},
+		Disputes: services.DisputeConfig{
+			FilingWindow:   src.duration("DISPUTE_FILING_WINDOW", 14*24*time.Hour),
+			ReviewWindow:   src.duration("DISPUTE_REVIEW_WINDOW", 48*time.Hour),
+			ResponseWindow: src.duration("DISPUTE_RESPONSE_WINDOW", 72*time.Hour),
+			ResolveWindow:  src.duration("DISPUTE_RESOLVE_WINDOW", 7*24*time.Hour),
+			SweepInterval:  src.duration("DISPUTE_SWEEP_INTERVAL", 5*time.Minute),
+		},
+		settings: src.settings,
 	}
 
+	errs := append(src.errs, src.unknownKeys()...)
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
 	return config, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// validate checks settings that parsed but are out of range, inconsistent or
+// missing for the environment.
+func (c *Config) validate() []error {
+	var errs []error
+	require := func(key, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", key))
+		}
+	}
+
+	switch c.Server.Env {
+	case EnvDevelopment, EnvTest, EnvStaging, EnvProduction:
+	default:
+		errs = append(errs, fmt.Errorf("GO_ENV: unknown environment %q", c.Server.Env))
+	}
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT: %q is not a valid port", c.Server.Port))
+	}
+
+	switch {
+	case c.JWT.Secret == "" && c.JWT.PrivateKeyFile == "":
+		errs = append(errs, errors.New("JWT_SECRET or JWT_PRIVATE_KEY_FILE is required"))
+	case c.JWT.Secret != "" && c.JWT.PrivateKeyFile != "":
+		errs = append(errs, errors.New("set only one of JWT_SECRET and JWT_PRIVATE_KEY_FILE"))
+	}
+	if c.JWT.PreviousSecret != "" && c.JWT.PreviousKeyFile != "" {
+		errs = append(errs, errors.New("set only one of JWT_PREVIOUS_SECRET and JWT_PREVIOUS_KEY_FILE"))
+	}
+	if (c.JWT.PreviousSecret != "" || c.JWT.PreviousKeyFile != "") && c.JWT.PreviousKeyExpiresAt.IsZero() {
+		errs = append(errs, errors.New("JWT_PREVIOUS_KEY_EXPIRES_AT is required with a previous signing key"))
+	}
+	cloudinaryConfigured := c.Cloudinary.CloudName != "" && c.Cloudinary.APIKey != "" && c.Cloudinary.APISecret != ""
+	localStorage := c.Storage.Driver == "local" || (c.Storage.Driver == "" && !cloudinaryConfigured)
+	if localStorage && c.Storage.SigningSecret == "" {
+		errs = append(errs, errors.New("STORAGE_SIGNING_SECRET is required for local storage"))
+	}
+	for key, secret := range map[string]string{
+		"JWT_SECRET":             c.JWT.Secret,
+		"JWT_PREVIOUS_SECRET":    c.JWT.PreviousSecret,
+		"STORAGE_SIGNING_SECRET": c.Storage.SigningSecret,
+	} {
+		if secret != "" && len(secret) < MinSecretLength {
+			errs = append(errs, fmt.Errorf("%s must be at least %d characters", key, MinSecretLength))
+		}
+	}
+
+	if c.Server.Env != EnvDevelopment && c.Server.Env != EnvTest {
+		require("DB_PASSWORD", c.Database.Password)
+		require("BREVO_API_KEY", c.Brevo.APIKey)
+	}
+	if c.Server.Env == EnvProduction && c.Database.SSLMode == "disable" {
+		errs = append(errs, errors.New("DB_SSL_MODE must not be disable in production"))
 	}
-	return defaultValue
-}
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if valueStr := os.Getenv(key); valueStr != "" {
-		if value, err := strconv.Atoi(valueStr); err == nil {
-			return value
+	switch c.Storage.Driver {
+	case "", "cloudinary", "local":
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER: unknown driver %q", c.Storage.Driver))
+	}
+	if c.Storage.URLTTL <= 0 {
+		errs = append(errs, errors.New("STORAGE_URL_TTL must be positive"))
+	}
+
+	if c.Metrics.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Addr); err != nil {
+			errs = append(errs, fmt.Errorf("METRICS_ADDR: %w", err))
 		}
 	}
-	return defaultValue
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER: unknown exporter %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("TRACING_SAMPLE_RATIO must be between 0 and 1"))
+	}
+
+	if c.RateLimit.Store != "redis" && c.RateLimit.Store != "memory" {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE: unknown store %q", c.RateLimit.Store))
+	}
+	if c.Lockout.MaxFailures > 0 && c.Lockout.LockoutDuration <= 0 {
+		errs = append(errs, errors.New("LOGIN_LOCKOUT must be positive when LOGIN_MAX_FAILURES is set"))
+	}
+	if c.Ledger.PlatformFeeBPS < 0 || c.Ledger.PlatformFeeBPS > 10000 {
+		errs = append(errs, errors.New("LEDGER_PLATFORM_FEE_BPS must be between 0 and 10000"))
+	}
+	if c.Paystack.SecretKey != "" {
+		if u, err := url.Parse(c.Paystack.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("PAYSTACK_BASE_URL: %q is not an absolute URL", c.Paystack.BaseURL))
+		}
+		if len(c.Payments.Currency) != 3 {
+			errs = append(errs, fmt.Errorf("PAYMENT_CURRENCY: %q is not an ISO 4217 code", c.Payments.Currency))
+		}
+		if c.Payments.ReconcileInterval <= 0 {
+			errs = append(errs, errors.New("PAYMENT_RECONCILE_INTERVAL must be positive"))
+		}
+		if c.Payments.AbandonAfter <= c.Payments.ReconcileAfter {
+			errs = append(errs, errors.New("PAYMENT_ABANDON_AFTER must be longer than PAYMENT_RECONCILE_AFTER"))
+		}
+	}
+
+	if c.Pricing.Lookback <= 0 {
+		errs = append(errs, errors.New("PRICING_LOOKBACK must be positive"))
+	}
+	if c.Pricing.MinSamples < 1 {
+		errs = append(errs, errors.New("PRICING_MIN_SAMPLES must be at least 1"))
+	}
+	if c.Tracking.PingTTL <= 0 {
+		errs = append(errs, errors.New("TRACKING_PING_TTL must be positive"))
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"DISPUTE_FILING_WINDOW", c.Disputes.FilingWindow},
+		{"DISPUTE_REVIEW_WINDOW", c.Disputes.ReviewWindow},
+		{"DISPUTE_RESPONSE_WINDOW", c.Disputes.ResponseWindow},
+		{"DISPUTE_RESOLVE_WINDOW", c.Disputes.ResolveWindow},
+		{"DISPUTE_SWEEP_INTERVAL", c.Disputes.SweepInterval},
+	} {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", d.name))
+		}
+	}
+
+	if err := c.CORS.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
 }
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret        = "0123456789abcdef0123456789abcdef"
+	testSigningSecret = "fedcba9876543210fedcba9876543210"
+)
+
+// setEnv sets the settings a development config needs, then env on top. An
+// empty value unsets a setting.
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("STORAGE_SIGNING_SECRET", testSigningSecret)
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	production := map[string]string{
+		"GO_ENV":               "production",
+		"DB_PASSWORD":          "secret",
+		"DB_SSL_MODE":          "require",
+		"BREVO_API_KEY":        "key",
+		"CORS_ALLOWED_ORIGINS": "https://campusconnect.example.com",
+	}
+	with := func(base map[string]string, key, value string) map[string]string {
+		env := map[string]string{key: value}
+		for k, v := range base {
+			if k != key {
+				env[k] = v
+			}
+		}
+		return env
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr []string
+	}{
+		{name: "development defaults"},
+		{name: "production", env: production},
+		{name: "unknown environment", env: map[string]string{"GO_ENV": "prod"},
+			wantErr: []string{`GO_ENV: unknown environment "prod"`}},
+		{name: "bad port", env: map[string]string{"PORT": "70000"},
+			wantErr: []string{`PORT: "70000" is not a valid port`}},
+		{name: "no signing key", env: map[string]string{"JWT_SECRET": ""},
+			wantErr: []string{"JWT_SECRET or JWT_PRIVATE_KEY_FILE is required"}},
+		{name: "secret and key file", env: map[string]string{"JWT_PRIVATE_KEY_FILE": "jwt.pem"},
+			wantErr: []string{"set only one of JWT_SECRET and JWT_PRIVATE_KEY_FILE"}},
+		{name: "short secret", env: map[string]string{"JWT_SECRET": "short"},
+			wantErr: []string{"JWT_SECRET must be at least 32 characters"}},
+		{name: "previous key without expiry", env: map[string]string{"JWT_PREVIOUS_SECRET": testSigningSecret},
+			wantErr: []string{"JWT_PREVIOUS_KEY_EXPIRES_AT is required"}},
+		{name: "bad previous key expiry", env: map[string]string{"JWT_PREVIOUS_SECRET": testSigningSecret, "JWT_PREVIOUS_KEY_EXPIRES_AT": "tomorrow"},
+			wantErr: []string{"JWT_PREVIOUS_KEY_EXPIRES_AT: \"tomorrow\" is not an RFC 3339 time"}},
+		{name: "local storage without signing secret", env: map[string]string{"STORAGE_SIGNING_SECRET": ""},
+			wantErr: []string{"STORAGE_SIGNING_SECRET is required for local storage"}},
+		{name: "production secrets missing", env: with(with(production, "DB_PASSWORD", ""), "BREVO_API_KEY", ""),
+			wantErr: []string{"DB_PASSWORD is required", "BREVO_API_KEY is required"}},
+		{name: "production without TLS to the database", env: with(production, "DB_SSL_MODE", "disable"),
+			wantErr: []string{"DB_SSL_MODE must not be disable in production"}},
+		{name: "production without origins", env: with(production, "CORS_ALLOWED_ORIGINS", ""),
+			wantErr: []string{"CORS_ALLOWED_ORIGINS must be set"}},
+		{name: "parse errors", env: map[string]string{"REDIS_DB": "one", "PAYMENT_RECONCILE_INTERVAL": "5", "RATE_LIMIT_AUTH": "lots"},
+			wantErr: []string{`REDIS_DB: "one" is not an integer`, `PAYMENT_RECONCILE_INTERVAL: "5" is not a duration`, `RATE_LIMIT_AUTH: invalid rate limit "lots"`}},
+		{name: "out of range", env: map[string]string{"TRACING_SAMPLE_RATIO": "2", "LEDGER_PLATFORM_FEE_BPS": "-1", "PRICING_MIN_SAMPLES": "0"},
+			wantErr: []string{"TRACING_SAMPLE_RATIO must be between 0 and 1", "LEDGER_PLATFORM_FEE_BPS must be between 0 and 10000", "PRICING_MIN_SAMPLES must be at least 1"}},
+		{name: "payment windows out of order", env: map[string]string{"PAYSTACK_SECRET_KEY": "sk_test", "PAYMENT_RECONCILE_AFTER": "1h", "PAYMENT_ABANDON_AFTER": "30m"},
+			wantErr: []string{"PAYMENT_ABANDON_AFTER must be longer than PAYMENT_RECONCILE_AFTER"}},
+		{name: "zero dispute window", env: map[string]string{"DISPUTE_REVIEW_WINDOW": "0s"},
+			wantErr: []string{"DISPUTE_REVIEW_WINDOW must be positive"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			_, err := Load("")
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Load() error = nil, want %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	setEnv(t, map[string]string{"REDIS_DB": "3"})
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := `
+redis:
+  addr: redis.internal:6379
+  db: 1
+cors:
+  allowed:
+    origins:
+      - https://a.example.com
+      - https://*.b.example.com
+login:
+  lockout: 30m
+`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Redis.Addr != "redis.internal:6379" {
+		t.Errorf("Redis.Addr = %q, want the file's value", cfg.Redis.Addr)
+	}
+	if cfg.Redis.DB != 3 {
+		t.Errorf("Redis.DB = %d, want the environment's 3 over the file's 1", cfg.Redis.DB)
+	}
+	if got := strings.Join(cfg.CORS.AllowedOrigins, ","); got != "https://a.example.com,https://*.b.example.com" {
+		t.Errorf("CORS.AllowedOrigins = %q, want the file's list", got)
+	}
+	if cfg.Lockout.LockoutDuration != 30*time.Minute {
+		t.Errorf("Lockout.LockoutDuration = %s, want 30m", cfg.Lockout.LockoutDuration)
+	}
+}
+
+func TestLoadFileUnknownKey(t *testing.T) {
+	setEnv(t, nil)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("redis:\n  adress: localhost:6379\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "REDIS_ADRESS: unknown setting in config file") {
+		t.Errorf("Load() error = %v, want REDIS_ADRESS reported as unknown", err)
+	}
+}
+
+func TestLoadLegacyDurations(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want time.Duration
+	}{
+		{name: "default", want: 15 * time.Minute},
+		{name: "legacy minutes", env: map[string]string{"LOGIN_LOCKOUT_MINUTES": "30"}, want: 30 * time.Minute},
+		{name: "duration", env: map[string]string{"LOGIN_LOCKOUT": "1h"}, want: time.Hour},
+		{name: "duration wins", env: map[string]string{"LOGIN_LOCKOUT": "1h", "LOGIN_LOCKOUT_MINUTES": "30"}, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			cfg, err := Load("")
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Lockout.LockoutDuration != tt.want {
+				t.Errorf("Lockout.LockoutDuration = %s, want %s", cfg.Lockout.LockoutDuration, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		wantErr     bool
+	}{
+		{name: "exact origins", origins: []string{"https://example.com", "http://localhost:3000"}, credentials: true},
+		{name: "subdomain wildcard", origins: []string{"https://*.example.com"}, credentials: true},
+		{name: "any origin without credentials", origins: []string{"*"}},
+		{name: "any origin with credentials", origins: []string{"*"}, credentials: true, wantErr: true},
+		{name: "credentials without origins", credentials: true, wantErr: true},
+		{name: "no scheme", origins: []string{"example.com"}, wantErr: true},
+		{name: "path", origins: []string{"https://example.com/app"}, wantErr: true},
+		{name: "bad port", origins: []string{"https://example.com:99999"}, wantErr: true},
+		{name: "wildcard over a top-level domain", origins: []string{"https://*.com"}, wantErr: true},
+		{name: "wildcard inside a label", origins: []string{"https://app-*.example.com"}, wantErr: true},
+		{name: "empty label", origins: []string{"https://*.example..com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CORSConfig{AllowedOrigins: tt.origins, AllowCredentials: tt.credentials}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteEffective(t *testing.T) {
+	setEnv(t, map[string]string{"REDIS_DB": "2"})
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.WriteEffective(&buf); err != nil {
+		t.Fatalf("WriteEffective() error = %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, testSecret) {
+		t.Error("WriteEffective() printed JWT_SECRET")
+	}
+	for _, want := range []string{"JWT_SECRET=[redacted]", "REDIS_DB=2", "PORT=8080"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteEffective() output does not contain %q:\n%s", want, out)
+		}
+	}
+}
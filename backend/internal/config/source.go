@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Where a setting's effective value came from.
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+)
+
+// setting is one resolved configuration value, kept for --print-config.
+type setting struct {
+	key    string
+	value  string
+	source string
+	secret bool
+}
+
+// source resolves settings from the environment, then the optional YAML
+// file, then the default. Typed getters record parse errors instead of
+// falling back, so Load can report every problem at once.
+type source struct {
+	file     map[string]string
+	used     map[string]bool
+	settings []setting
+	errs     []error
+}
+
+// newSource reads the YAML file at path, if any. File keys are environment
+// variable names in lower case and may be nested on underscores, so
+//
+//	db:
+//	  host: localhost
+//
+// sets DB_HOST. Lists are joined with commas.
+func newSource(path string) (*source, error) {
+	s := &source{file: map[string]string{}, used: map[string]bool{}}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	flatten("", doc, s.file)
+	return s, nil
+}
+
+func flatten(prefix string, value any, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			name := strings.ToUpper(key)
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+			flatten(name, child, out)
+		}
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		out[prefix] = strings.Join(items, ",")
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+func (s *source) lookup(key, defaultValue string, secret bool) string {
+	s.used[key] = true
+	value, from := defaultValue, sourceDefault
+	if v, ok := s.file[key]; ok && v != "" {
+		value, from = v, sourceFile
+	}
+	if v := os.Getenv(key); v != "" {
+		value, from = v, sourceEnv
+	}
+	s.settings = append(s.settings, setting{key: key, value: value, source: from, secret: secret})
+	return value
+}
+
+// isSet reports whether key is set in the environment or the file.
+func (s *source) isSet(key string) bool {
+	s.used[key] = true
+	return os.Getenv(key) != "" || s.file[key] != ""
+}
+
+func (s *source) fail(key string, err error) {
+	s.errs = append(s.errs, fmt.Errorf("%s: %w", key, err))
+}
+
+func (s *source) string(key, defaultValue string) string {
+	return s.lookup(key, defaultValue, false)
+}
+
+// secret is like string, but the value is redacted by --print-config.
+func (s *source) secret(key, defaultValue string) string {
+	return s.lookup(key, defaultValue, true)
+}
+
+func (s *source) int(key string, defaultValue int) int {
+	raw := s.lookup(key, strconv.Itoa(defaultValue), false)
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		s.fail(key, fmt.Errorf("%q is not an integer", raw))
+		return defaultValue
+	}
+	return value
+}
+
+func (s *source) float(key string, defaultValue float64) float64 {
+	raw := s.lookup(key, strconv.FormatFloat(defaultValue, 'f', -1, 64), false)
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		s.fail(key, fmt.Errorf("%q is not a number", raw))
+		return defaultValue
+	}
+	return value
+}
+
+func (s *source) bool(key string, defaultValue bool) bool {
+	raw := s.lookup(key, strconv.FormatBool(defaultValue), false)
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		s.fail(key, fmt.Errorf("%q is not true or false", raw))
+		return defaultValue
+	}
+	return value
+}
+
+// duration parses a Go duration such as "90s", "15m" or "1h30m".
+func (s *source) duration(key string, defaultValue time.Duration) time.Duration {
+	raw := s.lookup(key, formatDuration(defaultValue), false)
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		s.fail(key, fmt.Errorf("%q is not a duration, e.g. 90s, 15m or 1h", raw))
+		return defaultValue
+	}
+	return value
+}
+
+// durationOrSeconds is like duration, but also accepts a bare number of
+// seconds, the format key took before it read durations.
+func (s *source) durationOrSeconds(key string, defaultValue time.Duration) time.Duration {
+	raw := s.lookup(key, formatDuration(defaultValue), false)
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		s.fail(key, fmt.Errorf("%q is not a duration, e.g. 90s, 15m or 1h", raw))
+		return defaultValue
+	}
+	return value
+}
+
+// renamedDuration reads key, falling back to legacyKey, the whole number of
+// unit it replaced, when only legacyKey is set.
+func (s *source) renamedDuration(key, legacyKey string, unit, defaultValue time.Duration) time.Duration {
+	if s.isSet(key) || !s.isSet(legacyKey) {
+		return s.duration(key, defaultValue)
+	}
+	value := time.Duration(s.int(legacyKey, int(defaultValue/unit))) * unit
+	s.settings = append(s.settings, setting{key: key, value: formatDuration(value), source: legacyKey})
+	return value
+}
+
+// time parses an RFC 3339 time such as "2026-11-01T00:00:00Z". It returns the
+// zero time when key is not set.
+func (s *source) time(key string) time.Time {
+	raw := s.lookup(key, "", false)
+	if raw == "" {
+		return time.Time{}
+	}
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		s.fail(key, fmt.Errorf("%q is not an RFC 3339 time, e.g. 2026-11-01T00:00:00Z", raw))
+	}
+	return value
+}
+
+// list splits a comma-separated value, dropping empty entries.
+func (s *source) list(key, defaultValue string) []string {
+	var values []string
+	for _, v := range strings.Split(s.lookup(key, defaultValue, false), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// unknownKeys reports file keys that no setting reads, which are usually
+// typos.
+func (s *source) unknownKeys() []error {
+	var errs []error
+	for key := range s.file {
+		if !s.used[key] {
+			errs = append(errs, fmt.Errorf("%s: unknown setting in config file", key))
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
+
+// formatDuration drops the zero units time.Duration.String adds, so 1h is
+// printed as "1h" rather than "1h0m0s".
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// WriteEffective writes the resolved settings in .env format, sorted by
+// name, with the source of each value and secrets redacted.
+func (c *Config) WriteEffective(w io.Writer) error {
+	settings := append([]setting(nil), c.settings...)
+	sort.SliceStable(settings, func(i, j int) bool { return settings[i].key < settings[j].key })
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	seen := map[string]bool{}
+	for _, st := range settings {
+		if seen[st.key] {
+			continue
+		}
+		seen[st.key] = true
+		value := st.value
+		if st.secret && value != "" {
+			value = "[redacted]"
+		} else if strings.ContainsAny(value, " #'\"") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(tw, "%s=%s\t# %s\n", st.key, value, st.source)
+	}
+	return tw.Flush()
+}
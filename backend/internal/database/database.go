@@ -3,7 +3,7 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -13,6 +13,7 @@ import (
 
 type DB struct {
 	*sql.DB
+	name string
 }
 
 type Config struct {
@@ -22,6 +23,8 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// MigrationsPath is the directory holding the migration files.
+	MigrationsPath string
 }
 
 func NewConnection(config Config) (*DB, error) {
@@ -37,8 +40,8 @@ func NewConnection(config Config) (*DB, error) {
 		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
 
-	log.Println("Successfully connected to database")
-	return &DB{db}, nil
+	slog.Info("connected to database", "host", config.Host, "port", config.Port, "database", config.DBName)
+	return &DB{DB: db, name: config.DBName}, nil
 }
 
 func (db *DB) RunMigrations(migrationsPath string) error {
@@ -60,7 +63,7 @@ func (db *DB) RunMigrations(migrationsPath string) error {
 		return fmt.Errorf("could not run migrations: %w", err)
 	}
 
-	log.Println("Migrations ran successfully")
+	slog.Info("migrations ran successfully")
 	return nil
 }
 
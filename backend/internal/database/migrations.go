@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_.+\.up\.sql$`)
+
+// MigrationStatus is the schema version recorded by golang-migrate.
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+}
+
+// MigrationStatus reads the applied schema version. Version is 0 when no
+// migration has run yet.
+func (db *DB) MigrationStatus(ctx context.Context) (MigrationStatus, error) {
+	var status MigrationStatus
+	err := db.QueryRowNamed(ctx, "schema_migrations.Status",
+		`SELECT version, dirty FROM schema_migrations LIMIT 1`,
+	).Scan(&status.Version, &status.Dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return MigrationStatus{}, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return status, nil
+}
+
+// LatestMigrationVersion returns the highest version among the up migrations
+// in migrationsPath.
+func LatestMigrationVersion(migrationsPath string) (uint, error) {
+	entries, err := os.ReadDir(migrationsPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+	return latest, nil
+}
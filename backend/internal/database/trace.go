@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("campus-connect/internal/database")
+
+// QueryRowNamed, QueryNamed and ExecNamed run a statement inside a span named
+// after it, such as "trips.GetByID". Spans record the statement name and
+// database, never the query arguments. Inside InTx they run in the
+// transaction. Query spans stay open until the results are read: a Row's
+// span ends when it is scanned and a Rows' span when it is closed.
+func (db *DB) QueryRowNamed(ctx context.Context, name, query string, args ...interface{}) *Row {
+	ctx, span := db.startSpan(ctx, name)
+	return &Row{Row: db.conn(ctx).QueryRowContext(ctx, query, args...), span: span}
+}
+
+func (db *DB) QueryNamed(ctx context.Context, name, query string, args ...interface{}) (*Rows, error) {
+	ctx, span := db.startSpan(ctx, name)
+
+	rows, err := db.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		recordError(span, err)
+		span.End()
+		return nil, err
+	}
+	return &Rows{Rows: rows, span: span}, nil
+}
+
+func (db *DB) ExecNamed(ctx context.Context, name, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := db.startSpan(ctx, name)
+	defer span.End()
+
+	result, err := db.conn(ctx).ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return result, err
+}
+
+// Row is the result of QueryRowNamed.
+type Row struct {
+	*sql.Row
+	span trace.Span
+}
+
+func (r *Row) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	if !errors.Is(err, sql.ErrNoRows) {
+		recordError(r.span, err)
+	}
+	r.span.End()
+	return err
+}
+
+// Rows is the result of QueryNamed.
+type Rows struct {
+	*sql.Rows
+	span trace.Span
+}
+
+func (r *Rows) Scan(dest ...interface{}) error {
+	err := r.Rows.Scan(dest...)
+	recordError(r.span, err)
+	return err
+}
+
+func (r *Rows) Err() error {
+	err := r.Rows.Err()
+	recordError(r.span, err)
+	return err
+}
+
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	r.span.End()
+	return err
+}
+
+func (db *DB) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBNamespace(db.name),
+			semconv.DBOperationName(name),
+		),
+	)
+}
+
+func recordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -1,25 +1,86 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"campus-connect/internal/apperrors"
 	"campus-connect/internal/auth"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
 	"campus-connect/internal/middleware"
 	"campus-connect/internal/models"
 	"campus-connect/internal/repositories"
 	"campus-connect/internal/services"
 	"campus-connect/internal/utils"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
+const (
+	maxProfileImageSize    = 5 << 20
+	profileImageURLTTL     = 24 * time.Hour
+	maxVerificationDocSize = 10 << 20
+	verificationDocURLTTL  = 5 * time.Minute
+	maxUserAgentLength     = 512
+)
+
+var profileImageUpload = fileUpload{
+	name:    "Image",
+	maxSize: maxProfileImageSize,
+	types: map[string]bool{
+		"image/jpeg": true,
+		"image/png":  true,
+		"image/webp": true,
+	},
+	typeError: "Profile image must be a JPEG, PNG or WebP image",
+	public:    true,
+}
+
+var verificationDocUpload = fileUpload{
+	name:    "Document",
+	maxSize: maxVerificationDocSize,
+	types: map[string]bool{
+		"image/jpeg":      true,
+		"image/png":       true,
+		"image/webp":      true,
+		"application/pdf": true,
+	},
+	typeError: "Document must be a JPEG, PNG, WebP or PDF file",
+}
+
+var (
+	errInvalidCredentials      = apperrors.Unauthorized(apperrors.CodeInvalidCredentials, "Invalid email or password")
+	errInvalidPhoneNumber      = apperrors.InvalidInput(apperrors.CodeInvalidPhoneNumber, "Phone number must be a valid Ghanaian number")
+	errInvalidVerificationCode = apperrors.InvalidInput(apperrors.CodeInvalidCode, "Invalid or expired verification code")
+)
+
+var profileImageLimits = utils.ImageLimits{
+	MinWidth:  100,
+	MinHeight: 100,
+	MaxWidth:  4096,
+	MaxHeight: 4096,
+}
+
 type AuthHandler struct {
 	userRepo    repositories.UserRepository
 	authService *auth.AuthService
-	cloudinary  *services.CloudinaryService
+	store       services.ObjectStore
 	verifier    *services.VerificationService
+	sms         services.SMSProvider
+	loginEvents repositories.LoginEventRepository
+	throttle    *services.LoginThrottle
 }
 
 func NewAuthHandler(userRepo repositories.UserRepository, authService *auth.AuthService) *AuthHandler {
@@ -29,8 +90,8 @@ func NewAuthHandler(userRepo repositories.UserRepository, authService *auth.Auth
 	}
 }
 
-func (h *AuthHandler) WithCloudinary(cld *services.CloudinaryService) *AuthHandler {
-	h.cloudinary = cld
+func (h *AuthHandler) WithStore(store services.ObjectStore) *AuthHandler {
+	h.store = store
 	return h
 }
 
@@ -39,35 +100,54 @@ func (h *AuthHandler) WithVerifier(v *services.VerificationService) *AuthHandler
 	return h
 }
 
+func (h *AuthHandler) WithSMS(p services.SMSProvider) *AuthHandler {
+	h.sms = p
+	return h
+}
+
+// WithLoginEvents records sign-in attempts for the login history and
+// new-device notifications.
+func (h *AuthHandler) WithLoginEvents(repo repositories.LoginEventRepository) *AuthHandler {
+	h.loginEvents = repo
+	return h
+}
+
+func (h *AuthHandler) WithLoginThrottle(t *services.LoginThrottle) *AuthHandler {
+	h.throttle = t
+	return h
+}
+
 func (h *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
 	if !strings.HasSuffix(strings.ToLower(req.Email), "@st.knust.edu.gh") {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Only KNUST student emails (@st.knust.edu.gh) are allowed")
+		utils.WriteError(w, r, apperrors.InvalidInput(apperrors.CodeInvalidEmailDomain, "Only KNUST student emails (@st.knust.edu.gh) are allowed"))
 		return
 	}
 
-	if existingUser, _ := h.userRepo.GetByEmail(req.Email); existingUser != nil {
-		utils.WriteErrorResponse(w, http.StatusConflict, "User with this email already exists")
+	phoneNumber, err := utils.NormalizeGhanaPhone(req.PhoneNumber)
+	if err != nil {
+		utils.WriteError(w, r, errInvalidPhoneNumber)
 		return
 	}
 
-	if existingUser, _ := h.userRepo.GetByStudentID(req.StudentID); existingUser != nil {
-		utils.WriteErrorResponse(w, http.StatusConflict, "User with this student ID already exists")
+	if existingUser, _ := h.userRepo.GetByEmail(r.Context(), req.Email); existingUser != nil {
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeEmailTaken, "User with this email already exists"))
+		return
+	}
+
+	if existingUser, _ := h.userRepo.GetByStudentID(r.Context(), req.StudentID); existingUser != nil {
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeStudentIDTaken, "User with this student ID already exists"))
 		return
 	}
 
 	hashedPassword, err := h.authService.HashPassword(req.Password)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to process password")
+		utils.WriteInternalError(w, r, err, "Failed to process password")
 		return
 	}
 
@@ -78,22 +158,29 @@ func (h *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 		Email:            req.Email,
 		Password:         hashedPassword,
 		StudentID:        req.StudentID,
-		PhoneNumber:      req.PhoneNumber,
+		PhoneNumber:      phoneNumber,
 		Gender:           req.Gender,
 		IndexNumber:      req.IndexNumber,
 		ProgrammeOfStudy: req.ProgrammeOfStudy,
 		CurrentYear:      req.CurrentYear,
 	}
 
-	if err := h.userRepo.Create(user); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create user")
+	if err := h.userRepo.Create(r.Context(), user); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to create user")
 		return
 	}
+	metrics.Signups.Inc()
 
 	if h.verifier != nil {
+		// The account already exists, so failures here are logged rather than
+		// failing the sign-up.
+		logger := logging.FromContext(r.Context())
 		code := generateNumericCode(6)
-		_ = h.verifier.StoreCode(r.Context(), strings.ToLower(user.Email), code, 10*time.Minute)
-		_ = h.verifier.SendVerificationEmail(user.Email, user.FirstName+" "+user.LastName, code)
+		if err := h.verifier.StoreCode(r.Context(), strings.ToLower(user.Email), code, 10*time.Minute); err != nil {
+			logger.Error("failed to store email verification code", "new_user_id", user.ID, "error", err)
+		} else if err := h.verifier.SendVerificationEmail(r.Context(), user.Email, user.FirstName+" "+user.LastName, code); err != nil {
+			logger.Error("failed to send verification email", "new_user_id", user.ID, "error", err)
+		}
 	}
 
 	signupToken, _ := h.authService.GenerateToken(user)
@@ -117,37 +204,88 @@ func (h *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	user, err := h.userRepo.GetByEmail(req.Email)
+	logger := logging.FromContext(r.Context())
+	ip := middleware.ClientIP(r)
+
+	if h.throttle != nil {
+		block, err := h.throttle.Attempt(r.Context(), req.Email, ip)
+		if err != nil {
+			logger.Error("login throttle unavailable, refusing attempt", "error", err)
+			utils.WriteErrorResponse(w, r, http.StatusServiceUnavailable, "Service temporarily unavailable, please try again")
+			return
+		}
+		if block.Blocked() {
+			metrics.LoginAttempts.WithLabelValues(metrics.LoginBlocked).Inc()
+			writeLoginBlocked(w, r, block)
+			return
+		}
+	}
+
+	user, err := h.userRepo.GetByEmail(r.Context(), req.Email)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid email or password")
+		if errors.Is(err, apperrors.ErrNotFound) {
+			h.authService.CompareNoPassword(req.Password)
+			h.recordLoginFailure(r, req.Email, nil, models.LoginFailureUnknownEmail)
+			utils.WriteError(w, r, errInvalidCredentials)
+		} else {
+			utils.WriteError(w, r, err)
+		}
 		return
 	}
 
 	if err := h.authService.ComparePassword(req.Password, user.Password); err != nil {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid email or password")
+		h.recordLoginFailure(r, req.Email, user, models.LoginFailureBadPassword)
+		utils.WriteError(w, r, errInvalidCredentials)
+		return
+	}
+
+	// The password was right, so earlier failures no longer count
+	if h.throttle != nil {
+		if err := h.throttle.RecordSuccess(r.Context(), req.Email, ip); err != nil {
+			logger.Warn("failed to reset login failures", "error", err)
+		}
+	}
+
+	if user.BannedAt != nil {
+		reason := models.LoginFailureBanned
+		h.recordLoginAttempt(r, req.Email, user, &reason)
+		metrics.LoginAttempts.WithLabelValues(reason).Inc()
+		utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeAccountBanned, "This account has been banned"))
 		return
 	}
 
 	// Require verified email
 	if user.VerificationStatus != models.VerificationApproved {
-		utils.WriteErrorResponse(w, http.StatusForbidden, "Please verify your email")
+		reason := models.LoginFailureEmailNotVerified
+		h.recordLoginAttempt(r, req.Email, user, &reason)
+		metrics.LoginAttempts.WithLabelValues(reason).Inc()
+		utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeEmailNotVerified, "Please verify your email"))
 		return
 	}
 
 	token, err := h.authService.GenerateToken(user)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to generate token")
+		utils.WriteInternalError(w, r, err, "Failed to generate token")
 		return
 	}
 
+	metrics.LoginAttempts.WithLabelValues(metrics.LoginSucceeded).Inc()
+	if event := h.recordLoginAttempt(r, req.Email, user, nil); event != nil && event.NewDevice && h.verifier != nil {
+		// Sent in the background so a slow email provider does not hold up
+		// sign-in; the detached context keeps the request's logger and trace
+		ctx := context.WithoutCancel(r.Context())
+		go func() {
+			name := user.FirstName + " " + user.LastName
+			if err := h.verifier.SendNewDeviceEmail(ctx, user.Email, name, event.IPAddress, event.UserAgent, event.CreatedAt); err != nil {
+				logging.FromContext(ctx).Error("failed to send new device email", "error", err)
+			}
+		}()
+	}
+
 	response := map[string]interface{}{
 		"message": "Login successful",
 		"token":   token,
@@ -163,47 +301,138 @@ func (h *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccessResponse(w, "Login successful", response)
 }
 
+// recordLoginFailure adds a wrong email or password to the metrics and login
+// history. The throttle already counted the attempt as a failure.
+func (h *AuthHandler) recordLoginFailure(r *http.Request, email string, user *models.User, reason string) {
+	metrics.LoginAttempts.WithLabelValues(reason).Inc()
+	h.recordLoginAttempt(r, email, user, &reason)
+}
+
+// recordLoginAttempt adds a sign-in attempt to the login history. A
+// successful sign-in is from a new device when the account has signed in
+// before, but never with this user agent. Errors are logged rather than
+// failing the sign-in; the event is nil if it was not recorded.
+func (h *AuthHandler) recordLoginAttempt(r *http.Request, email string, user *models.User, failureReason *string) *models.LoginEvent {
+	if h.loginEvents == nil {
+		return nil
+	}
+	logger := logging.FromContext(r.Context())
+
+	userAgent := truncateUTF8(strings.ToValidUTF8(r.UserAgent(), "\uFFFD"), maxUserAgentLength)
+	deviceHash := sha256.Sum256([]byte(userAgent))
+
+	event := &models.LoginEvent{
+		ID:            uuid.New(),
+		Email:         strings.ToLower(email),
+		IPAddress:     middleware.ClientIP(r),
+		UserAgent:     userAgent,
+		DeviceHash:    hex.EncodeToString(deviceHash[:]),
+		Success:       failureReason == nil,
+		FailureReason: failureReason,
+	}
+	if user != nil {
+		event.UserID = &user.ID
+	}
+
+	if user != nil && event.Success {
+		fromDevice, total, err := h.loginEvents.CountSuccessful(r.Context(), user.ID, event.DeviceHash)
+		if err != nil {
+			logger.Warn("failed to check sign-in device", "error", err)
+		} else {
+			event.NewDevice = total > 0 && fromDevice == 0
+		}
+	}
+
+	if err := h.loginEvents.Create(r.Context(), event); err != nil {
+		logger.Error("failed to record login event", "error", err)
+		return nil
+	}
+	return event
+}
+
+func writeLoginBlocked(w http.ResponseWriter, r *http.Request, block services.LoginBlock) {
+	retryAfter := max(block.RetryAfter.Round(time.Second), time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	if block.Locked {
+		utils.WriteError(w, r, apperrors.New(apperrors.ErrRateLimited, apperrors.CodeAccountLocked,
+			fmt.Sprintf("Too many failed sign-in attempts. Try again in %s", retryAfter)))
+		return
+	}
+	utils.WriteError(w, r, apperrors.New(apperrors.ErrRateLimited, apperrors.CodeRateLimited,
+		fmt.Sprintf("Too many failed sign-in attempts. Try again in %s", retryAfter)))
+}
+
+// LoginHistory lists the current user's recent sign-in attempts, newest
+// first.
+func (h *AuthHandler) LoginHistory(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+	if h.loginEvents == nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "Login history not configured")
+		return
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	events, err := h.loginEvents.ListByUser(r.Context(), user.ID, limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get login history")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Login history retrieved successfully", map[string]interface{}{
+		"events": events,
+	})
+}
+
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccessResponse(w, "Logout successful", nil)
 }
 
 type verifyEmailRequest struct {
-	Email string `json:"email"`
-	Code  string `json:"code"`
+	Email string `json:"email" validate:"required,email,max=255"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
 }
 
 func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	if h.verifier == nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Verification service not configured")
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "Verification service not configured")
 		return
 	}
 
 	var req verifyEmailRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
 	ok, err := h.verifier.ValidateCode(r.Context(), strings.ToLower(req.Email), req.Code)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Verification failed")
+		utils.WriteInternalError(w, r, err, "Verification failed")
 		return
 	}
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid or expired verification code")
+		utils.WriteError(w, r, errInvalidVerificationCode)
 		return
 	}
 
 	// Mark user as verified
-	user, err := h.userRepo.GetByEmail(req.Email)
+	user, err := h.userRepo.GetByEmail(r.Context(), req.Email)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "User not found")
+		utils.WriteError(w, r, err)
 		return
 	}
-	if err := h.userRepo.SetVerificationStatus(user.ID, models.VerificationApproved); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update user")
+	if err := h.userRepo.SetVerificationStatus(r.Context(), user.ID, models.VerificationApproved); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to update user")
 		return
 	}
+	metrics.Verifications.WithLabelValues(metrics.VerificationEmail).Inc()
 
 	utils.WriteSuccessResponse(w, "Email verified successfully", map[string]interface{}{
 		"userId": user.ID,
@@ -214,13 +443,13 @@ func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	fullUser, err := h.userRepo.GetByID(user.ID)
+	fullUser, err := h.userRepo.GetByID(r.Context(), user.ID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "User not found")
+		utils.WriteError(w, r, err)
 		return
 	}
 
@@ -234,7 +463,7 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 			"verificationStatus": fullUser.VerificationStatus,
 			"rating":             fullUser.Rating,
 			"totalDeliveries":    fullUser.TotalDeliveries,
-			"profileImage":       fullUser.ProfileImage,
+			"profileImage":       h.profileImageURL(r, fullUser),
 			"gender":             fullUser.Gender,
 			"indexNumber":        fullUser.IndexNumber,
 			"programmeOfStudy":   fullUser.ProgrammeOfStudy,
@@ -250,23 +479,19 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
 	var req models.UpdateProfileRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	updatedUser, err := h.userRepo.UpdateProfile(user.ID, &req)
+	updatedUser, err := h.userRepo.UpdateProfile(r.Context(), user.ID, &req)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update profile")
+		utils.WriteInternalError(w, r, err, "Failed to update profile")
 		return
 	}
 
@@ -289,61 +514,407 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccessResponse(w, "Profile updated successfully", response)
 }
 
-func (h *AuthHandler) UploadVerificationDocument(w http.ResponseWriter, r *http.Request) {
+func (h *AuthHandler) UploadProfileImage(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	if h.store == nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "File storage not configured")
+		return
+	}
+
+	data, contentType, ok := profileImageUpload.read(w, r)
+	if !ok {
+		return
+	}
+	if _, err := utils.ValidateImage(data, profileImageLimits); err != nil {
+		switch {
+		case errors.Is(err, utils.ErrUnsupportedImageType):
+			utils.WriteErrorResponse(w, r, http.StatusUnsupportedMediaType, profileImageUpload.typeError)
+		case errors.Is(err, utils.ErrImageDimensions):
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, fmt.Sprintf(
+				"Image must be between %dx%d and %dx%d pixels",
+				profileImageLimits.MinWidth, profileImageLimits.MinHeight,
+				profileImageLimits.MaxWidth, profileImageLimits.MaxHeight,
+			))
+		default:
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid image file")
+		}
+		return
+	}
+
+	// Every upload gets a fresh key so the previous image stays valid until
+	// the database points at the new one
+	key := fmt.Sprintf("profiles/%s/%s", user.ID, uuid.NewString())
+	var storedKey string
+	var previousKey *string
+	saved := profileImageUpload.store(w, r, h.store, key, data, contentType, func(key string) error {
+		var err error
+		storedKey = key
+		previousKey, err = h.userRepo.SetProfileImage(r.Context(), user.ID, &key)
+		return err
+	})
+	if !saved {
+		return
+	}
+
+	if previousKey != nil && *previousKey != storedKey {
+		h.deleteObject(r, *previousKey, services.Public)
+	}
+
+	utils.WriteSuccessResponse(w, "Profile image updated successfully", map[string]interface{}{
+		"profileImage": h.profileImageURL(r, &models.User{ID: user.ID, ProfileImageKey: &storedKey}),
+	})
+}
+
+func (h *AuthHandler) DeleteProfileImage(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	previousKey, err := h.userRepo.SetProfileImage(r.Context(), user.ID, nil)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to remove profile image")
+		return
+	}
+
+	if previousKey != nil {
+		h.deleteObject(r, *previousKey, services.Public)
+	}
+
+	utils.WriteSuccessResponse(w, "Profile image removed successfully", map[string]interface{}{
+		"profileImage": nil,
+	})
+}
+
+// profileImageURL returns a fetchable URL for the user's profile image,
+// minted from its object key. Images stored before keys were recorded fall
+// back to their saved URL.
+func (h *AuthHandler) profileImageURL(r *http.Request, user *models.User) *string {
+	if h.store == nil || user.ProfileImageKey == nil {
+		return user.ProfileImage
+	}
+
+	url, err := h.store.URL(r.Context(), *user.ProfileImageKey, services.Public, profileImageURLTTL)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("failed to build profile image URL",
+			"profile_user_id", user.ID, "error", err)
+		return user.ProfileImage
+	}
+	return &url
+}
+
+// deleteObject removes a stored file that is no longer referenced. Failures
+// are logged rather than returned because the database is already consistent.
+func (h *AuthHandler) deleteObject(r *http.Request, key string, visibility services.Visibility) {
+	if h.store == nil {
+		return
+	}
+	if err := h.store.Delete(r.Context(), key, visibility); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to delete stored file", "key", key, "error", err)
+	}
+}
+
+func (h *AuthHandler) ChangePhoneNumber(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req models.ChangePhoneRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	phoneNumber, err := utils.NormalizeGhanaPhone(req.PhoneNumber)
+	if err != nil {
+		utils.WriteError(w, r, errInvalidPhoneNumber)
+		return
+	}
+
+	if err := h.userRepo.UpdatePhoneNumber(r.Context(), user.ID, phoneNumber); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to update phone number")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Phone number updated. Please verify your new number.", map[string]interface{}{
+		"phoneNumber":   phoneNumber,
+		"phoneVerified": false,
+	})
+}
+
+func (h *AuthHandler) SendPhoneCode(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	if h.cloudinary == nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Cloudinary service not configured")
+	if h.verifier == nil || h.sms == nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "Phone verification not configured")
 		return
 	}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid multipart form data")
+	fullUser, err := h.userRepo.GetByID(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteError(w, r, err)
 		return
 	}
 
-	docType := r.FormValue("docType")
-	if docType == "" {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "docType is required")
+	if fullUser.PhoneVerified {
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodePhoneAlreadyVerified, "Phone number is already verified"))
 		return
 	}
 
-	file, header, err := r.FormFile("file")
+	phoneNumber, err := utils.NormalizeGhanaPhone(fullUser.PhoneNumber)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "file is required")
+		utils.WriteError(w, r, apperrors.InvalidInput(apperrors.CodeInvalidPhoneNumber, "Please update your phone number to a valid Ghanaian number"))
 		return
 	}
-	file.Close()
 
-	url, err := h.cloudinary.UploadFromMultipartFile(header, user.ID.String())
+	code := generateNumericCode(6)
+	if err := h.verifier.StorePhoneCode(r.Context(), user.ID.String(), phoneNumber, code, 10*time.Minute); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to generate verification code")
+		return
+	}
+
+	message := fmt.Sprintf("Your CampusConnect verification code is %s. It expires in 10 minutes.", code)
+	if err := h.sms.SendSMS(r.Context(), phoneNumber, message); err != nil {
+		logging.FromContext(r.Context()).Error("failed to send verification SMS", "error", err)
+		utils.WriteErrorResponse(w, r, http.StatusBadGateway, "Failed to send verification code")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Verification code sent", map[string]interface{}{
+		"phoneNumber": phoneNumber,
+	})
+}
+
+func (h *AuthHandler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	if h.verifier == nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "Verification service not configured")
+		return
+	}
+
+	var req models.VerifyPhoneRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	fullUser, err := h.userRepo.GetByID(r.Context(), user.ID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to upload document")
+		utils.WriteError(w, r, err)
 		return
 	}
 
-	if err := h.userRepo.AddVerificationDocument(user.ID, docType, url); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to save document record")
+	// Codes are keyed by the number they were sent to, so a code sent before
+	// the number changed does not verify the new one.
+	phoneNumber, err := utils.NormalizeGhanaPhone(fullUser.PhoneNumber)
+	if err != nil {
+		utils.WriteError(w, r, errInvalidVerificationCode)
+		return
+	}
+	ok, err = h.verifier.ValidatePhoneCode(r.Context(), user.ID.String(), phoneNumber, req.Code)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Verification failed")
+		return
+	}
+	if !ok {
+		utils.WriteError(w, r, errInvalidVerificationCode)
+		return
+	}
+
+	if err := h.userRepo.SetPhoneVerified(r.Context(), user.ID, fullUser.PhoneNumber); err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	metrics.Verifications.WithLabelValues(metrics.VerificationPhone).Inc()
+
+	utils.WriteSuccessResponse(w, "Phone number verified successfully", map[string]interface{}{
+		"phoneNumber":   phoneNumber,
+		"phoneVerified": true,
+	})
+}
+
+// UploadVerificationDocument stores an identity document as a private object.
+// Documents never get a permanent URL; the owner and admins fetch them through
+// short-lived signed links.
+func (h *AuthHandler) UploadVerificationDocument(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	if h.store == nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "File storage not configured")
+		return
+	}
+
+	data, contentType, ok := verificationDocUpload.read(w, r)
+	if !ok {
+		return
+	}
+	req := models.UploadVerificationDocRequest{DocType: r.FormValue("docType")}
+	if err := utils.ValidateStruct(&req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	doc := &models.VerificationDocument{
+		ID:      uuid.New(),
+		UserID:  user.ID,
+		DocType: req.DocType,
+	}
+	key := fmt.Sprintf("verification_docs/%s/%s", user.ID, doc.ID)
+	saved := verificationDocUpload.store(w, r, h.store, key, data, contentType, func(key string) error {
+		doc.StorageKey = &key
+		return h.userRepo.AddVerificationDocument(r.Context(), doc)
+	})
+	if !saved {
+		return
+	}
+
+	url, err := h.store.URL(r.Context(), *doc.StorageKey, services.Private, verificationDocURLTTL)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to create document link")
 		return
 	}
 
 	utils.WriteSuccessResponse(w, "Verification document uploaded", map[string]interface{}{
-		"url":     url,
-		"docType": docType,
+		"id":        doc.ID,
+		"docType":   doc.DocType,
+		"createdAt": doc.CreatedAt,
+		"url":       url,
+		"expiresAt": time.Now().Add(verificationDocURLTTL),
+	})
+}
+
+func (h *AuthHandler) ListVerificationDocuments(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	docs, err := h.userRepo.ListVerificationDocuments(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get verification documents")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Verification documents retrieved successfully", map[string]interface{}{
+		"documents": docs,
+	})
+}
+
+// ListUserVerificationDocuments lets admins review another user's documents.
+func (h *AuthHandler) ListUserVerificationDocuments(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	docs, err := h.userRepo.ListVerificationDocuments(r.Context(), userID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get verification documents")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Verification documents retrieved successfully", map[string]interface{}{
+		"documents": docs,
+	})
+}
+
+// GetVerificationDocumentURL mints a short-lived link to a verification
+// document. Only the document's owner and admins may request one.
+func (h *AuthHandler) GetVerificationDocumentURL(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	if h.store == nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "File storage not configured")
+		return
+	}
+
+	docID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid document ID format")
+		return
+	}
+
+	doc, err := h.userRepo.GetVerificationDocument(r.Context(), docID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	// Report other users' documents as missing rather than forbidden so IDs
+	// cannot be probed
+	if doc.UserID != user.ID && !user.IsAdmin() {
+		utils.WriteError(w, r, apperrors.NotFound(apperrors.CodeDocumentNotFound, "Verification document not found"))
+		return
+	}
+
+	if doc.StorageKey == nil {
+		utils.WriteErrorResponse(w, r, http.StatusGone, "Document was uploaded before private storage and must be re-uploaded")
+		return
+	}
+
+	url, err := h.store.URL(r.Context(), *doc.StorageKey, services.Private, verificationDocURLTTL)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to create document link")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Document link created", map[string]interface{}{
+		"id":        doc.ID,
+		"docType":   doc.DocType,
+		"url":       url,
+		"expiresAt": time.Now().Add(verificationDocURLTTL),
 	})
 }
 
-// simple numeric code generator
+// truncateUTF8 shortens s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// generateNumericCode returns a random code of length digits. Codes stand
+// in for a password while they are valid, so they must not be guessable.
 func generateNumericCode(length int) string {
-	digits := "0123456789"
 	b := make([]byte, length)
-	now := time.Now().UnixNano()
-	for i := 0; i < length; i++ {
-		idx := int((now >> uint(i*3)) % 10)
-		b[i] = digits[idx]
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			// crypto/rand does not fail on supported platforms
+			panic(fmt.Sprintf("failed to generate code: %v", err))
+		}
+		b[i] = byte('0' + n.Int64())
 	}
 	return string(b)
 }
@@ -1,14 +1,20 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
 	"campus-connect/internal/middleware"
 	"campus-connect/internal/models"
 	"campus-connect/internal/repositories"
+	"campus-connect/internal/services"
 	"campus-connect/internal/utils"
 
 	"github.com/go-chi/chi/v5"
@@ -18,6 +24,13 @@ import (
 type DeliveryHandler struct {
 	deliveryRepo repositories.DeliveryRepository
 	tripRepo     repositories.TripRepository
+	ledger       repositories.LedgerRepository
+	tx           repositories.Transactor
+	pricing      *services.PricingService
+	locations    repositories.LocationRepository
+	tracking     *services.TrackingService
+	proofs       repositories.ProofRepository
+	blocks       repositories.BlockRepository
 }
 
 func NewDeliveryHandler(deliveryRepo repositories.DeliveryRepository, tripRepo repositories.TripRepository) *DeliveryHandler {
@@ -27,20 +40,67 @@ func NewDeliveryHandler(deliveryRepo repositories.DeliveryRepository, tripRepo r
 	}
 }
 
+// WithLedger holds delivery fees in escrow on a match, releasing them on
+// delivery and refunding them on cancellation. Status changes and ledger
+// postings are made in one transaction through tx.
+func (h *DeliveryHandler) WithLedger(ledger repositories.LedgerRepository, tx repositories.Transactor) *DeliveryHandler {
+	h.ledger = ledger
+	h.tx = tx
+	return h
+}
+
+// WithPricing adds a price estimate to created delivery requests and flags
+// outlying prices.
+func (h *DeliveryHandler) WithPricing(pricing *services.PricingService) *DeliveryHandler {
+	h.pricing = pricing
+	return h
+}
+
+// WithLocations links delivery request locations to the catalogue.
+func (h *DeliveryHandler) WithLocations(locations repositories.LocationRepository) *DeliveryHandler {
+	h.locations = locations
+	return h
+}
+
+// WithTracking stops live location sharing when a delivery leaves transit.
+func (h *DeliveryHandler) WithTracking(tracking *services.TrackingService) *DeliveryHandler {
+	h.tracking = tracking
+	return h
+}
+
+// WithProofs issues the requester a handover code on a match, which the
+// traveler must enter to mark the delivery delivered, and records proof of
+// each delivery.
+func (h *DeliveryHandler) WithProofs(proofs repositories.ProofRepository) *DeliveryHandler {
+	h.proofs = proofs
+	return h
+}
+
+// WithBlocks hides requests between users who blocked each other and stops
+// them offering to carry each other's requests.
+func (h *DeliveryHandler) WithBlocks(blocks repositories.BlockRepository) *DeliveryHandler {
+	h.blocks = blocks
+	return h
+}
+
+// inTx runs fn in a transaction when one is available.
+func (h *DeliveryHandler) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if h.tx == nil {
+		return fn(ctx)
+	}
+	return h.tx.InTx(ctx, fn)
+}
+
 func (h *DeliveryHandler) CreateDeliveryRequest(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
 	var req models.CreateDeliveryRequestRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
@@ -51,16 +111,35 @@ func (h *DeliveryHandler) CreateDeliveryRequest(w http.ResponseWriter, r *http.R
 	if err != nil {
 		pickupDateTime, err = time.Parse("2006-01-02T15:04", req.PickupDate+"T"+req.PickupTime)
 		if err != nil {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid pickup date or time format. Expected YYYY-MM-DD and HH:mm")
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid pickup date or time format. Expected YYYY-MM-DD and HH:mm")
 			return
 		}
 	}
 
+	pickup, err := resolveLocation(r.Context(), h.locations, "pickupLocation",
+		req.PickupLocationID, req.PickupLocation, req.PickupLatitude, req.PickupLongitude)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	dropoff, err := resolveLocation(r.Context(), h.locations, "dropoffLocation",
+		req.DropoffLocationID, req.DropoffLocation, req.DropoffLatitude, req.DropoffLongitude)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
 	deliveryRequest := &models.DeliveryRequest{
 		ID:                  uuid.New(),
 		UserID:              user.ID,
-		PickupLocation:      req.PickupLocation,
-		DropoffLocation:     req.DropoffLocation,
+		PickupLocation:      pickup.Name,
+		DropoffLocation:     dropoff.Name,
+		PickupLocationID:    pickup.ID,
+		DropoffLocationID:   dropoff.ID,
+		PickupLatitude:      pickup.Latitude,
+		PickupLongitude:     pickup.Longitude,
+		DropoffLatitude:     dropoff.Latitude,
+		DropoffLongitude:    dropoff.Longitude,
 		ItemDescription:     req.ItemDescription,
 		ItemSize:            req.ItemSize,
 		Priority:            req.Priority,
@@ -77,15 +156,23 @@ func (h *DeliveryHandler) CreateDeliveryRequest(w http.ResponseWriter, r *http.R
 		deliveryRequest.Priority = models.PriorityNormal
 	}
 
-	if err := h.deliveryRepo.Create(deliveryRequest); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create delivery request")
+	if err := h.deliveryRepo.Create(r.Context(), deliveryRequest); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to create delivery request")
 		return
 	}
+	metrics.DeliveryRequestsCreated.Inc()
 
 	response := map[string]interface{}{
 		"message":         "Delivery request created successfully",
 		"deliveryRequest": deliveryRequest,
 	}
+	addPriceCheck(r, h.pricing, response, models.PriceFlagDeliveryRequest, deliveryRequest.ID, user.ID, models.PriceQuery{
+		PickupLocation:  deliveryRequest.PickupLocation,
+		DropoffLocation: deliveryRequest.DropoffLocation,
+		ItemSize:        deliveryRequest.ItemSize,
+		Priority:        deliveryRequest.Priority,
+		LeadTime:        max(time.Until(deliveryRequest.PickupDate), time.Minute),
+	}, deliveryRequest.PaymentAmount)
 
 	utils.WriteCreatedResponse(w, "Delivery request created successfully", response)
 }
@@ -113,9 +200,9 @@ func (h *DeliveryHandler) GetDeliveryRequests(w http.ResponseWriter, r *http.Req
 	offset := (page - 1) * limit
 
 	// Get pending delivery requests
-	requests, totalCount, err := h.deliveryRepo.GetPendingRequests(limit, offset)
+	requests, totalCount, err := h.deliveryRepo.GetPendingRequests(r.Context(), viewerID(r), limit, offset)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to get delivery requests")
+		utils.WriteInternalError(w, r, err, "Failed to get delivery requests")
 		return
 	}
 
@@ -131,24 +218,67 @@ func (h *DeliveryHandler) GetDeliveryRequests(w http.ResponseWriter, r *http.Req
 	utils.WriteSuccessResponse(w, "Delivery requests retrieved successfully", response)
 }
 
+// SearchNearbyRequests finds pending requests picked up near a point, or
+// along a route corridor; see parseGeoSearch. Requests without coordinates
+// are not found.
+func (h *DeliveryHandler) SearchNearbyRequests(w http.ResponseWriter, r *http.Request) {
+	search, err := parseGeoSearch(r, h.locations)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	search.ViewerID = viewerID(r)
+
+	page := 1
+	limit := 10
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	requests, totalCount, err := h.deliveryRepo.SearchNearby(r.Context(), search, limit, (page-1)*limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to search delivery requests")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Delivery requests retrieved successfully", map[string]interface{}{
+		"deliveryRequests": requests,
+		"totalRequests":    totalCount,
+		"currentPage":      page,
+		"totalPages":       (totalCount + limit - 1) / limit,
+	})
+}
+
 func (h *DeliveryHandler) GetDeliveryRequestByID(w http.ResponseWriter, r *http.Request) {
 	requestIDStr := chi.URLParam(r, "id")
 	if requestIDStr == "" {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Request ID is required")
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Request ID is required")
 		return
 	}
 
 	requestID, err := uuid.Parse(requestIDStr)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request ID format")
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid request ID format")
 		return
 	}
 
-	deliveryRequest, err := h.deliveryRepo.GetByID(requestID)
+	deliveryRequest, err := h.deliveryRepo.GetByID(r.Context(), requestID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "Delivery request not found")
+		utils.WriteError(w, r, err)
 		return
 	}
+	if viewer := viewerID(r); viewer != nil {
+		if err := checkBlocked(r.Context(), h.blocks, *viewer, deliveryRequest.UserID); err != nil {
+			if errors.Is(err, errUserBlocked) {
+				err = apperrors.NotFound(apperrors.CodeRequestNotFound, "Delivery request not found")
+			}
+			utils.WriteError(w, r, err)
+			return
+		}
+	}
 
 	response := map[string]interface{}{
 		"deliveryRequest": deliveryRequest,
@@ -160,63 +290,83 @@ func (h *DeliveryHandler) GetDeliveryRequestByID(w http.ResponseWriter, r *http.
 func (h *DeliveryHandler) OfferDelivery(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
 	var req models.OfferDeliveryRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
 	// Get the delivery request
-	deliveryRequest, err := h.deliveryRepo.GetByID(req.DeliveryRequestID)
+	deliveryRequest, err := h.deliveryRepo.GetByID(r.Context(), req.DeliveryRequestID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "Delivery request not found")
+		utils.WriteError(w, r, err)
 		return
 	}
 
 	// Get the trip
-	trip, err := h.tripRepo.GetByID(req.TripID)
+	trip, err := h.tripRepo.GetByID(r.Context(), req.TripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "Trip not found")
+		utils.WriteError(w, r, err)
 		return
 	}
 
 	// Check if user is the traveler
 	if trip.TravelerID != user.ID {
-		utils.WriteErrorResponse(w, http.StatusForbidden, "Only the trip traveler can offer delivery service")
+		utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotTripOwner, "Only the trip traveler can offer delivery service"))
+		return
+	}
+
+	if err := checkBlocked(r.Context(), h.blocks, user.ID, deliveryRequest.UserID); err != nil {
+		utils.WriteError(w, r, err)
 		return
 	}
 
 	// Check if trip has available space
 	if trip.CurrentDeliveries >= trip.MaxDeliveries {
-		utils.WriteErrorResponse(w, http.StatusConflict, "Trip is full")
+		utils.WriteError(w, r, errTripFull)
 		return
 	}
 
 	// Check if request is already matched
 	if deliveryRequest.Status != models.DeliveryPending {
-		utils.WriteErrorResponse(w, http.StatusConflict, "Delivery request is already matched")
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeRequestAlreadyMatched, "Delivery request is already matched"))
 		return
 	}
 
-	// Update delivery request status and match with trip
-	if err := h.deliveryRepo.UpdateStatus(req.DeliveryRequestID, models.DeliveryMatched, &req.TripID); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update delivery request")
+	// Match the request with the trip and hold the fee in escrow if the
+	// requester's wallet covers it; otherwise it is held once they pay
+	err = h.inTx(r.Context(), func(ctx context.Context) error {
+		if err := h.deliveryRepo.TransitionMatch(ctx, req.DeliveryRequestID, models.DeliveryPending, models.DeliveryMatched, &req.TripID); err != nil {
+			return notPending(err)
+		}
+		if err := h.tripRepo.AddDeliveryRequest(ctx, req.TripID, req.DeliveryRequestID); err != nil {
+			return err
+		}
+		if h.ledger != nil {
+			amount := models.PesewasFromCedis(deliveryRequest.PaymentAmount)
+			_, err := h.ledger.Hold(ctx, deliveryRequest.ID, deliveryRequest.UserID, amount)
+			if err != nil && !apperrors.HasCode(err, apperrors.CodeInsufficientBalance) {
+				return err
+			}
+		}
+		if h.proofs != nil {
+			code := generateNumericCode(models.HandoverCodeLength)
+			if err := h.proofs.IssueHandoverCode(ctx, deliveryRequest.ID, code); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.WriteError(w, r, err)
 		return
 	}
 
-	// Add delivery request to trip
-	if err := h.tripRepo.AddDeliveryRequest(req.TripID, req.DeliveryRequestID); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to add delivery request to trip")
-		return
-	}
+	metrics.Matches.Inc()
 
 	utils.WriteSuccessResponse(w, "Delivery offer made successfully", nil)
 }
@@ -224,57 +374,248 @@ func (h *DeliveryHandler) OfferDelivery(w http.ResponseWriter, r *http.Request)
 func (h *DeliveryHandler) CancelDeliveryOffer(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
 	var req models.CancelDeliveryRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
 	// Get the delivery request
-	deliveryRequest, err := h.deliveryRepo.GetByID(req.DeliveryRequestID)
+	deliveryRequest, err := h.deliveryRepo.GetByID(r.Context(), req.DeliveryRequestID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "Delivery request not found")
+		utils.WriteError(w, r, err)
 		return
 	}
 
 	// Get the trip
-	trip, err := h.tripRepo.GetByID(req.TripID)
+	trip, err := h.tripRepo.GetByID(r.Context(), req.TripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "Trip not found")
+		utils.WriteError(w, r, err)
 		return
 	}
 
 	// Check if user is the traveler
 	if trip.TravelerID != user.ID {
-		utils.WriteErrorResponse(w, http.StatusForbidden, "Only the trip traveler can cancel delivery offer")
+		utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotTripOwner, "Only the trip traveler can cancel delivery offer"))
 		return
 	}
 
 	// Check if request is matched with this trip
 	if deliveryRequest.MatchedTripID == nil || *deliveryRequest.MatchedTripID != req.TripID {
-		utils.WriteErrorResponse(w, http.StatusConflict, "Delivery request is not matched with this trip")
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeRequestNotMatched, "Delivery request is not matched with this trip"))
 		return
 	}
 
-	// Update delivery request status back to pending
-	if err := h.deliveryRepo.UpdateStatus(req.DeliveryRequestID, models.DeliveryPending, nil); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update delivery request")
+	// Once the item is on its way the offer can no longer be withdrawn
+	if deliveryRequest.Status != models.DeliveryMatched {
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeInvalidTransition, "Delivery offer can only be cancelled before the item is in transit"))
 		return
 	}
 
-	// Remove delivery request from trip
-	if err := h.tripRepo.RemoveDeliveryRequest(req.TripID, req.DeliveryRequestID); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to remove delivery request from trip")
+	// Return the request to pending and refund the held fee
+	err = h.inTx(r.Context(), func(ctx context.Context) error {
+		if err := h.deliveryRepo.TransitionMatch(ctx, req.DeliveryRequestID, models.DeliveryMatched, models.DeliveryPending, nil); err != nil {
+			return err
+		}
+		if err := h.tripRepo.RemoveDeliveryRequest(ctx, req.TripID, req.DeliveryRequestID); err != nil {
+			return err
+		}
+		if h.ledger != nil {
+			if _, err := h.ledger.Refund(ctx, deliveryRequest.ID); err != nil {
+				return err
+			}
+		}
+		if h.proofs != nil {
+			return h.proofs.DeleteHandoverCode(ctx, deliveryRequest.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.WriteError(w, r, err)
 		return
 	}
 
 	utils.WriteSuccessResponse(w, "Delivery offer cancelled successfully", nil)
 }
+
+// notPending reports a failed pending to matched transition, which means
+// another offer matched the request first.
+func notPending(err error) error {
+	if errors.Is(err, apperrors.ErrConflict) {
+		return apperrors.Conflict(apperrors.CodeRequestNotPending, "Delivery request is no longer pending")
+	}
+	return err
+}
+
+// UpdateDeliveryStatus moves a matched delivery request to in_transit or
+// delivered, or cancels it. Delivery releases the held fee to the traveler;
+// cancellation removes the request from its trip and refunds the fee. A
+// traveler marking a delivery delivered must give the requester's handover
+// code.
+func (h *DeliveryHandler) UpdateDeliveryStatus(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	requestID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid request ID format")
+		return
+	}
+
+	var req models.UpdateDeliveryStatusRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	deliveryRequest, err := h.deliveryRepo.GetByID(r.Context(), requestID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	var trip *models.Trip
+	if deliveryRequest.MatchedTripID != nil {
+		if trip, err = h.tripRepo.GetByID(r.Context(), *deliveryRequest.MatchedTripID); err != nil {
+			utils.WriteError(w, r, err)
+			return
+		}
+	}
+	isRequester := deliveryRequest.UserID == user.ID
+	isTraveler := trip != nil && trip.TravelerID == user.ID
+
+	from := deliveryRequest.Status
+	if !from.CanTransitionTo(req.Status) {
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeInvalidTransition,
+			fmt.Sprintf("A %s delivery request cannot be marked %s", from, req.Status)))
+		return
+	}
+
+	switch req.Status {
+	case models.DeliveryInTransit:
+		if !isTraveler {
+			utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotTripOwner, "Only the trip traveler can mark a delivery in transit"))
+			return
+		}
+	case models.DeliveryDelivered:
+		if trip == nil {
+			utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeRequestNotMatched, "Delivery request is not matched with a trip"))
+			return
+		}
+		if !isTraveler && !isRequester {
+			utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotTripOwner, "Only the traveler or the requester can mark a delivery delivered"))
+			return
+		}
+		if !isRequester && h.proofs != nil {
+			if req.HandoverCode == "" {
+				utils.WriteError(w, r, apperrors.InvalidInput(apperrors.CodeValidationFailed, "handoverCode is required to mark a delivery delivered"))
+				return
+			}
+			// Checked before the transaction so wrong codes are counted even
+			// though the update does not go ahead
+			if err := h.proofs.CheckHandoverCode(r.Context(), requestID, req.HandoverCode); err != nil {
+				utils.WriteError(w, r, err)
+				return
+			}
+		}
+	case models.DeliveryCancelled:
+		if !isRequester {
+			utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotRequestOwner, "Only the requester can cancel a delivery request"))
+			return
+		}
+	}
+
+	var ledgerTxn *models.LedgerTransaction
+	err = h.inTx(r.Context(), func(ctx context.Context) error {
+		if err := h.deliveryRepo.TransitionStatus(ctx, requestID, from, req.Status); err != nil {
+			return err
+		}
+
+		var err error
+		switch req.Status {
+		case models.DeliveryDelivered:
+			if h.proofs != nil {
+				if err := h.recordHandover(ctx, requestID, user.ID, isRequester, &req); err != nil {
+					return err
+				}
+			}
+			if h.ledger != nil {
+				ledgerTxn, err = h.ledger.Release(ctx, requestID, trip.TravelerID)
+			}
+		case models.DeliveryCancelled:
+			if trip != nil {
+				if err := h.tripRepo.RemoveDeliveryRequest(ctx, trip.ID, requestID); err != nil {
+					return err
+				}
+			}
+			if h.proofs != nil {
+				if err := h.proofs.DeleteHandoverCode(ctx, requestID); err != nil {
+					return err
+				}
+			}
+			if h.ledger != nil {
+				ledgerTxn, err = h.ledger.Refund(ctx, requestID)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	if req.Status == models.DeliveryDelivered {
+		metrics.DeliveriesCompleted.Inc()
+		if h.proofs != nil {
+			kind := models.ProofHandoverCode
+			if isRequester {
+				kind = models.ProofRequesterConfirmation
+			}
+			metrics.DeliveryProofs.WithLabelValues(string(kind)).Inc()
+		}
+	}
+	if from == models.DeliveryInTransit && h.tracking != nil {
+		// The location expires on its own, so a failure here is only logged
+		if err := h.tracking.Stop(r.Context(), requestID, req.Status); err != nil {
+			logging.FromContext(r.Context()).Error("failed to stop tracking", "delivery_request_id", requestID, "error", err)
+		}
+	}
+	deliveryRequest.Status = req.Status
+
+	utils.WriteSuccessResponse(w, "Delivery request status updated successfully", map[string]interface{}{
+		"deliveryRequest":   deliveryRequest,
+		"ledgerTransaction": ledgerTxn,
+	})
+}
+
+// recordHandover records proof that a delivery was handed over: the
+// requester's confirmation, or the traveler's use of the handover code.
+func (h *DeliveryHandler) recordHandover(
+	ctx context.Context,
+	requestID, userID uuid.UUID,
+	isRequester bool,
+	req *models.UpdateDeliveryStatusRequest,
+) error {
+	proof := &models.DeliveryProof{
+		DeliveryRequestID: requestID,
+		Kind:              models.ProofRequesterConfirmation,
+		SubmittedBy:       userID,
+		Latitude:          req.Latitude,
+		Longitude:         req.Longitude,
+		Accuracy:          req.Accuracy,
+	}
+	if !isRequester {
+		if err := h.proofs.UseHandoverCode(ctx, requestID); err != nil {
+			return err
+		}
+		proof.Kind = models.ProofHandoverCode
+	}
+	return h.proofs.CreateProof(ctx, proof)
+}
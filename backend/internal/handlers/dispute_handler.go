@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/middleware"
+	"campus-connect/internal/models"
+	"campus-connect/internal/services"
+	"campus-connect/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisputeAttachmentSize = 10 << 20
+	disputeAttachmentURLTTL  = 5 * time.Minute
+)
+
+var disputeAttachmentUpload = fileUpload{
+	name:    "Attachment",
+	maxSize: maxDisputeAttachmentSize,
+	types: map[string]bool{
+		"image/jpeg":      true,
+		"image/png":       true,
+		"image/webp":      true,
+		"application/pdf": true,
+	},
+	typeError: "Attachment must be a JPEG, PNG or WebP image or a PDF",
+}
+
+type DisputeHandler struct {
+	disputes *services.DisputeService
+	store    services.ObjectStore
+}
+
+func NewDisputeHandler(disputes *services.DisputeService, store services.ObjectStore) *DisputeHandler {
+	return &DisputeHandler{
+		disputes: disputes,
+		store:    store,
+	}
+}
+
+func disputeID(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return uuid.Nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Invalid dispute ID format")
+	}
+	return id, nil
+}
+
+// pagination reads the page and limit query parameters.
+func pagination(r *http.Request) (page, limit int) {
+	page = 1
+	limit = 20
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	return page, limit
+}
+
+// OpenDispute files a case about a delivery against its other party.
+func (h *DisputeHandler) OpenDispute(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req models.CreateDisputeRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	dispute, err := h.disputes.Open(r.Context(), user, req)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteCreatedResponse(w, "Dispute opened successfully", dispute)
+}
+
+// GetMyDisputes lists the cases the user opened or was named in.
+func (h *DisputeHandler) GetMyDisputes(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	page, limit := pagination(r)
+	disputes, totalCount, err := h.disputes.ListForUser(r.Context(), user, limit, (page-1)*limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get disputes")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Disputes retrieved successfully", map[string]interface{}{
+		"disputes":      disputes,
+		"totalDisputes": totalCount,
+		"currentPage":   page,
+		"totalPages":    (totalCount + limit - 1) / limit,
+	})
+}
+
+// GetDispute returns a case with its history and attachments to its parties
+// and admins. Attachments come with short-lived links.
+func (h *DisputeHandler) GetDispute(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, err := disputeID(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	detail, err := h.disputes.Get(r.Context(), user, id)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	for _, attachment := range detail.Attachments {
+		if err := h.signAttachmentURL(r, attachment); err != nil {
+			utils.WriteInternalError(w, r, err, "Failed to create attachment link")
+			return
+		}
+	}
+
+	utils.WriteSuccessResponse(w, "Dispute retrieved successfully", detail)
+}
+
+// AddMessage adds a message to a case, seen by both parties and admins.
+func (h *DisputeHandler) AddMessage(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, err := disputeID(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	var req models.CreateDisputeNoteRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	note, err := h.disputes.AddMessage(r.Context(), user, id, req.Body)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteCreatedResponse(w, "Message added successfully", note)
+}
+
+// UploadAttachment adds evidence, an image or PDF, to an unfinished case.
+func (h *DisputeHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	if h.store == nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "File storage not configured")
+		return
+	}
+
+	id, err := disputeID(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	dispute, err := h.disputes.CheckOpen(r.Context(), user, id)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	data, contentType, ok := disputeAttachmentUpload.read(w, r)
+	if !ok {
+		return
+	}
+
+	attachment := &models.DisputeAttachment{
+		ID:          uuid.New(),
+		DisputeID:   dispute.ID,
+		UploadedBy:  user.ID,
+		ContentType: contentType,
+	}
+	key := fmt.Sprintf("dispute_attachments/%s/%s", dispute.ID, attachment.ID)
+	saved := disputeAttachmentUpload.store(w, r, h.store, key, data, contentType, func(key string) error {
+		attachment.StorageKey = key
+		return h.disputes.AddAttachment(r.Context(), attachment)
+	})
+	if !saved {
+		return
+	}
+
+	if err := h.signAttachmentURL(r, attachment); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to create attachment link")
+		return
+	}
+
+	utils.WriteCreatedResponse(w, "Attachment added successfully", attachment)
+}
+
+// ListDisputes is the admins' queue: escalated cases first, then by
+// deadline. It can be filtered by status, category, assignee ("me" or an
+// admin's ID) and escalation.
+func (h *DisputeHandler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.DisputeFilter{
+		Status:   models.DisputeStatus(query.Get("status")),
+		Category: models.DisputeCategory(query.Get("category")),
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "status must be open, in_review, awaiting_response, resolved or closed")
+		return
+	}
+	if filter.Category != "" && !filter.Category.IsValid() {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid category")
+		return
+	}
+	switch s := query.Get("assignedTo"); s {
+	case "":
+	case "me":
+		filter.AssignedTo = &user.ID
+	default:
+		adminID, err := uuid.Parse(s)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "assignedTo must be me or an admin ID")
+			return
+		}
+		filter.AssignedTo = &adminID
+	}
+	if s := query.Get("escalated"); s != "" {
+		escalated, err := strconv.ParseBool(s)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "escalated must be true or false")
+			return
+		}
+		filter.Escalated = &escalated
+	}
+
+	page, limit := pagination(r)
+	disputes, totalCount, err := h.disputes.List(r.Context(), filter, limit, (page-1)*limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get disputes")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Disputes retrieved successfully", map[string]interface{}{
+		"disputes":      disputes,
+		"totalDisputes": totalCount,
+		"currentPage":   page,
+		"totalPages":    (totalCount + limit - 1) / limit,
+	})
+}
+
+// AssignDispute gives a case to an admin, the caller unless another is named.
+func (h *DisputeHandler) AssignDispute(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, err := disputeID(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	var req models.AssignDisputeRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	dispute, err := h.disputes.Assign(r.Context(), admin, id, req.AdminID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Dispute assigned successfully", dispute)
+}
+
+// AddNote adds an internal note to a case, seen by admins only.
+func (h *DisputeHandler) AddNote(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, err := disputeID(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	var req models.CreateDisputeNoteRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	note, err := h.disputes.AddNote(r.Context(), admin, id, req.Body)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteCreatedResponse(w, "Note added successfully", note)
+}
+
+// UpdateDisputeStatus moves a case to review, asks a party for a response or
+// closes it without a resolution.
+func (h *DisputeHandler) UpdateDisputeStatus(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, err := disputeID(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	var req models.UpdateDisputeStatusRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	dispute, err := h.disputes.UpdateStatus(r.Context(), admin, id, req)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Dispute status updated successfully", dispute)
+}
+
+// ResolveDispute settles a case with a refund, partial refund, warning, ban
+// or no action.
+func (h *DisputeHandler) ResolveDispute(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	id, err := disputeID(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	var req models.ResolveDisputeRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	dispute, err := h.disputes.Resolve(r.Context(), admin, id, req)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Dispute resolved successfully", dispute)
+}
+
+// signAttachmentURL sets a short-lived link to the attachment.
+func (h *DisputeHandler) signAttachmentURL(r *http.Request, attachment *models.DisputeAttachment) error {
+	if h.store == nil {
+		return nil
+	}
+	url, err := h.store.URL(r.Context(), attachment.StorageKey, services.Private, disputeAttachmentURLTTL)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(disputeAttachmentURLTTL)
+	attachment.URL = &url
+	attachment.URLExpiresAt = &expiresAt
+	return nil
+}
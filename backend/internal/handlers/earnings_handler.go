@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"campus-connect/internal/middleware"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+	"campus-connect/internal/services"
+	"campus-connect/internal/utils"
+)
+
+// maxEarningsRange bounds the period earnings are reported over.
+const maxEarningsRange = 366 * 24 * time.Hour
+
+type EarningsHandler struct {
+	earningsRepo repositories.EarningsRepository
+	tripRepo     repositories.TripRepository
+	payoutRepo   repositories.PayoutRepository
+	userRepo     repositories.UserRepository
+}
+
+func NewEarningsHandler(
+	earningsRepo repositories.EarningsRepository,
+	tripRepo repositories.TripRepository,
+	payoutRepo repositories.PayoutRepository,
+	userRepo repositories.UserRepository,
+) *EarningsHandler {
+	return &EarningsHandler{
+		earningsRepo: earningsRepo,
+		tripRepo:     tripRepo,
+		payoutRepo:   payoutRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// parseEarningsRange reads the from and to dates (YYYY-MM-DD, UTC, both
+// inclusive) of a report and returns them as the range [from, to). They
+// default to the current month so far.
+func parseEarningsRange(r *http.Request) (from, to time.Time, err error) {
+	now := time.Now().UTC()
+	from = models.GroupByMonth.PeriodStart(now)
+	to = models.GroupByDay.PeriodStart(now).AddDate(0, 0, 1)
+
+	if s := r.URL.Query().Get("from"); s != "" {
+		if from, err = time.Parse(time.DateOnly, s); err != nil {
+			return from, to, errors.New("from must be a date like 2025-01-31")
+		}
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		if to, err = time.Parse(time.DateOnly, s); err != nil {
+			return from, to, errors.New("to must be a date like 2025-01-31")
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	if !from.Before(to) {
+		return from, to, errors.New("from must not be after to")
+	}
+	if to.Sub(from) > maxEarningsRange {
+		return from, to, errors.New("range must not be longer than a year")
+	}
+	return from, to, nil
+}
+
+// GetEarnings reports the traveler's fees released over a period, in
+// totals and per day, week or month, with their current balances.
+func (h *EarningsHandler) GetEarnings(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	from, to, err := parseEarningsRange(r)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	groupBy := models.GroupByDay
+	if s := r.URL.Query().Get("groupBy"); s != "" {
+		groupBy = models.EarningsGrouping(s)
+		if !groupBy.IsValid() {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "groupBy must be day, week or month")
+			return
+		}
+	}
+
+	earnings, err := h.earningsRepo.ListReleases(r.Context(), user.ID, from, to)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get earnings")
+		return
+	}
+
+	balances, err := h.earningsRepo.Balances(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get balances")
+		return
+	}
+
+	// Every period in the range is listed, including those with no earnings.
+	// The first may start before from.
+	var totals models.EarningsTotals
+	periods := []*models.EarningsPeriod{}
+	i := 0
+	for start := groupBy.PeriodStart(from); start.Before(to); start = groupBy.Next(start) {
+		period := &models.EarningsPeriod{Start: start, End: groupBy.Next(start)}
+		for ; i < len(earnings) && earnings[i].ReleasedAt.Before(period.End); i++ {
+			period.Add(earnings[i])
+			totals.Add(earnings[i])
+		}
+		periods = append(periods, period)
+	}
+
+	utils.WriteSuccessResponse(w, "Earnings retrieved successfully", map[string]interface{}{
+		"from":     from,
+		"to":       to,
+		"groupBy":  groupBy,
+		"totals":   totals,
+		"periods":  periods,
+		"balances": balances,
+	})
+}
+
+// GetTripEarnings reports the fees released and still held for each of the
+// traveler's trips, newest trip first.
+func (h *EarningsHandler) GetTripEarnings(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	trips, err := h.tripRepo.GetByTravelerID(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get user trips")
+		return
+	}
+
+	byTrip, err := h.earningsRepo.ByTrip(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get trip earnings")
+		return
+	}
+
+	var totals models.EarningsTotals
+	var pending models.Pesewas
+	tripEarnings := make([]*models.TripEarnings, 0, len(trips))
+	for _, trip := range trips {
+		e, ok := byTrip[trip.ID]
+		if !ok {
+			e = &models.TripEarnings{}
+		}
+		e.Trip = trip
+		totals.Deliveries += e.Deliveries
+		totals.Gross += e.Gross
+		totals.Fees += e.Fees
+		totals.Net += e.Net
+		pending += e.Pending
+		tripEarnings = append(tripEarnings, e)
+	}
+
+	utils.WriteSuccessResponse(w, "Trip earnings retrieved successfully", map[string]interface{}{
+		"trips":   tripEarnings,
+		"totals":  totals,
+		"pending": pending,
+	})
+}
+
+// ExportStatement downloads the traveler's earnings and payouts over a
+// period as a CSV or PDF statement.
+func (h *EarningsHandler) ExportStatement(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	from, to, err := parseEarningsRange(r)
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "pdf" {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "format must be csv or pdf")
+		return
+	}
+
+	traveler, err := h.userRepo.GetByID(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	earnings, err := h.earningsRepo.ListReleases(r.Context(), user.ID, from, to)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get earnings")
+		return
+	}
+
+	balances, err := h.earningsRepo.Balances(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get balances")
+		return
+	}
+
+	payouts, err := h.payoutRepo.GetByUserID(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get payouts")
+		return
+	}
+
+	statement := &services.EarningsStatement{
+		TravelerName: traveler.FirstName + " " + traveler.LastName,
+		From:         from,
+		To:           to,
+		GeneratedAt:  time.Now().UTC(),
+		Earnings:     earnings,
+		Balances:     balances,
+	}
+	for _, e := range earnings {
+		statement.Totals.Add(e)
+	}
+	// Payouts are listed newest first; statements read oldest first
+	for i := len(payouts) - 1; i >= 0; i-- {
+		p := payouts[i]
+		if p.Status == models.PayoutApproved && !p.ReviewedAt.Before(from) && p.ReviewedAt.Before(to) {
+			statement.Payouts = append(statement.Payouts, p)
+		}
+	}
+
+	// Rendered in full first, so failures can still be reported
+	var buf bytes.Buffer
+	contentType := "text/csv; charset=utf-8"
+	if format == "pdf" {
+		contentType = "application/pdf"
+		err = statement.WritePDF(&buf)
+	} else {
+		err = statement.WriteCSV(&buf)
+	}
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to write earnings statement")
+		return
+	}
+
+	filename := fmt.Sprintf("earnings-%s-%s.%s", from.Format(time.DateOnly), to.AddDate(0, 0, -1).Format(time.DateOnly), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(buf.Bytes())
+}
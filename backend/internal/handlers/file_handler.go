@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"campus-connect/internal/services"
+	"campus-connect/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// FileHandler serves files kept by the local disk object store through the
+// signed URLs it issues.
+type FileHandler struct {
+	store *services.LocalStore
+}
+
+func NewFileHandler(store *services.LocalStore) *FileHandler {
+	return &FileHandler{
+		store: store,
+	}
+}
+
+func (h *FileHandler) ServeFile(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	query := r.URL.Query()
+
+	file, err := h.store.Open(key, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		if errors.Is(err, services.ErrObjectNotFound) || errors.Is(err, services.ErrInvalidKey) {
+			utils.WriteErrorResponse(w, r, http.StatusNotFound, "File not found or link expired")
+		} else {
+			utils.WriteInternalError(w, r, err, "Failed to read file")
+		}
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to read file")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", info.ModTime().In(time.UTC), file)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"campus-connect/internal/database"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/utils"
+	"campus-connect/internal/version"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	readinessTimeout = 2 * time.Second
+
+	statusOK          = "ok"
+	statusDown        = "down"
+	statusUnavailable = "unavailable"
+)
+
+// HealthHandler serves liveness and readiness probes. Liveness only says the
+// process is serving requests; readiness also checks the dependencies every
+// request needs, so orchestrators can stop routing to a broken instance
+// without restarting it.
+type HealthHandler struct {
+	db                *database.DB
+	redis             *redis.Client
+	expectedMigration uint
+}
+
+// NewHealthHandler creates a HealthHandler. Readiness fails while the applied
+// schema is older than expectedMigration or left dirty by a failed migration.
+func NewHealthHandler(db *database.DB, redis *redis.Client, expectedMigration uint) *HealthHandler {
+	return &HealthHandler{
+		db:                db,
+		redis:             redis,
+		expectedMigration: expectedMigration,
+	}
+}
+
+type dependencyCheck struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type migrationCheck struct {
+	dependencyCheck
+	Version  uint `json:"version"`
+	Expected uint `json:"expected"`
+	Dirty    bool `json:"dirty"`
+}
+
+type readinessChecks struct {
+	Database   dependencyCheck `json:"database"`
+	Redis      dependencyCheck `json:"redis"`
+	Migrations migrationCheck  `json:"migrations"`
+}
+
+type probeResponse struct {
+	Status    string           `json:"status"`
+	Service   string           `json:"service"`
+	Version   string           `json:"version"`
+	Commit    string           `json:"commit"`
+	BuildTime string           `json:"buildTime,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+	Checks    *readinessChecks `json:"checks,omitempty"`
+}
+
+func newProbeResponse(status string) probeResponse {
+	return probeResponse{
+		Status:    status,
+		Service:   "campus-connect-api",
+		Version:   version.Version,
+		Commit:    version.Commit,
+		BuildTime: version.BuildTime,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// Livez reports that the process is up. It never checks dependencies, so a
+// database outage does not get every instance restarted.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSONResponse(w, http.StatusOK, newProbeResponse(statusOK))
+}
+
+// Readyz checks Postgres, Redis and the schema version concurrently and
+// responds 503 if any of them is not usable.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := &readinessChecks{}
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		checks.Database = h.check(ctx, "database", h.db.PingContext)
+	}()
+	go func() {
+		defer wg.Done()
+		checks.Redis = h.check(ctx, "redis", func(ctx context.Context) error {
+			return h.redis.Ping(ctx).Err()
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		checks.Migrations = h.checkMigrations(ctx)
+	}()
+	wg.Wait()
+
+	response := newProbeResponse(statusOK)
+	response.Checks = checks
+	statusCode := http.StatusOK
+	if checks.Database.Status != statusOK || checks.Redis.Status != statusOK || checks.Migrations.Status != statusOK {
+		response.Status = statusUnavailable
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	utils.WriteJSONResponse(w, statusCode, response)
+}
+
+// check runs ping and reports its latency. The underlying error is logged
+// rather than returned because probe responses may be publicly reachable.
+func (h *HealthHandler) check(ctx context.Context, name string, ping func(context.Context) error) dependencyCheck {
+	start := time.Now()
+	err := ping(ctx)
+	check := dependencyCheck{
+		Status:    statusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("readiness check failed", "dependency", name, "error", err)
+		check.Status = statusDown
+		check.Error = name + " is unreachable"
+	}
+	return check
+}
+
+func (h *HealthHandler) checkMigrations(ctx context.Context) migrationCheck {
+	var status database.MigrationStatus
+	check := migrationCheck{
+		dependencyCheck: h.check(ctx, "migrations", func(ctx context.Context) error {
+			var err error
+			status, err = h.db.MigrationStatus(ctx)
+			return err
+		}),
+		Expected: h.expectedMigration,
+	}
+	if check.Status != statusOK {
+		check.Error = "could not read the schema version"
+		return check
+	}
+
+	check.Version = status.Version
+	check.Dirty = status.Dirty
+	switch {
+	case status.Dirty:
+		check.Status = statusDown
+		check.Error = "last migration failed and left the schema dirty"
+	case status.Version < h.expectedMigration:
+		check.Status = statusDown
+		check.Error = "schema is older than this build expects"
+	}
+	return check
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"campus-connect/internal/auth"
+	"campus-connect/internal/utils"
+)
+
+// JWKSHandler publishes the public keys that verify our tokens, so other
+// services can check them without sharing a secret.
+type JWKSHandler struct {
+	keyring *auth.Keyring
+}
+
+func NewJWKSHandler(keyring *auth.Keyring) *JWKSHandler {
+	return &JWKSHandler{
+		keyring: keyring,
+	}
+}
+
+// ServeJWKS writes the key set as a bare JWKS document, not wrapped in the
+// usual response envelope. The set is empty while tokens are signed with an
+// HMAC secret.
+func (h *JWKSHandler) ServeJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.WriteJSONResponse(w, http.StatusOK, h.keyring.JWKS())
+}
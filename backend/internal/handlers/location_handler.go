@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+	"campus-connect/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type LocationHandler struct {
+	locationRepo repositories.LocationRepository
+}
+
+func NewLocationHandler(locationRepo repositories.LocationRepository) *LocationHandler {
+	return &LocationHandler{
+		locationRepo: locationRepo,
+	}
+}
+
+// GetLocations lists the catalogue, optionally one category of it.
+func (h *LocationHandler) GetLocations(w http.ResponseWriter, r *http.Request) {
+	category := models.LocationCategory(r.URL.Query().Get("category"))
+	if category != "" && !category.IsValid() {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "category must be hall, hostel, faculty, landmark or town")
+		return
+	}
+
+	locations, err := h.locationRepo.List(r.Context(), category)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get locations")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Locations retrieved successfully", locations)
+}
+
+// Autocomplete suggests locations whose name or an alias contains q.
+func (h *LocationHandler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "q is required")
+		return
+	}
+	if len(q) > 100 {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "q must be at most 100 characters")
+		return
+	}
+
+	limit := 10
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+
+	locations, err := h.locationRepo.Search(r.Context(), q, limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to search locations")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Locations retrieved successfully", locations)
+}
+
+func (h *LocationHandler) GetLocation(w http.ResponseWriter, r *http.Request) {
+	locationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid location ID format")
+		return
+	}
+
+	location, err := h.locationRepo.GetByID(r.Context(), locationID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Location retrieved successfully", location)
+}
+
+// resolvedLocation is where a delivery request or trip starts or ends.
+type resolvedLocation struct {
+	Name      string
+	ID        *uuid.UUID
+	Latitude  *float64
+	Longitude *float64
+}
+
+// resolveLocation settles a location given as a catalogue ID, free text or
+// both. An ID must be catalogued and replaces the text with the location's
+// name. Text naming a catalogued location is linked to it; other text is
+// kept as free text. Coordinates given with the location override the
+// catalogue's.
+func resolveLocation(
+	ctx context.Context,
+	locations repositories.LocationRepository,
+	field string,
+	id *uuid.UUID,
+	text string,
+	latitude, longitude *float64,
+) (resolvedLocation, error) {
+	resolved := resolvedLocation{Name: text, Latitude: latitude, Longitude: longitude}
+	if locations == nil {
+		if text == "" {
+			return resolved, apperrors.InvalidInput(apperrors.CodeValidationFailed, field+" is required")
+		}
+		return resolved, nil
+	}
+
+	var location *models.Location
+	var err error
+	if id != nil {
+		location, err = locations.GetByID(ctx, *id)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return resolved, apperrors.NotFound(apperrors.CodeLocationNotFound, field+"Id is not a known location")
+		}
+	} else {
+		location, err = locations.Resolve(ctx, text)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return resolved, nil
+		}
+	}
+	if err != nil {
+		return resolved, err
+	}
+
+	resolved.Name = location.Name
+	resolved.ID = &location.ID
+	if latitude == nil {
+		resolved.Latitude, resolved.Longitude = &location.Latitude, &location.Longitude
+	}
+	return resolved, nil
+}
+
+// Radii of proximity searches, in metres.
+const (
+	defaultSearchRadius = 1000
+	maxSearchRadius     = 50000
+)
+
+// parseGeoSearch reads a proximity search from the query string. The origin
+// is originLat and originLng, or originLocationId; an optional destination,
+// given the same way, makes it a route corridor search. radius is in metres,
+// and sort is "distance", the default, or "newest".
+func parseGeoSearch(r *http.Request, locations repositories.LocationRepository) (models.GeoSearch, error) {
+	query := r.URL.Query()
+	search := models.GeoSearch{RadiusMeters: defaultSearchRadius, SortByDistance: true}
+
+	origin, err := parseGeoPoint(r.Context(), query, locations, "origin")
+	if err != nil {
+		return search, err
+	}
+	if origin == nil {
+		return search, apperrors.InvalidInput(apperrors.CodeBadRequest, "originLat and originLng, or originLocationId, are required")
+	}
+	search.Origin = *origin
+	if search.Destination, err = parseGeoPoint(r.Context(), query, locations, "destination"); err != nil {
+		return search, err
+	}
+
+	if s := query.Get("radius"); s != "" {
+		radius, err := parseFinite(s)
+		if err != nil || radius <= 0 || radius > maxSearchRadius {
+			return search, apperrors.InvalidInput(apperrors.CodeBadRequest, "radius must be between 0 and 50000 metres")
+		}
+		search.RadiusMeters = radius
+	}
+
+	switch query.Get("sort") {
+	case "", "distance":
+	case "newest":
+		search.SortByDistance = false
+	default:
+		return search, apperrors.InvalidInput(apperrors.CodeBadRequest, "sort must be distance or newest")
+	}
+	return search, nil
+}
+
+// parseGeoPoint reads the point named prefix from the query string, either
+// as prefixLat and prefixLng or as the catalogued prefixLocationId. It
+// returns nil if neither is given.
+func parseGeoPoint(ctx context.Context, query url.Values, locations repositories.LocationRepository, prefix string) (*models.GeoPoint, error) {
+	if s := query.Get(prefix + "LocationId"); s != "" && locations != nil {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Invalid "+prefix+"LocationId format")
+		}
+		location, err := locations.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return &models.GeoPoint{Latitude: location.Latitude, Longitude: location.Longitude}, nil
+	}
+
+	latStr, lngStr := query.Get(prefix+"Lat"), query.Get(prefix+"Lng")
+	if latStr == "" && lngStr == "" {
+		return nil, nil
+	}
+	lat, latErr := parseFinite(latStr)
+	lng, lngErr := parseFinite(lngStr)
+	if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, apperrors.InvalidInput(apperrors.CodeBadRequest,
+			prefix+"Lat and "+prefix+"Lng must be a latitude and longitude in degrees")
+	}
+	return &models.GeoPoint{Latitude: lat, Longitude: lng}, nil
+}
+
+// parseFinite parses a float, rejecting NaN and the infinities, which
+// strconv accepts but compare false against any bound.
+func parseFinite(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, strconv.ErrSyntax
+	}
+	return v, nil
+}
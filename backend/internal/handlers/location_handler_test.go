@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseGeoSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "origin", query: "originLat=6.6745&originLng=-1.5716"},
+		{name: "origin and radius", query: "originLat=6.6745&originLng=-1.5716&radius=500"},
+		{name: "corridor", query: "originLat=6.6745&originLng=-1.5716&destinationLat=5.6037&destinationLng=-0.1870"},
+		{name: "no origin", query: "radius=500", wantErr: true},
+		{name: "latitude out of range", query: "originLat=91&originLng=0", wantErr: true},
+		{name: "NaN latitude", query: "originLat=NaN&originLng=0", wantErr: true},
+		{name: "NaN longitude", query: "originLat=0&originLng=nan", wantErr: true},
+		{name: "infinite longitude", query: "originLat=0&originLng=-Inf", wantErr: true},
+		{name: "NaN destination", query: "originLat=0&originLng=0&destinationLat=NaN&destinationLng=0", wantErr: true},
+		{name: "NaN radius", query: "originLat=0&originLng=0&radius=NaN", wantErr: true},
+		{name: "infinite radius", query: "originLat=0&originLng=0&radius=Inf", wantErr: true},
+		{name: "unknown sort", query: "originLat=0&originLng=0&sort=price", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/search?"+tt.query, nil)
+			if _, err := parseGeoSearch(r, nil); (err != nil) != tt.wantErr {
+				t.Errorf("parseGeoSearch(%q) error = %v, want error: %t", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
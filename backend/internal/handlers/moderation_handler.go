@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/middleware"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+	"campus-connect/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var errUserBlocked = apperrors.Forbidden(apperrors.CodeUserBlocked, "You cannot deal with a user you blocked or who blocked you")
+
+// viewerID returns the signed-in user's ID on routes with optional
+// authentication, or nil for anonymous requests.
+func viewerID(r *http.Request) *uuid.UUID {
+	if user, ok := middleware.GetUserFromContext(r); ok {
+		return &user.ID
+	}
+	return nil
+}
+
+// checkBlocked fails with USER_BLOCKED if either user blocked the other.
+// It does nothing without a block repository.
+func checkBlocked(ctx context.Context, blocks repositories.BlockRepository, userID, otherID uuid.UUID) error {
+	if blocks == nil || userID == otherID {
+		return nil
+	}
+	blocked, err := blocks.IsBlocked(ctx, userID, otherID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return errUserBlocked
+	}
+	return nil
+}
+
+// ModerationHandler lets users block each other and report content, and
+// admins work through the reports.
+type ModerationHandler struct {
+	blocks       repositories.BlockRepository
+	reports      repositories.ReportRepository
+	userRepo     repositories.UserRepository
+	tripRepo     repositories.TripRepository
+	deliveryRepo repositories.DeliveryRepository
+	disputeRepo  repositories.DisputeRepository
+}
+
+func NewModerationHandler(
+	blocks repositories.BlockRepository,
+	reports repositories.ReportRepository,
+	userRepo repositories.UserRepository,
+	tripRepo repositories.TripRepository,
+	deliveryRepo repositories.DeliveryRepository,
+	disputeRepo repositories.DisputeRepository,
+) *ModerationHandler {
+	return &ModerationHandler{
+		blocks:       blocks,
+		reports:      reports,
+		userRepo:     userRepo,
+		tripRepo:     tripRepo,
+		deliveryRepo: deliveryRepo,
+		disputeRepo:  disputeRepo,
+	}
+}
+
+// BlockUser hides the two users' trips and requests from each other and
+// stops them dealing with each other.
+func (h *ModerationHandler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req models.BlockUserRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+	if req.UserID == user.ID {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "You cannot block yourself")
+		return
+	}
+
+	if _, err := h.userRepo.GetByID(r.Context(), req.UserID); err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	if err := h.blocks.Block(r.Context(), user.ID, req.UserID); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to block user")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "User blocked successfully", nil)
+}
+
+func (h *ModerationHandler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	blockedID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid user ID format")
+		return
+	}
+
+	if err := h.blocks.Unblock(r.Context(), user.ID, blockedID); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to unblock user")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "User unblocked successfully", nil)
+}
+
+// GetBlockedUsers lists the users the caller blocked.
+func (h *ModerationHandler) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	blocks, err := h.blocks.ListBlocked(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get blocked users")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Blocked users retrieved successfully", map[string]interface{}{
+		"blocks": blocks,
+	})
+}
+
+// CreateReport flags a trip, delivery request, dispute message or user for
+// the moderation queue.
+func (h *ModerationHandler) CreateReport(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req models.CreateReportRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	reportedUserID, err := h.reportedUser(r.Context(), user, req.TargetType, req.TargetID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	if reportedUserID == user.ID {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "You cannot report yourself or your own content")
+		return
+	}
+
+	report := &models.Report{
+		ReporterID:     user.ID,
+		TargetType:     req.TargetType,
+		TargetID:       req.TargetID,
+		ReportedUserID: reportedUserID,
+		Reason:         req.Reason,
+		Details:        req.Details,
+	}
+	if err := h.reports.Create(r.Context(), report); err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	metrics.Reports.WithLabelValues(string(req.TargetType)).Inc()
+
+	utils.WriteCreatedResponse(w, "Report submitted successfully", report)
+}
+
+// reportedUser returns who posted the reported content. Dispute messages can
+// only be reported by those who can see them.
+func (h *ModerationHandler) reportedUser(ctx context.Context, user *models.User, target models.ReportTarget, id uuid.UUID) (uuid.UUID, error) {
+	switch target {
+	case models.ReportTrip:
+		trip, err := h.tripRepo.GetByID(ctx, id)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return trip.TravelerID, nil
+	case models.ReportDeliveryRequest:
+		request, err := h.deliveryRepo.GetByID(ctx, id)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return request.UserID, nil
+	case models.ReportMessage:
+		errMessageNotFound := apperrors.NotFound(apperrors.CodeNotFound, "Message not found")
+		note, err := h.disputeRepo.GetNote(ctx, id)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if note.AuthorID == nil || note.Internal {
+			return uuid.Nil, errMessageNotFound
+		}
+		dispute, err := h.disputeRepo.GetByID(ctx, note.DisputeID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if !dispute.IsParty(user.ID) && !user.IsAdmin() {
+			return uuid.Nil, errMessageNotFound
+		}
+		return *note.AuthorID, nil
+	default:
+		if _, err := h.userRepo.GetByID(ctx, id); err != nil {
+			return uuid.Nil, err
+		}
+		return id, nil
+	}
+}
+
+// GetMyReports lists the caller's reports and what came of them.
+func (h *ModerationHandler) GetMyReports(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	page, limit := pagination(r)
+	reports, totalCount, err := h.reports.ListByReporter(r.Context(), user.ID, limit, (page-1)*limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get reports")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Reports retrieved successfully", map[string]interface{}{
+		"reports":      reports,
+		"totalReports": totalCount,
+		"currentPage":  page,
+		"totalPages":   (totalCount + limit - 1) / limit,
+	})
+}
+
+// ListReports is the admins' moderation queue. It lists open reports unless
+// another status, or "all", is asked for.
+func (h *ModerationHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	filter := models.ReportFilter{Status: models.ReportOpen}
+	switch s := r.URL.Query().Get("status"); s {
+	case "":
+	case "all":
+		filter.Status = ""
+	default:
+		filter.Status = models.ReportStatus(s)
+		if !filter.Status.IsValid() {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "status must be open, actioned, dismissed or all")
+			return
+		}
+	}
+	if s := r.URL.Query().Get("targetType"); s != "" {
+		filter.TargetType = models.ReportTarget(s)
+		if !filter.TargetType.IsValid() {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "targetType must be trip, delivery_request, message or user")
+			return
+		}
+	}
+
+	page, limit := pagination(r)
+	reports, totalCount, err := h.reports.List(r.Context(), filter, limit, (page-1)*limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get reports")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Reports retrieved successfully", map[string]interface{}{
+		"reports":      reports,
+		"totalReports": totalCount,
+		"currentPage":  page,
+		"totalPages":   (totalCount + limit - 1) / limit,
+	})
+}
+
+// ReviewReport records whether action was taken on an open report.
+func (h *ModerationHandler) ReviewReport(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	reportID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid report ID format")
+		return
+	}
+
+	var req models.ReviewReportRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	report, err := h.reports.GetByID(r.Context(), reportID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	report.Status = req.Status
+	report.ReviewNote = req.Note
+	report.ReviewedBy = &admin.ID
+	if err := h.reports.Review(r.Context(), report); err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("report reviewed",
+		"report_id", report.ID,
+		"status", report.Status,
+		"admin_id", admin.ID,
+	)
+
+	utils.WriteSuccessResponse(w, "Report reviewed successfully", report)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"campus-connect/internal/logging"
+	"campus-connect/internal/middleware"
+	"campus-connect/internal/models"
+	"campus-connect/internal/services"
+	"campus-connect/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxWebhookBytes bounds the webhook bodies read before their signature is
+// checked.
+const maxWebhookBytes = 64 << 10
+
+type PaymentHandler struct {
+	payments *services.PaymentService
+}
+
+func NewPaymentHandler(payments *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		payments: payments,
+	}
+}
+
+// InitiatePayment charges the requester's mobile money wallet for a matched
+// delivery request. The charge is usually still pending when this returns;
+// the payer approves it on their phone and the provider's webhook settles it.
+func (h *PaymentHandler) InitiatePayment(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req models.InitiatePaymentRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	payment, err := h.payments.Initiate(r.Context(), user, req)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	utils.WriteCreatedResponse(w, "Payment initiated successfully", payment)
+}
+
+// SubmitOTP completes a charge that needs a one-time code, as Telecel Cash
+// charges do.
+func (h *PaymentHandler) SubmitOTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req models.SubmitPaymentOTPRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	payment, err := h.payments.SubmitOTP(r.Context(), user, chi.URLParam(r, "reference"), req.OTP)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Payment code submitted successfully", payment)
+}
+
+func (h *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	payment, err := h.payments.Get(r.Context(), user, chi.URLParam(r, "reference"))
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Payment retrieved successfully", payment)
+}
+
+// Webhook receives charge updates from the payment provider. Anything but a
+// 2xx response makes the provider retry, so only unsigned webhooks and
+// failures to record them are errors.
+func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusRequestEntityTooLarge, "Webhook body is too large")
+		return
+	}
+
+	if err := h.payments.HandleWebhook(r.Context(), body, r.Header); err != nil {
+		if errors.Is(err, services.ErrInvalidWebhookSignature) {
+			logging.FromContext(r.Context()).Warn("rejected unsigned payment webhook")
+			utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "Invalid webhook signature")
+			return
+		}
+		utils.WriteInternalError(w, r, err, "Failed to process webhook")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Webhook processed", nil)
+}
+
+func (h *PaymentHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrProviderUnavailable) {
+		logging.FromContext(r.Context()).Error("payment provider unavailable", "error", err)
+		utils.WriteErrorResponse(w, r, http.StatusBadGateway, "Payment provider is unavailable, please try again later")
+		return
+	}
+	utils.WriteError(w, r, err)
+}
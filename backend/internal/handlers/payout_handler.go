@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/middleware"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+	"campus-connect/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var errPayoutReviewed = apperrors.Conflict(apperrors.CodePayoutReviewed, "Payout has already been reviewed")
+
+type PayoutHandler struct {
+	payoutRepo repositories.PayoutRepository
+	ledger     repositories.LedgerRepository
+	tx         repositories.Transactor
+}
+
+func NewPayoutHandler(payoutRepo repositories.PayoutRepository, ledger repositories.LedgerRepository, tx repositories.Transactor) *PayoutHandler {
+	return &PayoutHandler{
+		payoutRepo: payoutRepo,
+		ledger:     ledger,
+		tx:         tx,
+	}
+}
+
+// RequestPayout asks for part of the traveler's wallet to be sent to their
+// mobile money number. The amount is reserved until an admin reviews it.
+func (h *PayoutHandler) RequestPayout(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req models.CreatePayoutRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	phoneNumber, err := utils.NormalizeGhanaPhone(req.PhoneNumber)
+	if err != nil {
+		utils.WriteError(w, r, errInvalidPhoneNumber)
+		return
+	}
+
+	payout := &models.Payout{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		Amount:      req.Amount,
+		Network:     req.Network,
+		PhoneNumber: phoneNumber,
+		Status:      models.PayoutPending,
+	}
+	if err := h.payoutRepo.Create(r.Context(), payout); err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("payout requested", "payout_id", payout.ID, "amount", int64(payout.Amount))
+	utils.WriteCreatedResponse(w, "Payout requested successfully", payout)
+}
+
+func (h *PayoutHandler) GetMyPayouts(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	payouts, err := h.payoutRepo.GetByUserID(r.Context(), user.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get payouts")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Payouts retrieved successfully", payouts)
+}
+
+// ListPayouts is the admins' review queue. It lists pending payouts unless
+// another status, or "all", is asked for.
+func (h *PayoutHandler) ListPayouts(w http.ResponseWriter, r *http.Request) {
+	status := models.PayoutPending
+	switch s := r.URL.Query().Get("status"); s {
+	case "":
+	case "all":
+		status = ""
+	default:
+		status = models.PayoutStatus(s)
+		if !status.IsValid() {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "status must be pending, approved, rejected or all")
+			return
+		}
+	}
+
+	page := 1
+	limit := 20
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	payouts, totalCount, err := h.payoutRepo.List(r.Context(), status, limit, (page-1)*limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get payouts")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Payouts retrieved successfully", map[string]interface{}{
+		"payouts":      payouts,
+		"totalPayouts": totalCount,
+		"currentPage":  page,
+		"totalPages":   (totalCount + limit - 1) / limit,
+	})
+}
+
+// ApprovePayout records that an admin sent a payout's money, debiting the
+// traveler's wallet.
+func (h *PayoutHandler) ApprovePayout(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	payoutID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid payout ID format")
+		return
+	}
+
+	var req models.ApprovePayoutRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	var payout *models.Payout
+	err = h.tx.InTx(r.Context(), func(ctx context.Context) error {
+		var err error
+		if payout, err = h.payoutRepo.GetByID(ctx, payoutID); err != nil {
+			return err
+		}
+		if payout.Status != models.PayoutPending {
+			return errPayoutReviewed
+		}
+		// Review fails on a payout reviewed concurrently, rolling this back
+		txn, err := h.ledger.Payout(ctx, payout.ID, payout.UserID, payout.Amount)
+		if err != nil {
+			return err
+		}
+		payout.Status = models.PayoutApproved
+		payout.TransferReference = &req.TransferReference
+		payout.LedgerTransactionID = &txn.ID
+		payout.ReviewedBy = &admin.ID
+		return h.payoutRepo.Review(ctx, payout)
+	})
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	metrics.Payouts.WithLabelValues(string(payout.Status)).Inc()
+	logging.FromContext(r.Context()).Info("payout approved", "payout_id", payout.ID, "amount", int64(payout.Amount))
+	utils.WriteSuccessResponse(w, "Payout approved successfully", payout)
+}
+
+// RejectPayout declines a payout, releasing the amount it reserved.
+func (h *PayoutHandler) RejectPayout(w http.ResponseWriter, r *http.Request) {
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	payoutID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid payout ID format")
+		return
+	}
+
+	var req models.RejectPayoutRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	payout, err := h.payoutRepo.GetByID(r.Context(), payoutID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	payout.Status = models.PayoutRejected
+	payout.RejectionReason = &req.Reason
+	payout.ReviewedBy = &admin.ID
+	if err := h.payoutRepo.Review(r.Context(), payout); err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	metrics.Payouts.WithLabelValues(string(payout.Status)).Inc()
+	utils.WriteSuccessResponse(w, "Payout rejected successfully", payout)
+}
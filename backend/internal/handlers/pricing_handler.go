@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"campus-connect/internal/logging"
+	"campus-connect/internal/models"
+	"campus-connect/internal/services"
+	"campus-connect/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+type PricingHandler struct {
+	pricing *services.PricingService
+}
+
+func NewPricingHandler(pricing *services.PricingService) *PricingHandler {
+	return &PricingHandler{
+		pricing: pricing,
+	}
+}
+
+// GetEstimate suggests a price range for a delivery between two locations.
+// Given an amount, it also says whether that amount is an outlier.
+func (h *PricingHandler) GetEstimate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := models.PriceQuery{
+		PickupLocation:  query.Get("pickupLocation"),
+		DropoffLocation: query.Get("dropoffLocation"),
+		ItemSize:        models.ItemSize(query.Get("itemSize")),
+		Priority:        models.Priority(query.Get("priority")),
+	}
+	if q.PickupLocation == "" || q.DropoffLocation == "" {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "pickupLocation and dropoffLocation are required")
+		return
+	}
+	if q.ItemSize != "" && !q.ItemSize.IsValid() {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "itemSize must be small, medium or large")
+		return
+	}
+	if q.Priority != "" && !q.Priority.IsValid() {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "priority must be low, normal, high or urgent")
+		return
+	}
+	if s := query.Get("pickupDate"); s != "" {
+		pickupDate, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "pickupDate must be a date like 2025-01-31")
+			return
+		}
+		// Dates have no time of day; count from midday
+		q.LeadTime = max(time.Until(pickupDate.Add(12*time.Hour)), time.Minute)
+	}
+
+	estimate, err := h.pricing.Estimate(r.Context(), q)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to estimate price")
+		return
+	}
+
+	response := map[string]interface{}{
+		"estimate": estimate,
+	}
+	if s := query.Get("amount"); s != "" {
+		amount, err := strconv.ParseFloat(s, 64)
+		if err != nil || amount <= 0 {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "amount must be a positive number")
+			return
+		}
+		if outlier := estimate.Outlier(amount); outlier != "" {
+			response["outlier"] = outlier
+		}
+	}
+
+	utils.WriteSuccessResponse(w, "Price estimated successfully", response)
+}
+
+// ListPriceFlags lists delivery requests and trips created with outlying
+// prices, newest first.
+func (h *PricingHandler) ListPriceFlags(w http.ResponseWriter, r *http.Request) {
+	page := 1
+	limit := 20
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	flags, totalCount, err := h.pricing.ListFlags(r.Context(), limit, (page-1)*limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get price flags")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Price flags retrieved successfully", map[string]interface{}{
+		"flags":       flags,
+		"totalFlags":  totalCount,
+		"currentPage": page,
+		"totalPages":  (totalCount + limit - 1) / limit,
+	})
+}
+
+// addPriceCheck checks the price of a delivery request or trip just created,
+// adding the estimate and any outlier to its create response. Pricing is
+// advisory, so failures are only logged.
+func addPriceCheck(
+	r *http.Request,
+	pricing *services.PricingService,
+	response map[string]interface{},
+	subject models.PriceFlagSubject,
+	subjectID, userID uuid.UUID,
+	q models.PriceQuery,
+	amount float64,
+) {
+	if pricing == nil {
+		return
+	}
+	estimate, outlier, err := pricing.CheckPrice(r.Context(), subject, subjectID, userID, q, amount)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to check price", "subject_type", subject, "subject_id", subjectID, "error", err)
+	}
+	if estimate != nil {
+		response["priceEstimate"] = estimate
+	}
+	if outlier != "" {
+		response["priceOutlier"] = outlier
+	}
+}
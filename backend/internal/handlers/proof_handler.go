@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/middleware"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+	"campus-connect/internal/services"
+	"campus-connect/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	maxProofPhotoSize = 10 << 20
+	proofPhotoURLTTL  = 5 * time.Minute
+)
+
+var proofPhotoUpload = fileUpload{
+	name:    "Photo",
+	maxSize: maxProofPhotoSize,
+	types: map[string]bool{
+		"image/jpeg": true,
+		"image/png":  true,
+		"image/webp": true,
+	},
+	typeError: "Photo must be a JPEG, PNG or WebP image",
+}
+
+type ProofHandler struct {
+	deliveryRepo repositories.DeliveryRepository
+	tripRepo     repositories.TripRepository
+	proofRepo    repositories.ProofRepository
+	store        services.ObjectStore
+}
+
+func NewProofHandler(
+	deliveryRepo repositories.DeliveryRepository,
+	tripRepo repositories.TripRepository,
+	proofRepo repositories.ProofRepository,
+	store services.ObjectStore,
+) *ProofHandler {
+	return &ProofHandler{
+		deliveryRepo: deliveryRepo,
+		tripRepo:     tripRepo,
+		proofRepo:    proofRepo,
+		store:        store,
+	}
+}
+
+// loadDelivery returns the delivery request in the URL and its matched
+// trip's traveler, which is uuid.Nil if there is none.
+func (h *ProofHandler) loadDelivery(r *http.Request) (*models.DeliveryRequest, uuid.UUID, error) {
+	requestID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, uuid.Nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Invalid request ID format")
+	}
+	request, err := h.deliveryRepo.GetByID(r.Context(), requestID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if request.MatchedTripID == nil {
+		return request, uuid.Nil, nil
+	}
+	trip, err := h.tripRepo.GetByID(r.Context(), *request.MatchedTripID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return request, trip.TravelerID, nil
+}
+
+// GetHandoverCode shows the requester the code to give the traveler at
+// handover, while the delivery is matched or in transit.
+func (h *ProofHandler) GetHandoverCode(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	request, _, err := h.loadDelivery(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	if request.UserID != user.ID {
+		utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotRequestOwner, "Only the requester can see the handover code"))
+		return
+	}
+	if request.Status != models.DeliveryMatched && request.Status != models.DeliveryInTransit {
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeRequestNotMatched, "Handover codes are only shown for matched deliveries"))
+		return
+	}
+
+	code, err := h.proofRepo.GetHandoverCode(r.Context(), request.ID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Handover code retrieved successfully", map[string]interface{}{
+		"code":              code.Code,
+		"createdAt":         code.CreatedAt,
+		"attemptsRemaining": max(models.MaxHandoverCodeAttempts-code.FailedAttempts, 0),
+	})
+}
+
+// UploadPhotoProof lets the traveler attach a photo of the handover, with
+// where and when it was taken, to a delivery in transit or delivered.
+func (h *ProofHandler) UploadPhotoProof(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	if h.store == nil {
+		utils.WriteErrorResponse(w, r, http.StatusInternalServerError, "File storage not configured")
+		return
+	}
+
+	request, travelerID, err := h.loadDelivery(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	if travelerID != user.ID {
+		utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotTripOwner, "Only the trip traveler can add a proof photo"))
+		return
+	}
+	if request.Status != models.DeliveryInTransit && request.Status != models.DeliveryDelivered {
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeInvalidTransition, "Proof photos can only be added once the delivery is in transit"))
+		return
+	}
+
+	data, contentType, ok := proofPhotoUpload.read(w, r)
+	if !ok {
+		return
+	}
+	req, err := parsePhotoProofForm(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	proof := &models.DeliveryProof{
+		ID:                uuid.New(),
+		DeliveryRequestID: request.ID,
+		Kind:              models.ProofPhoto,
+		SubmittedBy:       user.ID,
+		ContentType:       &contentType,
+		Latitude:          req.Latitude,
+		Longitude:         req.Longitude,
+		Accuracy:          req.Accuracy,
+		CapturedAt:        req.CapturedAt,
+	}
+	key := fmt.Sprintf("delivery_proofs/%s/%s", request.ID, proof.ID)
+	saved := proofPhotoUpload.store(w, r, h.store, key, data, contentType, func(key string) error {
+		proof.StorageKey = &key
+		return h.proofRepo.CreateProof(r.Context(), proof)
+	})
+	if !saved {
+		return
+	}
+	metrics.DeliveryProofs.WithLabelValues(string(models.ProofPhoto)).Inc()
+
+	if err := h.signProofURL(r, proof); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to create photo link")
+		return
+	}
+
+	utils.WriteCreatedResponse(w, "Proof photo added successfully", proof)
+}
+
+// parsePhotoProofForm reads the optional latitude, longitude, accuracy and
+// capturedAt form fields sent with a proof photo.
+func parsePhotoProofForm(r *http.Request) (*models.CreatePhotoProofRequest, error) {
+	req := &models.CreatePhotoProofRequest{}
+	fields := []struct {
+		name string
+		dest **float64
+	}{
+		{"latitude", &req.Latitude},
+		{"longitude", &req.Longitude},
+		{"accuracy", &req.Accuracy},
+	}
+	for _, f := range fields {
+		s := r.FormValue(f.name)
+		if s == "" {
+			continue
+		}
+		v, err := parseFinite(s)
+		if err != nil {
+			return nil, apperrors.InvalidInput(apperrors.CodeValidationFailed, f.name+" must be a number")
+		}
+		*f.dest = &v
+	}
+
+	if s := r.FormValue("capturedAt"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, apperrors.InvalidInput(apperrors.CodeValidationFailed, "capturedAt must be an RFC 3339 time")
+		}
+		req.CapturedAt = &t
+	}
+	return req, nil
+}
+
+// ListProofs returns a delivery's proofs to its requester, its traveler and
+// admins. Photos come with short-lived links.
+func (h *ProofHandler) ListProofs(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	request, travelerID, err := h.loadDelivery(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	if request.UserID != user.ID && travelerID != user.ID && !user.IsAdmin() {
+		utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotRequestOwner, "Only the requester, the traveler or an admin can see delivery proofs"))
+		return
+	}
+
+	proofs, err := h.proofRepo.ListProofs(r.Context(), request.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get delivery proofs")
+		return
+	}
+	for _, proof := range proofs {
+		if err := h.signProofURL(r, proof); err != nil {
+			utils.WriteInternalError(w, r, err, "Failed to create photo link")
+			return
+		}
+	}
+
+	utils.WriteSuccessResponse(w, "Delivery proofs retrieved successfully", map[string]interface{}{
+		"proofs": proofs,
+	})
+}
+
+// signProofURL sets a short-lived link to the proof's photo, if it has one.
+func (h *ProofHandler) signProofURL(r *http.Request, proof *models.DeliveryProof) error {
+	if proof.StorageKey == nil || h.store == nil {
+		return nil
+	}
+	url, err := h.store.URL(r.Context(), *proof.StorageKey, services.Private, proofPhotoURLTTL)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(proofPhotoURLTTL)
+	proof.URL = &url
+	proof.URLExpiresAt = &expiresAt
+	return nil
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParsePhotoProofForm(t *testing.T) {
+	tests := []struct {
+		name    string
+		form    url.Values
+		wantErr bool
+	}{
+		{name: "no fields", form: url.Values{}},
+		{name: "position", form: url.Values{"latitude": {"6.6745"}, "longitude": {"-1.5716"}, "accuracy": {"12"}}},
+		{name: "capture time", form: url.Values{"capturedAt": {"2026-10-01T12:00:00Z"}}},
+		{name: "not a number", form: url.Values{"latitude": {"north"}}, wantErr: true},
+		{name: "NaN latitude", form: url.Values{"latitude": {"NaN"}, "longitude": {"0"}}, wantErr: true},
+		{name: "infinite longitude", form: url.Values{"latitude": {"0"}, "longitude": {"Inf"}}, wantErr: true},
+		{name: "NaN accuracy", form: url.Values{"accuracy": {"nan"}}, wantErr: true},
+		{name: "bad capture time", form: url.Values{"capturedAt": {"yesterday"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if _, err := parsePhotoProofForm(r); (err != nil) != tt.wantErr {
+				t.Errorf("parsePhotoProofForm(%v) error = %v, want error: %t", tt.form, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/middleware"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+	"campus-connect/internal/services"
+	"campus-connect/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// trackingHeartbeat is how often an idle location stream sends a comment, so
+// proxies do not close it.
+const trackingHeartbeat = 15 * time.Second
+
+var errNotInTransit = apperrors.Conflict(apperrors.CodeNotInTransit, "Delivery is not in transit")
+
+type TrackingHandler struct {
+	deliveryRepo repositories.DeliveryRepository
+	tripRepo     repositories.TripRepository
+	tracking     *services.TrackingService
+}
+
+func NewTrackingHandler(deliveryRepo repositories.DeliveryRepository, tripRepo repositories.TripRepository, tracking *services.TrackingService) *TrackingHandler {
+	return &TrackingHandler{
+		deliveryRepo: deliveryRepo,
+		tripRepo:     tripRepo,
+		tracking:     tracking,
+	}
+}
+
+// loadDelivery returns the delivery request in the URL and its matched trip,
+// which is nil if there is none.
+func (h *TrackingHandler) loadDelivery(r *http.Request) (*models.DeliveryRequest, *models.Trip, error) {
+	requestID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Invalid request ID format")
+	}
+	request, err := h.deliveryRepo.GetByID(r.Context(), requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request.MatchedTripID == nil {
+		return request, nil, nil
+	}
+	trip, err := h.tripRepo.GetByID(r.Context(), *request.MatchedTripID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return request, trip, nil
+}
+
+// loadWatchedDelivery returns the delivery request in the URL if the user is
+// its requester or traveler and it is in transit.
+func (h *TrackingHandler) loadWatchedDelivery(r *http.Request, user *models.User) (*models.DeliveryRequest, error) {
+	request, trip, err := h.loadDelivery(r)
+	if err != nil {
+		return nil, err
+	}
+	if request.UserID != user.ID && (trip == nil || trip.TravelerID != user.ID) {
+		return nil, apperrors.Forbidden(apperrors.CodeNotRequestOwner, "Only the requester or the traveler can track a delivery")
+	}
+	if request.Status != models.DeliveryInTransit {
+		return nil, errNotInTransit
+	}
+	return request, nil
+}
+
+// ShareLocation records the traveler's position while the delivery is in
+// transit.
+func (h *TrackingHandler) ShareLocation(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	var req models.CreateLocationPingRequest
+	if err := utils.DecodeAndValidate(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	request, trip, err := h.loadDelivery(r)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	if trip == nil || trip.TravelerID != user.ID {
+		utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeNotTripOwner, "Only the trip traveler can share their location"))
+		return
+	}
+	if request.Status != models.DeliveryInTransit {
+		utils.WriteError(w, r, errNotInTransit)
+		return
+	}
+
+	ping := &models.LocationPing{
+		Latitude:   *req.Latitude,
+		Longitude:  *req.Longitude,
+		Accuracy:   req.Accuracy,
+		Speed:      req.Speed,
+		Heading:    req.Heading,
+		RecordedAt: time.Now().UTC(),
+	}
+	event, err := h.tracking.Record(r.Context(), request, trip.TransportMethod, ping)
+	if errors.Is(err, services.ErrTrackingStopped) {
+		utils.WriteError(w, r, errNotInTransit)
+		return
+	}
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to share location")
+		return
+	}
+	metrics.TrackingPings.Inc()
+
+	utils.WriteSuccessResponse(w, "Location shared successfully", event)
+}
+
+// GetLocation returns the traveler's latest position, or no data if none
+// has been shared recently.
+func (h *TrackingHandler) GetLocation(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	request, err := h.loadWatchedDelivery(r, user)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	event, err := h.tracking.Latest(r.Context(), request.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get location")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Location retrieved successfully", event)
+}
+
+// StreamLocation sends the traveler's position as server-sent events: the
+// latest one first, then each new one. The stream ends with a "stopped"
+// event once the delivery is no longer in transit.
+func (h *TrackingHandler) StreamLocation(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
+		return
+	}
+
+	request, err := h.loadWatchedDelivery(r, user)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	sub, err := h.tracking.Subscribe(ctx, request.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to track delivery")
+		return
+	}
+	defer sub.Close()
+
+	// The delivery may have completed before the subscription started, in
+	// which case its stopped event was missed
+	current, err := h.deliveryRepo.GetByID(ctx, request.ID)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	latest, err := h.tracking.Latest(ctx, request.ID)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to get location")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	log := logging.FromContext(ctx)
+
+	send := func(event *models.TrackingEvent) bool {
+		data, err := json.Marshal(event)
+		if err == nil {
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			log.Debug("location stream closed", "delivery_request_id", request.ID, "error", err)
+			return false
+		}
+		return true
+	}
+
+	if current.Status != models.DeliveryInTransit {
+		send(&models.TrackingEvent{Type: models.TrackingStopped, DeliveryRequestID: request.ID, Status: &current.Status})
+		return
+	}
+	if latest != nil && !send(latest) {
+		return
+	}
+
+	metrics.TrackingStreams.Inc()
+	defer metrics.TrackingStreams.Dec()
+
+	heartbeat := time.NewTicker(trackingHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok || !send(event) || event.Type == models.TrackingStopped {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -1,22 +1,30 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/metrics"
 	"campus-connect/internal/middleware"
 	"campus-connect/internal/models"
 	"campus-connect/internal/repositories"
+	"campus-connect/internal/services"
 	"campus-connect/internal/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
+var errTripFull = apperrors.Conflict(apperrors.CodeTripFull, "Trip is full")
+
 type TripHandler struct {
-	tripRepo repositories.TripRepository
+	tripRepo  repositories.TripRepository
+	pricing   *services.PricingService
+	locations repositories.LocationRepository
+	blocks    repositories.BlockRepository
 }
 
 func NewTripHandler(tripRepo repositories.TripRepository) *TripHandler {
@@ -25,20 +33,36 @@ func NewTripHandler(tripRepo repositories.TripRepository) *TripHandler {
 	}
 }
 
+// WithPricing adds a price estimate to created trips and flags outlying
+// prices per delivery.
+func (h *TripHandler) WithPricing(pricing *services.PricingService) *TripHandler {
+	h.pricing = pricing
+	return h
+}
+
+// WithLocations links trip locations to the catalogue.
+func (h *TripHandler) WithLocations(locations repositories.LocationRepository) *TripHandler {
+	h.locations = locations
+	return h
+}
+
+// WithBlocks hides trips between users who blocked each other and stops
+// them joining each other's trips.
+func (h *TripHandler) WithBlocks(blocks repositories.BlockRepository) *TripHandler {
+	h.blocks = blocks
+	return h
+}
+
 func (h *TripHandler) CreateTrip(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
 	var req models.CreateTripRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
@@ -53,16 +77,35 @@ func (h *TripHandler) CreateTrip(w http.ResponseWriter, r *http.Request) {
 		// If that fails, try without seconds (HH:mm format)
 		departureDateTime, err = time.Parse("2006-01-02T15:04", req.DepartureDate+"T"+req.DepartureTime)
 		if err != nil {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid departure date or time format. Expected YYYY-MM-DD and HH:mm")
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid departure date or time format. Expected YYYY-MM-DD and HH:mm")
 			return
 		}
 	}
 
+	from, err := resolveLocation(r.Context(), h.locations, "fromLocation",
+		req.FromLocationID, req.FromLocation, req.FromLatitude, req.FromLongitude)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	to, err := resolveLocation(r.Context(), h.locations, "toLocation",
+		req.ToLocationID, req.ToLocation, req.ToLatitude, req.ToLongitude)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+
 	trip := &models.Trip{
 		ID:                uuid.New(),
 		TravelerID:        user.ID,
-		FromLocation:      req.FromLocation,
-		ToLocation:        req.ToLocation,
+		FromLocation:      from.Name,
+		ToLocation:        to.Name,
+		FromLocationID:    from.ID,
+		ToLocationID:      to.ID,
+		FromLatitude:      from.Latitude,
+		FromLongitude:     from.Longitude,
+		ToLatitude:        to.Latitude,
+		ToLongitude:       to.Longitude,
 		DepartureTime:     departureDateTime,
 		TransportMethod:   req.VehicleType,
 		MaxDeliveries:     req.AvailableSeats,
@@ -74,14 +117,15 @@ func (h *TripHandler) CreateTrip(w http.ResponseWriter, r *http.Request) {
 		ContactInfo:       req.ContactInfo,
 	}
 
-	if err := h.tripRepo.Create(trip); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create trip")
+	if err := h.tripRepo.Create(r.Context(), trip); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to create trip")
 		return
 	}
+	metrics.TripsCreated.Inc()
 
-	createdTrip, err := h.tripRepo.GetByID(trip.ID)
+	createdTrip, err := h.tripRepo.GetByID(r.Context(), trip.ID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve created trip")
+		utils.WriteInternalError(w, r, err, "Failed to retrieve created trip")
 		return
 	}
 
@@ -89,6 +133,11 @@ func (h *TripHandler) CreateTrip(w http.ResponseWriter, r *http.Request) {
 		"message": "Trip created successfully",
 		"trip":    createdTrip,
 	}
+	addPriceCheck(r, h.pricing, response, models.PriceFlagTrip, trip.ID, user.ID, models.PriceQuery{
+		PickupLocation:  trip.FromLocation,
+		DropoffLocation: trip.ToLocation,
+		LeadTime:        max(time.Until(trip.DepartureTime), time.Minute),
+	}, trip.PricePerDelivery)
 
 	utils.WriteCreatedResponse(w, "Trip created successfully", response)
 }
@@ -114,9 +163,9 @@ func (h *TripHandler) GetTrips(w http.ResponseWriter, r *http.Request) {
 
 	offset := (page - 1) * limit
 
-	trips, totalCount, err := h.tripRepo.GetActiveTrips(limit, offset)
+	trips, totalCount, err := h.tripRepo.GetActiveTrips(r.Context(), viewerID(r), limit, offset)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to get trips")
+		utils.WriteInternalError(w, r, err, "Failed to get trips")
 		return
 	}
 
@@ -132,34 +181,77 @@ func (h *TripHandler) GetTrips(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccessResponse(w, "Trips retrieved successfully", response)
 }
 
+// SearchNearbyTrips finds active trips leaving from near a point, or along a
+// route corridor; see parseGeoSearch. Trips without coordinates are not
+// found.
+func (h *TripHandler) SearchNearbyTrips(w http.ResponseWriter, r *http.Request) {
+	search, err := parseGeoSearch(r, h.locations)
+	if err != nil {
+		utils.WriteError(w, r, err)
+		return
+	}
+	search.ViewerID = viewerID(r)
+
+	page := 1
+	limit := 10
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	trips, totalCount, err := h.tripRepo.SearchNearby(r.Context(), search, limit, (page-1)*limit)
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to search trips")
+		return
+	}
+
+	utils.WriteSuccessResponse(w, "Trips retrieved successfully", map[string]interface{}{
+		"trips":       trips,
+		"totalTrips":  totalCount,
+		"currentPage": page,
+		"totalPages":  (totalCount + limit - 1) / limit,
+	})
+}
+
 func (h *TripHandler) GetTripDetails(w http.ResponseWriter, r *http.Request) {
 	tripIDStr := chi.URLParam(r, "id")
 	if tripIDStr == "" {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Trip ID is required")
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Trip ID is required")
 		return
 	}
 
 	tripID, err := uuid.Parse(tripIDStr)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid trip ID format")
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid trip ID format")
 		return
 	}
 
-	trip, err := h.tripRepo.GetByID(tripID)
+	trip, err := h.tripRepo.GetByID(r.Context(), tripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "Trip not found")
+		utils.WriteError(w, r, err)
 		return
 	}
+	if viewer := viewerID(r); viewer != nil {
+		if err := checkBlocked(r.Context(), h.blocks, *viewer, trip.TravelerID); err != nil {
+			if errors.Is(err, errUserBlocked) {
+				err = apperrors.NotFound(apperrors.CodeTripNotFound, "Trip not found")
+			}
+			utils.WriteError(w, r, err)
+			return
+		}
+	}
 
-	participants, err := h.tripRepo.GetParticipants(tripID)
+	participants, err := h.tripRepo.GetParticipants(r.Context(), tripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to get trip participants")
+		utils.WriteInternalError(w, r, err, "Failed to get trip participants")
 		return
 	}
 
-	matchedRequests, err := h.tripRepo.GetMatchedRequests(tripID)
+	matchedRequests, err := h.tripRepo.GetMatchedRequests(r.Context(), tripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to get matched requests")
+		utils.WriteInternalError(w, r, err, "Failed to get matched requests")
 		return
 	}
 
@@ -179,46 +271,47 @@ func (h *TripHandler) GetTripDetails(w http.ResponseWriter, r *http.Request) {
 func (h *TripHandler) JoinTrip(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
 	var req models.JoinTripRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	trip, err := h.tripRepo.GetByID(req.TripID)
+	trip, err := h.tripRepo.GetByID(r.Context(), req.TripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "Trip not found")
+		utils.WriteError(w, r, err)
+		return
+	}
+
+	if err := checkBlocked(r.Context(), h.blocks, user.ID, trip.TravelerID); err != nil {
+		utils.WriteError(w, r, err)
 		return
 	}
 
 	if trip.CurrentDeliveries >= trip.MaxDeliveries {
-		utils.WriteErrorResponse(w, http.StatusConflict, "Trip is full")
+		utils.WriteError(w, r, errTripFull)
 		return
 	}
 
-	participants, err := h.tripRepo.GetParticipants(req.TripID)
+	participants, err := h.tripRepo.GetParticipants(r.Context(), req.TripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to check trip participants")
+		utils.WriteInternalError(w, r, err, "Failed to check trip participants")
 		return
 	}
 
 	for _, participant := range participants {
 		if participant.ID == user.ID {
-			utils.WriteErrorResponse(w, http.StatusConflict, "You are already part of this trip")
+			utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeAlreadyJoined, "You are already part of this trip"))
 			return
 		}
 	}
 
-	if err := h.tripRepo.AddParticipant(req.TripID, user.ID); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to join trip")
+	if err := h.tripRepo.AddParticipant(r.Context(), req.TripID, user.ID); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to join trip")
 		return
 	}
 
@@ -228,29 +321,25 @@ func (h *TripHandler) JoinTrip(w http.ResponseWriter, r *http.Request) {
 func (h *TripHandler) LeaveTrip(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
 	var req models.LeaveTripRequest
 	if err := utils.DecodeAndValidate(r, &req); err != nil {
-		if strings.Contains(err.Error(), "validation failed") {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, utils.FormatValidationError(err))
-		} else {
-			utils.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		}
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	_, err := h.tripRepo.GetByID(req.TripID)
+	_, err := h.tripRepo.GetByID(r.Context(), req.TripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusNotFound, "Trip not found")
+		utils.WriteError(w, r, err)
 		return
 	}
 
-	participants, err := h.tripRepo.GetParticipants(req.TripID)
+	participants, err := h.tripRepo.GetParticipants(r.Context(), req.TripID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to check trip participants")
+		utils.WriteInternalError(w, r, err, "Failed to check trip participants")
 		return
 	}
 
@@ -263,12 +352,12 @@ func (h *TripHandler) LeaveTrip(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !isParticipant {
-		utils.WriteErrorResponse(w, http.StatusConflict, "You are not part of this trip")
+		utils.WriteError(w, r, apperrors.Conflict(apperrors.CodeNotParticipant, "You are not part of this trip"))
 		return
 	}
 
-	if err := h.tripRepo.RemoveParticipant(req.TripID, user.ID); err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to leave trip")
+	if err := h.tripRepo.RemoveParticipant(r.Context(), req.TripID, user.ID); err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to leave trip")
 		return
 	}
 
@@ -278,13 +367,13 @@ func (h *TripHandler) LeaveTrip(w http.ResponseWriter, r *http.Request) {
 func (h *TripHandler) GetMyTrips(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		utils.WriteErrorResponse(w, http.StatusUnauthorized, "User not found in context")
+		utils.WriteErrorResponse(w, r, http.StatusUnauthorized, "User not found in context")
 		return
 	}
 
-	trips, err := h.tripRepo.GetByTravelerID(user.ID)
+	trips, err := h.tripRepo.GetByTravelerID(r.Context(), user.ID)
 	if err != nil {
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to get user trips")
+		utils.WriteInternalError(w, r, err, "Failed to get user trips")
 		return
 	}
 
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"campus-connect/internal/logging"
+	"campus-connect/internal/services"
+	"campus-connect/internal/utils"
+)
+
+// fileUpload describes the file an upload endpoint accepts in the "file"
+// field of a multipart form.
+type fileUpload struct {
+	// name is how error messages refer to the file, e.g. "Photo".
+	name    string
+	maxSize int64
+	types   map[string]bool
+	// typeError is the message for a file of a type not in types.
+	typeError string
+	// public stores the file as a public object. Uploads are private unless
+	// set.
+	public bool
+}
+
+func (u fileUpload) visibility() services.Visibility {
+	if u.public {
+		return services.Public
+	}
+	return services.Private
+}
+
+// read parses the request's multipart form and returns the file's contents
+// and detected content type. Other form fields can be read once it returns.
+// On failure it writes the error response and returns false.
+func (u fileUpload) read(w http.ResponseWriter, r *http.Request) ([]byte, string, bool) {
+	tooLarge := fmt.Sprintf("%s must be %dMB or smaller", u.name, u.maxSize>>20)
+
+	r.Body = http.MaxBytesReader(w, r.Body, u.maxSize+(1<<20))
+	if err := r.ParseMultipartForm(u.maxSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.WriteErrorResponse(w, r, http.StatusRequestEntityTooLarge, tooLarge)
+		} else {
+			utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Invalid multipart form data")
+		}
+		return nil, "", false
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "file is required")
+		return nil, "", false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, u.maxSize+1))
+	if err != nil {
+		utils.WriteErrorResponse(w, r, http.StatusBadRequest, "Failed to read uploaded file")
+		return nil, "", false
+	}
+	if int64(len(data)) > u.maxSize {
+		utils.WriteErrorResponse(w, r, http.StatusRequestEntityTooLarge, tooLarge)
+		return nil, "", false
+	}
+
+	contentType := http.DetectContentType(data)
+	if !u.types[contentType] {
+		utils.WriteErrorResponse(w, r, http.StatusUnsupportedMediaType, u.typeError)
+		return nil, "", false
+	}
+	return data, contentType, true
+}
+
+// store puts data in store under key, with the extension of its content type
+// so backends keep its format, then calls save with the stored key to record
+// it. If save fails the object is deleted again. On failure it writes the
+// error response and returns false.
+func (u fileUpload) store(
+	w http.ResponseWriter,
+	r *http.Request,
+	store services.ObjectStore,
+	key string,
+	data []byte,
+	contentType string,
+	save func(key string) error,
+) bool {
+	stored, err := store.Put(r.Context(), key+services.KeyExtension(contentType), bytes.NewReader(data), services.PutOptions{
+		ContentType: contentType,
+		Visibility:  u.visibility(),
+	})
+	if err != nil {
+		utils.WriteInternalError(w, r, err, "Failed to upload "+strings.ToLower(u.name))
+		return false
+	}
+
+	if err := save(stored.Key); err != nil {
+		if err := store.Delete(r.Context(), stored.Key, u.visibility()); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to delete stored file", "key", stored.Key, "error", err)
+		}
+		utils.WriteInternalError(w, r, err, "Failed to save "+strings.ToLower(u.name))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"campus-connect/internal/services"
+)
+
+var testUpload = fileUpload{
+	name:    "Attachment",
+	maxSize: 1 << 20,
+	types: map[string]bool{
+		"image/png":       true,
+		"application/pdf": true,
+	},
+	typeError: "Attachment must be a PNG image or a PDF",
+}
+
+var (
+	testPNG = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	testPDF = []byte("%PDF-1.7\n%test document\n")
+)
+
+// memoryStore keeps objects and their visibility in maps.
+type memoryStore struct {
+	objects    map[string][]byte
+	visibility map[string]services.Visibility
+	putErr     error
+}
+
+func (s *memoryStore) Put(ctx context.Context, key string, body io.Reader, opts services.PutOptions) (*services.StoredObject, error) {
+	if s.putErr != nil {
+		return nil, s.putErr
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	s.objects[key] = data
+	s.visibility[key] = opts.Visibility
+	return &services.StoredObject{Key: key}, nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, key string, visibility services.Visibility) error {
+	if s.visibility[key] != visibility {
+		return services.ErrObjectNotFound
+	}
+	delete(s.objects, key)
+	delete(s.visibility, key)
+	return nil
+}
+
+func (s *memoryStore) URL(ctx context.Context, key string, visibility services.Visibility, ttl time.Duration) (string, error) {
+	return "memory://" + key, nil
+}
+
+func multipartRequest(t *testing.T, field string, data []byte) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	if field != "" {
+		part, err := form.CreateFormFile(field, "upload")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", "/", body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	return r
+}
+
+func TestFileUploadRead(t *testing.T) {
+	tests := []struct {
+		name            string
+		field           string
+		data            []byte
+		wantStatus      int
+		wantContentType string
+	}{
+		{name: "image", field: "file", data: testPNG, wantContentType: "image/png"},
+		{name: "pdf", field: "file", data: testPDF, wantContentType: "application/pdf"},
+		{name: "no file", wantStatus: http.StatusBadRequest},
+		{name: "wrong field", field: "photo", data: testPNG, wantStatus: http.StatusBadRequest},
+		{name: "unsupported type", field: "file", data: []byte("plain text"), wantStatus: http.StatusUnsupportedMediaType},
+		{name: "too large", field: "file", data: make([]byte, 3<<20), wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			data, contentType, ok := testUpload.read(w, multipartRequest(t, tt.field, tt.data))
+			if ok != (tt.wantStatus == 0) {
+				t.Fatalf("read() ok = %t, status %d: %s", ok, w.Code, w.Body)
+			}
+			if !ok {
+				if w.Code != tt.wantStatus {
+					t.Errorf("read() status = %d, want %d", w.Code, tt.wantStatus)
+				}
+				return
+			}
+			if contentType != tt.wantContentType {
+				t.Errorf("read() content type = %q, want %q", contentType, tt.wantContentType)
+			}
+			if !bytes.Equal(data, tt.data) {
+				t.Errorf("read() returned %d bytes, want %d", len(data), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestFileUploadStore(t *testing.T) {
+	errSave := errors.New("database down")
+	tests := []struct {
+		name        string
+		contentType string
+		public      bool
+		putErr      error
+		saveErr     error
+		wantKey     string
+		wantStatus  int
+	}{
+		{name: "pdf keeps its extension", contentType: "application/pdf", wantKey: "attachments/1.pdf"},
+		{name: "image", contentType: "image/png", wantKey: "attachments/1.png"},
+		{name: "public", contentType: "image/png", public: true, wantKey: "attachments/1.png"},
+		{name: "public save fails", contentType: "image/png", public: true, saveErr: errSave, wantStatus: http.StatusInternalServerError},
+		{name: "upload fails", contentType: "image/png", putErr: errors.New("store down"), wantStatus: http.StatusInternalServerError},
+		{name: "save fails", contentType: "image/png", saveErr: errSave, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{objects: map[string][]byte{}, visibility: map[string]services.Visibility{}, putErr: tt.putErr}
+			upload := testUpload
+			upload.public = tt.public
+			w := httptest.NewRecorder()
+			var savedKey string
+			ok := upload.store(w, httptest.NewRequest("POST", "/", nil), store, "attachments/1", testPDF, tt.contentType, func(key string) error {
+				savedKey = key
+				return tt.saveErr
+			})
+			if ok != (tt.wantStatus == 0) {
+				t.Fatalf("store() ok = %t, status %d: %s", ok, w.Code, w.Body)
+			}
+			if !ok {
+				if w.Code != tt.wantStatus {
+					t.Errorf("store() status = %d, want %d", w.Code, tt.wantStatus)
+				}
+				if len(store.objects) != 0 {
+					t.Errorf("store() left %d objects behind", len(store.objects))
+				}
+				return
+			}
+			if savedKey != tt.wantKey {
+				t.Errorf("saved key = %q, want %q", savedKey, tt.wantKey)
+			}
+			if _, stored := store.objects[tt.wantKey]; !stored {
+				t.Errorf("no object stored under %q", tt.wantKey)
+			}
+			if store.visibility[tt.wantKey] != upload.visibility() {
+				t.Errorf("stored visibility = %v, want %v", store.visibility[tt.wantKey], upload.visibility())
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Package logging builds the application's structured logger and carries the
+// per-request logger through contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// New returns a logger that writes JSON records at or above level to w.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel parses "debug", "info", "warn" or "error".
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+type ctxKey struct{}
+
+// requestLogger is stored by pointer so attributes added deeper in the
+// handler chain, such as the user ID, also appear in the access log written
+// by the outermost middleware.
+type requestLogger struct {
+	logger *slog.Logger
+}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &requestLogger{logger: logger})
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if rl, ok := ctx.Value(ctxKey{}).(*requestLogger); ok {
+		return rl.logger
+	}
+	return slog.Default()
+}
+
+// AddAttrs adds attributes to the logger carried by ctx for the rest of the
+// request. It does nothing if ctx carries no logger.
+func AddAttrs(ctx context.Context, args ...any) {
+	if rl, ok := ctx.Value(ctxKey{}).(*requestLogger); ok {
+		rl.logger = rl.logger.With(args...)
+	}
+}
@@ -0,0 +1,231 @@
+// Package metrics defines the Prometheus metrics exported at /metrics.
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "campus_connect"
+
+// Registry holds every metric the server exports. It is separate from the
+// default registry so only metrics defined here are exposed.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, chi route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and chi route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	Signups = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Accounts created.",
+	})
+
+	Verifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verifications_total",
+		Help:      "Successful email and phone verifications by kind.",
+	}, []string{"kind"})
+
+	TripsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trips_created_total",
+		Help:      "Trips created.",
+	})
+
+	DeliveryRequestsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "delivery_requests_created_total",
+		Help:      "Delivery requests created.",
+	})
+
+	Matches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "matches_total",
+		Help:      "Delivery requests matched with a trip.",
+	})
+
+	DeliveriesCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deliveries_completed_total",
+		Help:      "Delivery requests marked as delivered.",
+	})
+
+	Payments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_total",
+		Help:      "Mobile money payments that reached a final status, by status.",
+	}, []string{"status"})
+
+	PaymentAnomalies = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payment_anomalies_total",
+		Help:      "Charges needing an admin's attention, by kind: amount_mismatch or late_success.",
+	}, []string{"kind"})
+
+	Payouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payouts_total",
+		Help:      "Traveler payouts reviewed by admins, by status.",
+	}, []string{"status"})
+
+	PriceOutliers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "price_outliers_total",
+		Help:      "Delivery requests and trips created with an outlying price, by subject and direction.",
+	}, []string{"subject", "outlier"})
+
+	TrackingPings = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tracking_pings_total",
+		Help:      "Live location pings recorded for deliveries in transit.",
+	})
+
+	TrackingStreams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tracking_streams",
+		Help:      "Open live location streams.",
+	})
+
+	DeliveryProofs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "delivery_proofs_total",
+		Help:      "Proofs of delivery recorded, by kind.",
+	}, []string{"kind"})
+
+	Disputes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "disputes_total",
+		Help:      "Disputes by event: opened, escalated, response_expired, deadline_failed, closed or the resolution.",
+	}, []string{"event"})
+
+	Reports = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reports_total",
+		Help:      "Reports filed for moderation, by target type.",
+	}, []string{"target"})
+
+	LoginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_attempts_total",
+		Help:      "Sign-in attempts by result: success, blocked or the failure reason.",
+	}, []string{"result"})
+
+	ExternalCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "external_call_duration_seconds",
+		Help:      "Latency of calls to external services such as Redis and Brevo.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"service", "operation"})
+
+	ExternalCallErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "external_call_errors_total",
+		Help:      "Failed calls to external services such as Redis and Brevo.",
+	}, []string{"service", "operation"})
+)
+
+// Verification kinds.
+const (
+	VerificationEmail = "email"
+	VerificationPhone = "phone"
+)
+
+// Dispute events other than resolutions.
+const (
+	DisputeOpened          = "opened"
+	DisputeEscalated       = "escalated"
+	DisputeResponseExpired = "response_expired"
+	DisputeDeadlineFailed  = "deadline_failed"
+	DisputeClosed          = "closed"
+)
+
+// Sign-in results other than failure reasons.
+const (
+	LoginSucceeded = "success"
+	LoginBlocked   = "blocked"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		Signups,
+		Verifications,
+		TripsCreated,
+		DeliveryRequestsCreated,
+		Matches,
+		DeliveriesCompleted,
+		Payments,
+		PaymentAnomalies,
+		Payouts,
+		PriceOutliers,
+		TrackingPings,
+		TrackingStreams,
+		DeliveryProofs,
+		Disputes,
+		Reports,
+		LoginAttempts,
+		ExternalCallDuration,
+		ExternalCallErrors,
+	)
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func RegisterDB(db *sql.DB, dbName string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// Handler serves the metrics in Registry. When token is not empty, requests
+// must send it as a bearer token.
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte("Bearer "+token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// ObserveExternalCall records the latency of a call to an external service
+// that started at start, counting it as an error when err is not nil.
+func ObserveExternalCall(service, operation string, start time.Time, err error) {
+	ExternalCallDuration.WithLabelValues(service, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ExternalCallErrors.WithLabelValues(service, operation).Inc()
+	}
+}
@@ -5,17 +5,27 @@ import (
 	"net/http"
 	"strings"
 
+	"campus-connect/internal/apperrors"
 	"campus-connect/internal/auth"
+	"campus-connect/internal/logging"
 	"campus-connect/internal/models"
 	"campus-connect/internal/utils"
+
+	"github.com/google/uuid"
 )
 
 type contextKey string
 
 const UserContextKey contextKey = "user"
 
+// BanChecker reports whether a user has been banned.
+type BanChecker interface {
+	IsBanned(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
 type AuthMiddleware struct {
 	authService *auth.AuthService
+	bans        BanChecker
 }
 
 func NewAuthMiddleware(authService *auth.AuthService) *AuthMiddleware {
@@ -24,6 +34,22 @@ func NewAuthMiddleware(authService *auth.AuthService) *AuthMiddleware {
 	}
 }
 
+// WithBans turns away banned users on every request, not only when they
+// next sign in, so tokens issued before a ban stop working.
+func (am *AuthMiddleware) WithBans(bans BanChecker) *AuthMiddleware {
+	am.bans = bans
+	return am
+}
+
+// isBanned reports whether the user is banned, treating no ban checker as
+// nobody banned.
+func (am *AuthMiddleware) isBanned(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if am.bans == nil {
+		return false, nil
+	}
+	return am.bans.IsBanned(ctx, userID)
+}
+
 func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodOptions {
@@ -33,13 +59,13 @@ func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			utils.WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+			utils.WriteError(w, r, apperrors.Unauthorized(apperrors.CodeAuthRequired, "Authentication required"))
 			return
 		}
 
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			utils.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid authorization header format")
+			utils.WriteError(w, r, apperrors.Unauthorized(apperrors.CodeInvalidToken, "Invalid authorization header format"))
 			return
 		}
 		tokenString := bearerToken[1]
@@ -47,20 +73,33 @@ func (am *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		claims, err := am.authService.ValidateToken(tokenString)
 		if err != nil {
 			if err == auth.ErrTokenExpired {
-				utils.WriteErrorResponse(w, http.StatusUnauthorized, "Token expired")
+				utils.WriteError(w, r, apperrors.Unauthorized(apperrors.CodeTokenExpired, "Token expired"))
 			} else {
-				utils.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+				utils.WriteError(w, r, apperrors.Unauthorized(apperrors.CodeInvalidToken, "Invalid token"))
 			}
 			return
 		}
 
+		banned, err := am.isBanned(r.Context(), claims.UserID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("failed to check bans, refusing request", "error", err)
+			utils.WriteErrorResponse(w, r, http.StatusServiceUnavailable, "Service temporarily unavailable, please try again")
+			return
+		}
+		if banned {
+			utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeAccountBanned, "This account has been banned"))
+			return
+		}
+
 		user := &models.User{
 			ID:                 claims.UserID,
 			Email:              claims.Email,
 			VerificationStatus: claims.VerificationStatus,
+			Role:               claims.Role,
 		}
 
 		ctx := context.WithValue(r.Context(), UserContextKey, user)
+		logging.AddAttrs(ctx, "user_id", user.ID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -72,13 +111,24 @@ func (am *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 			bearerToken := strings.Split(authHeader, " ")
 			if len(bearerToken) == 2 && bearerToken[0] == "Bearer" {
 				tokenString := bearerToken[1]
-				if claims, err := am.authService.ValidateToken(tokenString); err == nil {
+				// Banned users, and any while bans cannot be checked, are
+				// treated as signed out
+				claims, err := am.authService.ValidateToken(tokenString)
+				if err == nil {
+					var banned bool
+					if banned, err = am.isBanned(r.Context(), claims.UserID); banned {
+						err = apperrors.ErrForbidden
+					}
+				}
+				if err == nil {
 					user := &models.User{
 						ID:                 claims.UserID,
 						Email:              claims.Email,
 						VerificationStatus: claims.VerificationStatus,
+						Role:               claims.Role,
 					}
 					ctx := context.WithValue(r.Context(), UserContextKey, user)
+					logging.AddAttrs(ctx, "user_id", user.ID)
 					r = r.WithContext(ctx)
 				}
 			}
@@ -88,6 +138,23 @@ func (am *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 	})
 }
 
+// RequireAdmin must be chained after RequireAuth. The role comes from the
+// token, so a demotion takes effect once the user's current token expires.
+func (am *AuthMiddleware) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := GetUserFromContext(r)
+		if !ok {
+			utils.WriteError(w, r, apperrors.Unauthorized(apperrors.CodeAuthRequired, "Authentication required"))
+			return
+		}
+		if !user.IsAdmin() {
+			utils.WriteError(w, r, apperrors.Forbidden(apperrors.CodeAdminRequired, "Admin access required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func GetUserFromContext(r *http.Request) (*models.User, bool) {
 	user, ok := r.Context().Value(UserContextKey).(*models.User)
 	return user, ok
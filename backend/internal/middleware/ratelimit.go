@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/ratelimit"
+	"campus-connect/internal/utils"
+)
+
+// RateLimitKeyFunc identifies the client a request is counted against.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitByIP counts requests per client IP. It relies on chi's RealIP
+// middleware having replaced RemoteAddr with the client address. RealIP
+// trusts X-Forwarded-For and X-Real-IP, so the API must sit behind a proxy
+// that overwrites them; otherwise clients choose the address counted.
+func RateLimitByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// RateLimitByUser counts requests per authenticated user, falling back to the
+// client IP for anonymous requests.
+func RateLimitByUser(r *http.Request) string {
+	if user, ok := GetUserFromContext(r); ok {
+		return "user:" + user.ID.String()
+	}
+	return RateLimitByIP(r)
+}
+
+// RateLimit allows each client, as identified by keyFunc, limit requests to
+// the routes in group. Every response carries RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers; rejected
+// requests get 429 with Retry-After. If the limiter fails the request is
+// refused with 503, so limited routes never go unprotected; use a
+// ratelimit.FallbackLimiter to keep them available through a Redis outage.
+func RateLimit(limiter ratelimit.Limiter, group string, limit ratelimit.Limit, keyFunc RateLimitKeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+		policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Window.Seconds()))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(r.Context(), group+":"+keyFunc(r), limit)
+			if err != nil {
+				logging.FromContext(r.Context()).Error("rate limiter unavailable, refusing request",
+					"group", group, "error", err)
+				utils.WriteErrorResponse(w, r, http.StatusServiceUnavailable, "Service temporarily unavailable, please try again")
+				return
+			}
+
+			reset := strconv.Itoa(ceilSeconds(result.Reset))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", reset)
+			w.Header().Set("RateLimit-Policy", policy)
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", reset)
+				utils.WriteError(w, r, apperrors.New(apperrors.ErrRateLimited, apperrors.CodeRateLimited,
+					fmt.Sprintf("Too many requests, please try again in %s seconds", reset)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the client address without its port. Behind chi's RealIP
+// middleware this is the address from X-Real-IP or X-Forwarded-For.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader is the response header carrying the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// ExposeRequestID must be chained after chi's RequestID middleware. It echoes
+// the request ID in the response so clients can quote it when reporting a
+// problem, matching the requestId field of error bodies.
+func ExposeRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestID := chiMiddleware.GetReqID(r.Context()); requestID != "" {
+			w.Header().Set(RequestIDHeader, requestID)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceRoute renames the server span started by otelhttp after the chi route
+// pattern once routing is complete, e.g. "GET /api/trips/{id}", so traces
+// group by route rather than by raw URL.
+func TraceRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		span := trace.SpanFromContext(r.Context())
+		if !span.IsRecording() {
+			return
+		}
+		route := routePattern(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+	})
+}
@@ -31,40 +31,101 @@ const (
 	PriorityUrgent Priority = "urgent"
 )
 
+func (s DeliveryStatus) IsValid() bool {
+	switch s {
+	case DeliveryPending, DeliveryMatched, DeliveryInTransit, DeliveryDelivered, DeliveryCancelled:
+		return true
+	}
+	return false
+}
+
+// deliveryTransitions lists the statuses each status may move to. Matching
+// (pending to matched) and withdrawing an offer (matched to pending) go
+// through the offer endpoints; the rest through the status endpoint.
+var deliveryTransitions = map[DeliveryStatus][]DeliveryStatus{
+	DeliveryPending:   {DeliveryMatched, DeliveryCancelled},
+	DeliveryMatched:   {DeliveryPending, DeliveryInTransit, DeliveryDelivered, DeliveryCancelled},
+	DeliveryInTransit: {DeliveryDelivered},
+}
+
+func (s DeliveryStatus) CanTransitionTo(next DeliveryStatus) bool {
+	for _, allowed := range deliveryTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s ItemSize) IsValid() bool {
+	switch s {
+	case ItemSizeSmall, ItemSizeMedium, ItemSizeLarge:
+		return true
+	}
+	return false
+}
+
+func (p Priority) IsValid() bool {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
 type DeliveryRequest struct {
-	ID                   uuid.UUID      `json:"id" db:"id"`
-	UserID               uuid.UUID      `json:"userId" db:"user_id"`
-	PickupLocation       string         `json:"pickupLocation" db:"pickup_location"`
-	DropoffLocation      string         `json:"dropoffLocation" db:"dropoff_location"`
-	ItemDescription      string         `json:"itemDescription" db:"item_description"`
-	ItemSize             ItemSize       `json:"itemSize" db:"item_size"`
-	Priority             Priority       `json:"priority" db:"priority"`
-	PaymentAmount        float64        `json:"paymentAmount" db:"payment_amount"`
-	PickupDate           time.Time      `json:"pickupDate" db:"pickup_date"`
-	PickupTime           string         `json:"pickupTime" db:"pickup_time"`
-	ContactInfo          string         `json:"contactInfo" db:"contact_info"`
-	SpecialInstructions  *string        `json:"specialInstructions" db:"special_instructions"`
-	Status               DeliveryStatus `json:"status" db:"status"`
-	MatchedTripID        *uuid.UUID     `json:"matchedTripId" db:"matched_trip_id"`
-	CreatedAt            time.Time      `json:"createdAt" db:"created_at"`
-	UpdatedAt            time.Time      `json:"updatedAt" db:"updated_at"`
-	
+	ID                  uuid.UUID      `json:"id" db:"id"`
+	UserID              uuid.UUID      `json:"userId" db:"user_id"`
+	PickupLocation      string         `json:"pickupLocation" db:"pickup_location"`
+	DropoffLocation     string         `json:"dropoffLocation" db:"dropoff_location"`
+	PickupLocationID    *uuid.UUID     `json:"pickupLocationId" db:"pickup_location_id"`
+	DropoffLocationID   *uuid.UUID     `json:"dropoffLocationId" db:"dropoff_location_id"`
+	PickupLatitude      *float64       `json:"pickupLatitude" db:"pickup_latitude"`
+	PickupLongitude     *float64       `json:"pickupLongitude" db:"pickup_longitude"`
+	DropoffLatitude     *float64       `json:"dropoffLatitude" db:"dropoff_latitude"`
+	DropoffLongitude    *float64       `json:"dropoffLongitude" db:"dropoff_longitude"`
+	ItemDescription     string         `json:"itemDescription" db:"item_description"`
+	ItemSize            ItemSize       `json:"itemSize" db:"item_size"`
+	Priority            Priority       `json:"priority" db:"priority"`
+	PaymentAmount       float64        `json:"paymentAmount" db:"payment_amount"`
+	PickupDate          time.Time      `json:"pickupDate" db:"pickup_date"`
+	PickupTime          string         `json:"pickupTime" db:"pickup_time"`
+	ContactInfo         string         `json:"contactInfo" db:"contact_info"`
+	SpecialInstructions *string        `json:"specialInstructions" db:"special_instructions"`
+	Status              DeliveryStatus `json:"status" db:"status"`
+	MatchedTripID       *uuid.UUID     `json:"matchedTripId" db:"matched_trip_id"`
+	CreatedAt           time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time      `json:"updatedAt" db:"updated_at"`
+
 	// Populated fields
-	User         *User `json:"user,omitempty"`
+	User          *User  `json:"user,omitempty"`
 	RequesterName string `json:"requesterName,omitempty"`
+	// Distances in metres from a proximity search's origin to the pickup and
+	// from its destination to the dropoff
+	Distance            *float64 `json:"distance,omitempty"`
+	DestinationDistance *float64 `json:"destinationDistance,omitempty"`
 }
 
+// CreateDeliveryRequestRequest takes each location as a catalogue ID, free
+// text, or both. Free text naming a catalogued location is linked to it.
+// Coordinates, given in pairs, override those of the catalogue.
 type CreateDeliveryRequestRequest struct {
-	PickupLocation      string   `json:"pickupLocation" validate:"required"`
-	DropoffLocation     string   `json:"dropoffLocation" validate:"required"`
-	ItemDescription     string   `json:"itemDescription" validate:"required"`
-	ItemSize            ItemSize `json:"itemSize" validate:"required"`
-	Priority            Priority `json:"priority"`
-	PaymentAmount       float64  `json:"paymentAmount" validate:"required,gt=0"`
-	PickupDate          string   `json:"pickupDate" validate:"required"`
-	PickupTime          string   `json:"pickupTime" validate:"required"`
-	ContactInfo         string   `json:"contactInfo" validate:"required"`
-	SpecialInstructions *string  `json:"specialInstructions"`
+	PickupLocation      string     `json:"pickupLocation" validate:"required_without=PickupLocationID,max=255"`
+	DropoffLocation     string     `json:"dropoffLocation" validate:"required_without=DropoffLocationID,max=255"`
+	PickupLocationID    *uuid.UUID `json:"pickupLocationId"`
+	DropoffLocationID   *uuid.UUID `json:"dropoffLocationId"`
+	PickupLatitude      *float64   `json:"pickupLatitude" validate:"required_with=PickupLongitude,omitnil,gte=-90,lte=90"`
+	PickupLongitude     *float64   `json:"pickupLongitude" validate:"required_with=PickupLatitude,omitnil,gte=-180,lte=180"`
+	DropoffLatitude     *float64   `json:"dropoffLatitude" validate:"required_with=DropoffLongitude,omitnil,gte=-90,lte=90"`
+	DropoffLongitude    *float64   `json:"dropoffLongitude" validate:"required_with=DropoffLatitude,omitnil,gte=-180,lte=180"`
+	ItemDescription     string     `json:"itemDescription" validate:"required,max=2000"`
+	ItemSize            ItemSize   `json:"itemSize" validate:"required,enum"`
+	Priority            Priority   `json:"priority" validate:"omitempty,enum"`
+	PaymentAmount       float64    `json:"paymentAmount" validate:"required,gt=0,lte=100000"`
+	PickupDate          string     `json:"pickupDate" validate:"required,datetime=2006-01-02"`
+	PickupTime          string     `json:"pickupTime" validate:"required,max=8"`
+	ContactInfo         string     `json:"contactInfo" validate:"required,max=500"`
+	SpecialInstructions *string    `json:"specialInstructions" validate:"omitempty,max=1000"`
 }
 
 type OfferDeliveryRequest struct {
@@ -72,6 +133,18 @@ type OfferDeliveryRequest struct {
 	TripID            uuid.UUID `json:"tripId" validate:"required"`
 }
 
+// UpdateDeliveryStatusRequest moves a delivery request along. The traveler
+// marks it in_transit and delivered, giving the requester's handover code
+// and where the handover happened; the requester may also confirm delivery,
+// and may cancel it before it is in transit.
+type UpdateDeliveryStatusRequest struct {
+	Status       DeliveryStatus `json:"status" validate:"required,oneof=in_transit delivered cancelled"`
+	HandoverCode string         `json:"handoverCode" validate:"omitempty,len=6,numeric"`
+	Latitude     *float64       `json:"latitude" validate:"required_with=Longitude,omitnil,gte=-90,lte=90"`
+	Longitude    *float64       `json:"longitude" validate:"required_with=Latitude,omitnil,gte=-180,lte=180"`
+	Accuracy     *float64       `json:"accuracy" validate:"omitnil,gte=0"`
+}
+
 type CancelDeliveryRequest struct {
 	DeliveryRequestID uuid.UUID `json:"deliveryRequestId" validate:"required"`
 	TripID            uuid.UUID `json:"tripId" validate:"required"`
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DisputeCategory string
+
+const (
+	DisputeLostItem     DisputeCategory = "lost_item"
+	DisputeDamagedItem  DisputeCategory = "damaged_item"
+	DisputeNoShow       DisputeCategory = "no_show"
+	DisputeLateDelivery DisputeCategory = "late_delivery"
+	DisputeAbuse        DisputeCategory = "abuse"
+	DisputePayment      DisputeCategory = "payment"
+	DisputeOther        DisputeCategory = "other"
+)
+
+func (c DisputeCategory) IsValid() bool {
+	switch c {
+	case DisputeLostItem, DisputeDamagedItem, DisputeNoShow, DisputeLateDelivery, DisputeAbuse, DisputePayment, DisputeOther:
+		return true
+	}
+	return false
+}
+
+type DisputeStatus string
+
+const (
+	DisputeOpen             DisputeStatus = "open"
+	DisputeInReview         DisputeStatus = "in_review"
+	DisputeAwaitingResponse DisputeStatus = "awaiting_response"
+	DisputeResolved         DisputeStatus = "resolved"
+	// DisputeClosed ends a case without a resolution, e.g. one opened by
+	// mistake.
+	DisputeClosed DisputeStatus = "closed"
+)
+
+func (s DisputeStatus) IsValid() bool {
+	switch s {
+	case DisputeOpen, DisputeInReview, DisputeAwaitingResponse, DisputeResolved, DisputeClosed:
+		return true
+	}
+	return false
+}
+
+// IsFinal reports whether the case is finished.
+func (s DisputeStatus) IsFinal() bool {
+	return s == DisputeResolved || s == DisputeClosed
+}
+
+// disputeTransitions lists the statuses each status may move to through the
+// status endpoint. Cases are resolved through the resolve endpoint.
+var disputeTransitions = map[DisputeStatus][]DisputeStatus{
+	DisputeOpen:             {DisputeInReview, DisputeAwaitingResponse, DisputeClosed},
+	DisputeInReview:         {DisputeAwaitingResponse, DisputeClosed},
+	DisputeAwaitingResponse: {DisputeInReview, DisputeClosed},
+}
+
+func (s DisputeStatus) CanTransitionTo(next DisputeStatus) bool {
+	for _, allowed := range disputeTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type DisputeResolution string
+
+const (
+	// ResolutionRefund returns the whole delivery fee to the requester.
+	ResolutionRefund DisputeResolution = "refund"
+	// ResolutionPartialRefund returns part of the fee to the requester; the
+	// rest goes, or stays with, the traveler.
+	ResolutionPartialRefund DisputeResolution = "partial_refund"
+	ResolutionWarning       DisputeResolution = "warning"
+	// ResolutionBan stops a party signing in.
+	ResolutionBan      DisputeResolution = "ban"
+	ResolutionNoAction DisputeResolution = "no_action"
+)
+
+func (r DisputeResolution) IsValid() bool {
+	switch r {
+	case ResolutionRefund, ResolutionPartialRefund, ResolutionWarning, ResolutionBan, ResolutionNoAction:
+		return true
+	}
+	return false
+}
+
+// Sanctions reports whether the resolution is taken against a user.
+func (r DisputeResolution) Sanctions() bool {
+	return r == ResolutionWarning || r == ResolutionBan
+}
+
+// Dispute is a case about a delivery, opened by its requester or traveler
+// against the other. DeadlineAt is when the current status times out: an
+// open or in-review case is escalated, and a case awaiting a response goes
+// back to review.
+type Dispute struct {
+	ID                  uuid.UUID          `json:"id" db:"id"`
+	DeliveryRequestID   uuid.UUID          `json:"deliveryRequestId" db:"delivery_request_id"`
+	OpenedBy            uuid.UUID          `json:"openedBy" db:"opened_by"`
+	RespondentID        uuid.UUID          `json:"respondentId" db:"respondent_id"`
+	Category            DisputeCategory    `json:"category" db:"category"`
+	Description         string             `json:"description" db:"description"`
+	Status              DisputeStatus      `json:"status" db:"status"`
+	AssignedTo          *uuid.UUID         `json:"assignedTo,omitempty" db:"assigned_to"`
+	Escalated           bool               `json:"escalated" db:"escalated"`
+	DeadlineAt          *time.Time         `json:"deadlineAt,omitempty" db:"deadline_at"`
+	Resolution          *DisputeResolution `json:"resolution,omitempty" db:"resolution"`
+	RefundAmount        *Pesewas           `json:"refundAmount,omitempty" db:"refund_amount"`
+	ResolutionNote      *string            `json:"resolutionNote,omitempty" db:"resolution_note"`
+	SanctionedUserID    *uuid.UUID         `json:"sanctionedUserId,omitempty" db:"sanctioned_user_id"`
+	LedgerTransactionID *uuid.UUID         `json:"ledgerTransactionId,omitempty" db:"ledger_transaction_id"`
+	ResolvedBy          *uuid.UUID         `json:"resolvedBy,omitempty" db:"resolved_by"`
+	ResolvedAt          *time.Time         `json:"resolvedAt,omitempty" db:"resolved_at"`
+	CreatedAt           time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time          `json:"updatedAt" db:"updated_at"`
+
+	// Populated fields
+	OpenerName     string `json:"openerName,omitempty"`
+	RespondentName string `json:"respondentName,omitempty"`
+}
+
+// IsParty reports whether the user opened the case or it was opened against
+// them.
+func (d *Dispute) IsParty(userID uuid.UUID) bool {
+	return d.OpenedBy == userID || d.RespondentID == userID
+}
+
+// DisputeNote is an entry in a case's history. Internal notes are shown to
+// admins only. AuthorID is nil for notes the system adds.
+type DisputeNote struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	DisputeID uuid.UUID  `json:"disputeId" db:"dispute_id"`
+	AuthorID  *uuid.UUID `json:"authorId,omitempty" db:"author_id"`
+	Internal  bool       `json:"internal" db:"internal"`
+	Body      string     `json:"body" db:"body"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+
+	// Populated fields
+	AuthorName string `json:"authorName,omitempty"`
+}
+
+type DisputeAttachment struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	DisputeID   uuid.UUID `json:"disputeId" db:"dispute_id"`
+	UploadedBy  uuid.UUID `json:"uploadedBy" db:"uploaded_by"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	ContentType string    `json:"contentType" db:"content_type"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+
+	// Populated fields
+	URL          *string    `json:"url,omitempty"`
+	URLExpiresAt *time.Time `json:"urlExpiresAt,omitempty"`
+}
+
+// DisputeDetail is a case with its history and attachments. Admins also see
+// the ledger transactions of its delivery request.
+type DisputeDetail struct {
+	*Dispute
+	Notes              []*DisputeNote       `json:"notes"`
+	Attachments        []*DisputeAttachment `json:"attachments"`
+	LedgerTransactions []*LedgerTransaction `json:"ledgerTransactions,omitempty"`
+}
+
+type CreateDisputeRequest struct {
+	DeliveryRequestID uuid.UUID       `json:"deliveryRequestId" validate:"required"`
+	Category          DisputeCategory `json:"category" validate:"required,enum"`
+	Description       string          `json:"description" validate:"required,min=10,max=5000"`
+}
+
+type CreateDisputeNoteRequest struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
+
+type AssignDisputeRequest struct {
+	// AdminID defaults to the admin making the request.
+	AdminID *uuid.UUID `json:"adminId"`
+}
+
+type UpdateDisputeStatusRequest struct {
+	Status DisputeStatus `json:"status" validate:"required,oneof=in_review awaiting_response closed"`
+	Note   string        `json:"note" validate:"max=5000"`
+}
+
+// ResolveDisputeRequest settles a case. RefundAmount, in pesewas, is
+// required for a partial refund. SanctionedUserID defaults to the
+// respondent for warnings and bans.
+type ResolveDisputeRequest struct {
+	Resolution       DisputeResolution `json:"resolution" validate:"required,enum"`
+	RefundAmount     *Pesewas          `json:"refundAmount" validate:"required_if=Resolution partial_refund,omitnil,gt=0"`
+	SanctionedUserID *uuid.UUID        `json:"sanctionedUserId"`
+	Note             string            `json:"note" validate:"required,max=5000"`
+}
+
+// DisputeFilter selects cases for the admin queue. Empty fields match all.
+type DisputeFilter struct {
+	Status     DisputeStatus
+	Category   DisputeCategory
+	AssignedTo *uuid.UUID
+	Escalated  *bool
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Earning is one delivery fee released to a traveler, or a dispute refund
+// that settled or clawed back one. Gross is what the traveler and platform
+// got of the fee the requester paid, Fee the platform's share and Net what
+// the traveler got; all are negative for a clawback.
+type Earning struct {
+	LedgerTransactionID uuid.UUID `json:"ledgerTransactionId" db:"ledger_transaction_id"`
+	DeliveryRequestID   uuid.UUID `json:"deliveryRequestId" db:"delivery_request_id"`
+	TripID              uuid.UUID `json:"tripId" db:"trip_id"`
+	ItemDescription     string    `json:"itemDescription" db:"item_description"`
+	PickupLocation      string    `json:"pickupLocation" db:"pickup_location"`
+	DropoffLocation     string    `json:"dropoffLocation" db:"dropoff_location"`
+	Gross               Pesewas   `json:"gross" db:"gross"`
+	Fee                 Pesewas   `json:"fee" db:"fee"`
+	Net                 Pesewas   `json:"net" db:"net"`
+	ReleasedAt          time.Time `json:"releasedAt" db:"released_at"`
+}
+
+// EarningsTotals adds up earnings.
+type EarningsTotals struct {
+	Deliveries int     `json:"deliveries"`
+	Gross      Pesewas `json:"gross"`
+	Fees       Pesewas `json:"fees"`
+	Net        Pesewas `json:"net"`
+}
+
+// Add adds e to the totals. Clawbacks adjust an earlier earning, so they are
+// not counted as deliveries.
+func (t *EarningsTotals) Add(e *Earning) {
+	if e.Gross > 0 {
+		t.Deliveries++
+	}
+	t.Gross += e.Gross
+	t.Fees += e.Fee
+	t.Net += e.Net
+}
+
+// EarningsPeriod totals the earnings released in [Start, End).
+type EarningsPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	EarningsTotals
+}
+
+// TripEarnings totals a trip's released fees, and the fees still held in
+// escrow for its matched deliveries.
+type TripEarnings struct {
+	Trip *Trip `json:"trip"`
+	EarningsTotals
+	Pending Pesewas `json:"pending"`
+}
+
+// EarningsBalances is where a traveler's money stands. Pending is held in
+// escrow until deliveries are made; Available can be paid out, less what is
+// already Requested; PaidOut has been sent.
+type EarningsBalances struct {
+	Pending   Pesewas `json:"pending"`
+	Available Pesewas `json:"available"`
+	Requested Pesewas `json:"requested"`
+	PaidOut   Pesewas `json:"paidOut"`
+}
+
+type EarningsGrouping string
+
+const (
+	GroupByDay   EarningsGrouping = "day"
+	GroupByWeek  EarningsGrouping = "week"
+	GroupByMonth EarningsGrouping = "month"
+)
+
+func (g EarningsGrouping) IsValid() bool {
+	switch g {
+	case GroupByDay, GroupByWeek, GroupByMonth:
+		return true
+	}
+	return false
+}
+
+// PeriodStart returns the start of the period containing t. Weeks start on
+// Monday.
+func (g EarningsGrouping) PeriodStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch g {
+	case GroupByWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case GroupByMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// Next returns the start of the period after the one starting at start.
+func (g EarningsGrouping) Next(start time.Time) time.Time {
+	switch g {
+	case GroupByWeek:
+		return start.AddDate(0, 0, 7)
+	case GroupByMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// earthRadiusMeters is the mean radius of the Earth, as used by the
+// haversine_meters SQL function.
+const earthRadiusMeters = 6_371_008.8
+
+// metersPerDegreeLatitude is the length of a degree of latitude, near enough
+// everywhere for bounding boxes.
+const metersPerDegreeLatitude = 111_320
+
+// GeoPoint is a position in degrees.
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// DistanceTo returns the great-circle distance to q in metres.
+func (p GeoPoint) DistanceTo(q GeoPoint) float64 {
+	lat1, lat2 := p.Latitude*math.Pi/180, q.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (q.Longitude - p.Longitude) * math.Pi / 180
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox returns bounds containing every point within meters of p. It
+// is wider than the circle, so callers still filter on the exact distance.
+func (p GeoPoint) BoundingBox(meters float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := meters / metersPerDegreeLatitude
+	dLng := 180.0
+	if cos := math.Cos(p.Latitude * math.Pi / 180); cos > 0.01 {
+		dLng = math.Min(dLat/cos, 180)
+	}
+	return p.Latitude - dLat, p.Latitude + dLat, p.Longitude - dLng, p.Longitude + dLng
+}
+
+// GeoSearch finds delivery requests or trips that start within RadiusMeters
+// of Origin. With a Destination, it is a route corridor search: they must
+// also end within RadiusMeters of Destination.
+type GeoSearch struct {
+	Origin       GeoPoint
+	Destination  *GeoPoint
+	RadiusMeters float64
+	// SortByDistance orders results nearest first, adding the distances at
+	// both ends for a corridor, instead of newest first.
+	SortByDistance bool
+	// ViewerID, if set, hides results from users who blocked or were
+	// blocked by the viewer.
+	ViewerID *uuid.UUID
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pesewas is an amount of money in pesewas, the hundredth part of a cedi.
+// Ledger amounts are always whole pesewas.
+type Pesewas int64
+
+// PesewasFromCedis converts an amount in cedis, as used by the request and
+// trip APIs, rounding to the nearest pesewa.
+func PesewasFromCedis(cedis float64) Pesewas {
+	return Pesewas(math.Round(cedis * 100))
+}
+
+func (p Pesewas) Cedis() float64 {
+	return float64(p) / 100
+}
+
+// String formats p in cedis, e.g. "GH₵12.50".
+func (p Pesewas) String() string {
+	sign := ""
+	if p < 0 {
+		sign, p = "-", -p
+	}
+	return fmt.Sprintf("%sGH₵%d.%02d", sign, p/100, p%100)
+}
+
+// PercentBPS returns bps basis points (hundredths of a percent) of p,
+// rounded down.
+func (p Pesewas) PercentBPS(bps int) Pesewas {
+	return p * Pesewas(bps) / 10000
+}
+
+type LedgerAccountType string
+
+const (
+	LedgerWallet   LedgerAccountType = "wallet"
+	LedgerEscrow   LedgerAccountType = "escrow"
+	LedgerPlatform LedgerAccountType = "platform"
+	// LedgerProvider stands for money collected by the payment provider.
+	// Its balance goes negative by the amount paid in.
+	LedgerProvider LedgerAccountType = "provider"
+)
+
+type LedgerTransactionKind string
+
+const (
+	// LedgerHold moves a delivery fee from the requester's wallet into
+	// escrow when a traveler is matched.
+	LedgerHold LedgerTransactionKind = "hold"
+	// LedgerRelease pays the held fee to the traveler, less the platform
+	// fee, once the delivery is made.
+	LedgerRelease LedgerTransactionKind = "release"
+	// LedgerRefund returns the held fee to the requester when the match or
+	// request is cancelled.
+	LedgerRefund LedgerTransactionKind = "refund"
+	// LedgerDeposit credits the requester's wallet with a mobile money
+	// payment.
+	LedgerDeposit LedgerTransactionKind = "deposit"
+	// LedgerPayout debits a wallet for money sent to its owner.
+	LedgerPayout LedgerTransactionKind = "payout"
+	// LedgerDisputeRefund returns some or all of a delivery fee to the
+	// requester when a dispute is resolved with a refund.
+	LedgerDisputeRefund LedgerTransactionKind = "dispute_refund"
+)
+
+type LedgerAccount struct {
+	ID        uuid.UUID         `json:"id" db:"id"`
+	Type      LedgerAccountType `json:"type" db:"type"`
+	UserID    *uuid.UUID        `json:"userId,omitempty" db:"user_id"`
+	Balance   Pesewas           `json:"balance" db:"balance"`
+	CreatedAt time.Time         `json:"createdAt" db:"created_at"`
+}
+
+type LedgerTransaction struct {
+	ID                uuid.UUID             `json:"id" db:"id"`
+	Kind              LedgerTransactionKind `json:"kind" db:"kind"`
+	DeliveryRequestID *uuid.UUID            `json:"deliveryRequestId,omitempty" db:"delivery_request_id"`
+	IdempotencyKey    string                `json:"-" db:"idempotency_key"`
+	CreatedAt         time.Time             `json:"createdAt" db:"created_at"`
+
+	Postings []LedgerPosting `json:"postings,omitempty"`
+}
+
+// LedgerPosting adds Amount to an account's balance. The postings of a
+// transaction sum to zero.
+type LedgerPosting struct {
+	AccountID uuid.UUID `json:"accountId" db:"account_id"`
+	Amount    Pesewas   `json:"amount" db:"amount"`
+}
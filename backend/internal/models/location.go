@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LocationCategory string
+
+const (
+	LocationHall     LocationCategory = "hall"
+	LocationHostel   LocationCategory = "hostel"
+	LocationFaculty  LocationCategory = "faculty"
+	LocationLandmark LocationCategory = "landmark"
+	LocationTown     LocationCategory = "town"
+)
+
+func (c LocationCategory) IsValid() bool {
+	switch c {
+	case LocationHall, LocationHostel, LocationFaculty, LocationLandmark, LocationTown:
+		return true
+	}
+	return false
+}
+
+// Location is a catalogued place deliveries and trips start or end at.
+// Aliases are the other names it goes by, such as "Conti" for Unity Hall.
+type Location struct {
+	ID        uuid.UUID        `json:"id" db:"id"`
+	Slug      string           `json:"slug" db:"slug"`
+	Name      string           `json:"name" db:"name"`
+	Category  LocationCategory `json:"category" db:"category"`
+	Latitude  float64          `json:"latitude" db:"latitude"`
+	Longitude float64          `json:"longitude" db:"longitude"`
+	Aliases   []string         `json:"aliases"`
+	CreatedAt time.Time        `json:"createdAt" db:"created_at"`
+}
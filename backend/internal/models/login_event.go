@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reasons a sign-in attempt failed.
+const (
+	LoginFailureUnknownEmail     = "unknown_email"
+	LoginFailureBadPassword      = "bad_password"
+	LoginFailureEmailNotVerified = "email_not_verified"
+	LoginFailureBanned           = "banned"
+)
+
+type LoginEvent struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	UserID        *uuid.UUID `json:"-" db:"user_id"`
+	Email         string     `json:"-" db:"email"`
+	IPAddress     string     `json:"ipAddress" db:"ip_address"`
+	UserAgent     string     `json:"userAgent" db:"user_agent"`
+	DeviceHash    string     `json:"-" db:"device_hash"`
+	Success       bool       `json:"success" db:"success"`
+	FailureReason *string    `json:"failureReason,omitempty" db:"failure_reason"`
+	NewDevice     bool       `json:"newDevice" db:"new_device"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+}
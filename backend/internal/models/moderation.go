@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserBlock records that BlockerID blocked BlockedID. Blocks work both ways:
+// neither user sees the other's trips and requests or can deal with them.
+type UserBlock struct {
+	BlockerID uuid.UUID `json:"blockerId" db:"blocker_id"`
+	BlockedID uuid.UUID `json:"blockedId" db:"blocked_id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+
+	// Populated fields
+	BlockedName string `json:"blockedName,omitempty"`
+}
+
+type BlockUserRequest struct {
+	UserID uuid.UUID `json:"userId" validate:"required"`
+}
+
+type ReportTarget string
+
+const (
+	ReportTrip            ReportTarget = "trip"
+	ReportDeliveryRequest ReportTarget = "delivery_request"
+	// ReportMessage is a message in a dispute.
+	ReportMessage ReportTarget = "message"
+	ReportUser    ReportTarget = "user"
+)
+
+func (t ReportTarget) IsValid() bool {
+	switch t {
+	case ReportTrip, ReportDeliveryRequest, ReportMessage, ReportUser:
+		return true
+	}
+	return false
+}
+
+type ReportReason string
+
+const (
+	ReportSpam          ReportReason = "spam"
+	ReportHarassment    ReportReason = "harassment"
+	ReportScam          ReportReason = "scam"
+	ReportInappropriate ReportReason = "inappropriate"
+	ReportSafety        ReportReason = "safety"
+	ReportOther         ReportReason = "other"
+)
+
+func (r ReportReason) IsValid() bool {
+	switch r {
+	case ReportSpam, ReportHarassment, ReportScam, ReportInappropriate, ReportSafety, ReportOther:
+		return true
+	}
+	return false
+}
+
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportActioned  ReportStatus = "actioned"
+	ReportDismissed ReportStatus = "dismissed"
+)
+
+func (s ReportStatus) IsValid() bool {
+	switch s {
+	case ReportOpen, ReportActioned, ReportDismissed:
+		return true
+	}
+	return false
+}
+
+// Report flags a trip, delivery request, dispute message or user for the
+// moderation queue. ReportedUserID is the user who posted the content, or
+// the reported user.
+type Report struct {
+	ID             uuid.UUID    `json:"id" db:"id"`
+	ReporterID     uuid.UUID    `json:"reporterId" db:"reporter_id"`
+	TargetType     ReportTarget `json:"targetType" db:"target_type"`
+	TargetID       uuid.UUID    `json:"targetId" db:"target_id"`
+	ReportedUserID uuid.UUID    `json:"reportedUserId" db:"reported_user_id"`
+	Reason         ReportReason `json:"reason" db:"reason"`
+	Details        *string      `json:"details,omitempty" db:"details"`
+	Status         ReportStatus `json:"status" db:"status"`
+	ReviewNote     *string      `json:"reviewNote,omitempty" db:"review_note"`
+	ReviewedBy     *uuid.UUID   `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	ReviewedAt     *time.Time   `json:"reviewedAt,omitempty" db:"reviewed_at"`
+	CreatedAt      time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time    `json:"updatedAt" db:"updated_at"`
+
+	// Populated fields
+	ReporterName     string `json:"reporterName,omitempty"`
+	ReportedUserName string `json:"reportedUserName,omitempty"`
+	// ReportCount is how many reports, of any status, name the reported
+	// user; set in the moderation queue.
+	ReportCount int `json:"reportCount,omitempty"`
+}
+
+type CreateReportRequest struct {
+	TargetType ReportTarget `json:"targetType" validate:"required,enum"`
+	TargetID   uuid.UUID    `json:"targetId" validate:"required"`
+	Reason     ReportReason `json:"reason" validate:"required,enum"`
+	Details    *string      `json:"details" validate:"omitempty,max=2000"`
+}
+
+type ReviewReportRequest struct {
+	Status ReportStatus `json:"status" validate:"required,oneof=actioned dismissed"`
+	Note   *string      `json:"note" validate:"omitempty,max=2000"`
+}
+
+// ReportFilter selects reports for the moderation queue. Empty fields match
+// all.
+type ReportFilter struct {
+	Status     ReportStatus
+	TargetType ReportTarget
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "pending"
+	PaymentSucceeded PaymentStatus = "success"
+	PaymentFailed    PaymentStatus = "failed"
+	// PaymentAbandoned marks a charge the payer never approved.
+	PaymentAbandoned PaymentStatus = "abandoned"
+)
+
+func (s PaymentStatus) IsFinal() bool {
+	return s != PaymentPending
+}
+
+// MobileMoneyNetwork is a mobile money operator students can pay with.
+type MobileMoneyNetwork string
+
+const (
+	NetworkMTN        MobileMoneyNetwork = "mtn"
+	NetworkTelecel    MobileMoneyNetwork = "telecel"
+	NetworkAirtelTigo MobileMoneyNetwork = "airteltigo"
+)
+
+func (n MobileMoneyNetwork) IsValid() bool {
+	switch n {
+	case NetworkMTN, NetworkTelecel, NetworkAirtelTigo:
+		return true
+	}
+	return false
+}
+
+// Payment is a mobile money charge for a delivery request's fee. Reference
+// is our identifier for the charge at the provider.
+type Payment struct {
+	ID                  uuid.UUID          `json:"id" db:"id"`
+	DeliveryRequestID   uuid.UUID          `json:"deliveryRequestId" db:"delivery_request_id"`
+	UserID              uuid.UUID          `json:"userId" db:"user_id"`
+	Provider            string             `json:"provider" db:"provider"`
+	Reference           string             `json:"reference" db:"reference"`
+	Amount              Pesewas            `json:"amount" db:"amount"`
+	Currency            string             `json:"currency" db:"currency"`
+	Network             MobileMoneyNetwork `json:"network" db:"network"`
+	PhoneNumber         string             `json:"phoneNumber" db:"phone_number"`
+	Status              PaymentStatus      `json:"status" db:"status"`
+	DisplayText         *string            `json:"displayText,omitempty" db:"display_text"`
+	FailureReason       *string            `json:"failureReason,omitempty" db:"failure_reason"`
+	LedgerTransactionID *uuid.UUID         `json:"-" db:"ledger_transaction_id"`
+	PaidAt              *time.Time         `json:"paidAt,omitempty" db:"paid_at"`
+	CreatedAt           time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time          `json:"updatedAt" db:"updated_at"`
+}
+
+type InitiatePaymentRequest struct {
+	DeliveryRequestID uuid.UUID          `json:"deliveryRequestId" validate:"required"`
+	Network           MobileMoneyNetwork `json:"network" validate:"required,enum"`
+	PhoneNumber       string             `json:"phoneNumber" validate:"required"`
+}
+
+type SubmitPaymentOTPRequest struct {
+	OTP string `json:"otp" validate:"required,min=4,max=10"`
+}
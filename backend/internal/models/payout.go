@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PayoutStatus string
+
+const (
+	PayoutPending  PayoutStatus = "pending"
+	PayoutApproved PayoutStatus = "approved"
+	PayoutRejected PayoutStatus = "rejected"
+)
+
+func (s PayoutStatus) IsValid() bool {
+	switch s {
+	case PayoutPending, PayoutApproved, PayoutRejected:
+		return true
+	}
+	return false
+}
+
+// MinPayout is the smallest payout a traveler can request.
+const MinPayout Pesewas = 100
+
+// Payout is a traveler's request to be sent money from their wallet. An
+// admin sends the money and approves it, or rejects it.
+type Payout struct {
+	ID                  uuid.UUID          `json:"id" db:"id"`
+	UserID              uuid.UUID          `json:"userId" db:"user_id"`
+	Amount              Pesewas            `json:"amount" db:"amount"`
+	Network             MobileMoneyNetwork `json:"network" db:"network"`
+	PhoneNumber         string             `json:"phoneNumber" db:"phone_number"`
+	Status              PayoutStatus       `json:"status" db:"status"`
+	TransferReference   *string            `json:"transferReference,omitempty" db:"transfer_reference"`
+	RejectionReason     *string            `json:"rejectionReason,omitempty" db:"rejection_reason"`
+	LedgerTransactionID *uuid.UUID         `json:"-" db:"ledger_transaction_id"`
+	ReviewedBy          *uuid.UUID         `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	ReviewedAt          *time.Time         `json:"reviewedAt,omitempty" db:"reviewed_at"`
+	CreatedAt           time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time          `json:"updatedAt" db:"updated_at"`
+
+	// Populated fields
+	RequesterName string `json:"requesterName,omitempty"`
+}
+
+type CreatePayoutRequest struct {
+	Amount      Pesewas            `json:"amount" validate:"required,min=100"`
+	Network     MobileMoneyNetwork `json:"network" validate:"required,enum"`
+	PhoneNumber string             `json:"phoneNumber" validate:"required"`
+}
+
+type ApprovePayoutRequest struct {
+	TransferReference string `json:"transferReference" validate:"required,max=100"`
+}
+
+type RejectPayoutRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PriceBasis string
+
+const (
+	// PriceBasisHistory estimates come from similar completed deliveries.
+	PriceBasisHistory PriceBasis = "history"
+	// PriceBasisDefault estimates come from base rates, for lack of history.
+	PriceBasisDefault PriceBasis = "default"
+)
+
+type PriceOutlier string
+
+const (
+	PriceOutlierLow  PriceOutlier = "low"
+	PriceOutlierHigh PriceOutlier = "high"
+)
+
+// PriceQuery describes a delivery to price. ItemSize and Priority may be
+// empty, as they are for trips.
+type PriceQuery struct {
+	PickupLocation  string
+	DropoffLocation string
+	ItemSize        ItemSize
+	Priority        Priority
+	// LeadTime is how long before pickup the delivery is asked for.
+	LeadTime time.Duration
+}
+
+// PriceSample is a completed delivery used for pricing.
+type PriceSample struct {
+	PickupLocation  string
+	DropoffLocation string
+	ItemSize        ItemSize
+	Priority        Priority
+	PaymentAmount   float64
+	LeadTime        time.Duration
+}
+
+// PriceEstimate is a suggested price range in cedis. Low and High bound the
+// middle half of similar deliveries' prices.
+type PriceEstimate struct {
+	Low        float64    `json:"low"`
+	Median     float64    `json:"median"`
+	High       float64    `json:"high"`
+	Basis      PriceBasis `json:"basis"`
+	SampleSize int        `json:"sampleSize"`
+
+	// Prices beyond these are outliers
+	MinReasonable float64 `json:"-"`
+	MaxReasonable float64 `json:"-"`
+}
+
+// Outlier reports whether amount is unusually low or high for the estimate,
+// or "" if it is not.
+func (e *PriceEstimate) Outlier(amount float64) PriceOutlier {
+	switch {
+	case amount < e.MinReasonable:
+		return PriceOutlierLow
+	case amount > e.MaxReasonable:
+		return PriceOutlierHigh
+	}
+	return ""
+}
+
+type PriceFlagSubject string
+
+const (
+	PriceFlagDeliveryRequest PriceFlagSubject = "delivery_request"
+	PriceFlagTrip            PriceFlagSubject = "trip"
+)
+
+// PriceFlag records a delivery request or trip created with an outlying
+// price, for admins to review.
+type PriceFlag struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	SubjectType PriceFlagSubject `json:"subjectType" db:"subject_type"`
+	SubjectID   uuid.UUID        `json:"subjectId" db:"subject_id"`
+	UserID      uuid.UUID        `json:"userId" db:"user_id"`
+	Amount      float64          `json:"amount" db:"amount"`
+	Outlier     PriceOutlier     `json:"outlier" db:"outlier"`
+	Low         float64          `json:"low" db:"suggested_low"`
+	Median      float64          `json:"median" db:"suggested_median"`
+	High        float64          `json:"high" db:"suggested_high"`
+	Basis       PriceBasis       `json:"basis" db:"basis"`
+	CreatedAt   time.Time        `json:"createdAt" db:"created_at"`
+}
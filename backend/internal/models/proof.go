@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HandoverCodeLength is the number of digits in a handover code.
+const HandoverCodeLength = 6
+
+// MaxHandoverCodeAttempts is how many wrong handover codes a traveler may
+// enter before the code is locked and the requester must confirm delivery.
+const MaxHandoverCodeAttempts = 5
+
+// HandoverCode is the one-time PIN the requester gives the traveler when the
+// item is handed over. It is issued when a delivery request is matched.
+type HandoverCode struct {
+	DeliveryRequestID uuid.UUID  `json:"deliveryRequestId" db:"delivery_request_id"`
+	Code              string     `json:"code" db:"code"`
+	FailedAttempts    int        `json:"-" db:"failed_attempts"`
+	UsedAt            *time.Time `json:"-" db:"used_at"`
+	CreatedAt         time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// Locked reports whether too many wrong codes have been entered.
+func (c *HandoverCode) Locked() bool {
+	return c.FailedAttempts >= MaxHandoverCodeAttempts
+}
+
+type DeliveryProofKind string
+
+const (
+	// ProofHandoverCode records the traveler entering the requester's code.
+	ProofHandoverCode DeliveryProofKind = "handover_code"
+	ProofPhoto        DeliveryProofKind = "photo"
+	// ProofRequesterConfirmation records the requester marking the delivery
+	// delivered themselves.
+	ProofRequesterConfirmation DeliveryProofKind = "requester_confirmation"
+)
+
+// DeliveryProof is evidence that a delivery was handed over. CapturedAt is
+// when the client says it was taken; CreatedAt is when it was received.
+type DeliveryProof struct {
+	ID                uuid.UUID         `json:"id" db:"id"`
+	DeliveryRequestID uuid.UUID         `json:"deliveryRequestId" db:"delivery_request_id"`
+	Kind              DeliveryProofKind `json:"kind" db:"kind"`
+	SubmittedBy       uuid.UUID         `json:"submittedBy" db:"submitted_by"`
+	StorageKey        *string           `json:"-" db:"storage_key"`
+	ContentType       *string           `json:"contentType,omitempty" db:"content_type"`
+	Latitude          *float64          `json:"latitude,omitempty" db:"latitude"`
+	Longitude         *float64          `json:"longitude,omitempty" db:"longitude"`
+	Accuracy          *float64          `json:"accuracy,omitempty" db:"accuracy"`
+	CapturedAt        *time.Time        `json:"capturedAt,omitempty" db:"captured_at"`
+	CreatedAt         time.Time         `json:"createdAt" db:"created_at"`
+
+	// Populated fields
+	SubmitterName string     `json:"submitterName,omitempty"`
+	URL           *string    `json:"url,omitempty"`
+	URLExpiresAt  *time.Time `json:"urlExpiresAt,omitempty"`
+}
+
+// CreatePhotoProofRequest holds the form fields sent with a proof photo.
+type CreatePhotoProofRequest struct {
+	Latitude   *float64   `json:"latitude" validate:"required_with=Longitude,omitnil,gte=-90,lte=90"`
+	Longitude  *float64   `json:"longitude" validate:"required_with=Latitude,omitnil,gte=-180,lte=180"`
+	Accuracy   *float64   `json:"accuracy" validate:"omitnil,gte=0"`
+	CapturedAt *time.Time `json:"capturedAt"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocationPing is a traveler's position while a delivery is in transit.
+type LocationPing struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Accuracy is the radius of uncertainty in metres, Speed is in metres
+	// per second and Heading in degrees clockwise from north, as reported by
+	// the device.
+	Accuracy   *float64  `json:"accuracy,omitempty"`
+	Speed      *float64  `json:"speed,omitempty"`
+	Heading    *float64  `json:"heading,omitempty"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+type CreateLocationPingRequest struct {
+	Latitude  *float64 `json:"latitude" validate:"required,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude" validate:"required,gte=-180,lte=180"`
+	Accuracy  *float64 `json:"accuracy" validate:"omitnil,gte=0"`
+	Speed     *float64 `json:"speed" validate:"omitnil,gte=0,lte=100"`
+	Heading   *float64 `json:"heading" validate:"omitnil,gte=0,lte=360"`
+}
+
+type TrackingEventType string
+
+const (
+	// TrackingLocation events carry the traveler's latest position.
+	TrackingLocation TrackingEventType = "location"
+	// TrackingStopped events end sharing, as the delivery is no longer in
+	// transit.
+	TrackingStopped TrackingEventType = "stopped"
+)
+
+// TrackingEvent is an update on a delivery's live location.
+type TrackingEvent struct {
+	Type              TrackingEventType `json:"type"`
+	DeliveryRequestID uuid.UUID         `json:"deliveryRequestId"`
+	Location          *LocationPing     `json:"location,omitempty"`
+	// DistanceRemaining is the straight-line distance to the dropoff in
+	// metres, and ETA the estimated arrival there. Both are missing when the
+	// dropoff has no coordinates.
+	DistanceRemaining *float64        `json:"distanceRemaining,omitempty"`
+	ETA               *time.Time      `json:"eta,omitempty"`
+	Status            *DeliveryStatus `json:"status,omitempty"`
+}
@@ -23,11 +23,33 @@ const (
 	TransportPublic     TransportMethod = "public_transport"
 )
 
+func (s TripStatus) IsValid() bool {
+	switch s {
+	case TripActive, TripCompleted, TripCancelled:
+		return true
+	}
+	return false
+}
+
+func (m TransportMethod) IsValid() bool {
+	switch m {
+	case TransportCar, TransportMotorcycle, TransportBicycle, TransportWalking, TransportPublic:
+		return true
+	}
+	return false
+}
+
 type Trip struct {
 	ID                uuid.UUID       `json:"id" db:"id"`
 	TravelerID        uuid.UUID       `json:"travelerId" db:"traveler_id"`
 	FromLocation      string          `json:"fromLocation" db:"from_location"`
 	ToLocation        string          `json:"toLocation" db:"to_location"`
+	FromLocationID    *uuid.UUID      `json:"fromLocationId" db:"from_location_id"`
+	ToLocationID      *uuid.UUID      `json:"toLocationId" db:"to_location_id"`
+	FromLatitude      *float64        `json:"fromLatitude" db:"from_latitude"`
+	FromLongitude     *float64        `json:"fromLongitude" db:"from_longitude"`
+	ToLatitude        *float64        `json:"toLatitude" db:"to_latitude"`
+	ToLongitude       *float64        `json:"toLongitude" db:"to_longitude"`
 	DepartureTime     time.Time       `json:"departureTime" db:"departure_time"`
 	TransportMethod   TransportMethod `json:"transportMethod" db:"transport_method"`
 	MaxDeliveries     int             `json:"maxDeliveries" db:"max_deliveries"`
@@ -45,18 +67,31 @@ type Trip struct {
 	TravelerName    string            `json:"travelerName,omitempty"`
 	JoinedUsers     []User            `json:"joinedUsers,omitempty"`
 	MatchedRequests []DeliveryRequest `json:"matchedRequests,omitempty"`
+	// Distances in metres from a proximity search's origin to the start and
+	// from its destination to the end
+	Distance            *float64 `json:"distance,omitempty"`
+	DestinationDistance *float64 `json:"destinationDistance,omitempty"`
 }
 
+// CreateTripRequest takes each location as a catalogue ID, free text, or
+// both. Free text naming a catalogued location is linked to it.
+// Coordinates, given in pairs, override those of the catalogue.
 type CreateTripRequest struct {
-	FromLocation     string          `json:"fromLocation" validate:"required"`
-	ToLocation       string          `json:"toLocation" validate:"required"`
-	DepartureDate    string          `json:"departureDate" validate:"required"`
-	DepartureTime    string          `json:"departureTime" validate:"required"`
-	AvailableSeats   int             `json:"availableSeats" validate:"required,gt=0"`
-	PricePerDelivery float64         `json:"pricePerDelivery" validate:"required,gt=0"`
-	VehicleType      TransportMethod `json:"vehicleType" validate:"required"`
-	Description      *string         `json:"description"`
-	ContactInfo      *string         `json:"contactInfo"`
+	FromLocation     string          `json:"fromLocation" validate:"required_without=FromLocationID,max=255"`
+	ToLocation       string          `json:"toLocation" validate:"required_without=ToLocationID,max=255"`
+	FromLocationID   *uuid.UUID      `json:"fromLocationId"`
+	ToLocationID     *uuid.UUID      `json:"toLocationId"`
+	FromLatitude     *float64        `json:"fromLatitude" validate:"required_with=FromLongitude,omitnil,gte=-90,lte=90"`
+	FromLongitude    *float64        `json:"fromLongitude" validate:"required_with=FromLatitude,omitnil,gte=-180,lte=180"`
+	ToLatitude       *float64        `json:"toLatitude" validate:"required_with=ToLongitude,omitnil,gte=-90,lte=90"`
+	ToLongitude      *float64        `json:"toLongitude" validate:"required_with=ToLatitude,omitnil,gte=-180,lte=180"`
+	DepartureDate    string          `json:"departureDate" validate:"required,datetime=2006-01-02"`
+	DepartureTime    string          `json:"departureTime" validate:"required,max=8"`
+	AvailableSeats   int             `json:"availableSeats" validate:"required,gt=0,lte=50"`
+	PricePerDelivery float64         `json:"pricePerDelivery" validate:"required,gt=0,lte=100000"`
+	VehicleType      TransportMethod `json:"vehicleType" validate:"required,enum"`
+	Description      *string         `json:"description" validate:"omitempty,max=2000"`
+	ContactInfo      *string         `json:"contactInfo" validate:"omitempty,max=500"`
 }
 
 type JoinTripRequest struct {
@@ -14,6 +14,13 @@ const (
 	VerificationRejected VerificationStatus = "rejected"
 )
 
+type UserRole string
+
+const (
+	RoleUser  UserRole = "user"
+	RoleAdmin UserRole = "admin"
+)
+
 type User struct {
 	ID                 uuid.UUID          `json:"id" db:"id"`
 	FirstName          string             `json:"firstName" db:"first_name"`
@@ -28,50 +35,67 @@ type User struct {
 	ProgrammeOfStudy   *string            `json:"programmeOfStudy" db:"programme_of_study"`
 	CurrentYear        *int               `json:"currentYear" db:"current_year"`
 	VerificationStatus VerificationStatus `json:"verificationStatus" db:"verification_status"`
+	Role               UserRole           `json:"role" db:"role"`
 	Rating             float64            `json:"rating" db:"rating"`
 	TotalDeliveries    int                `json:"totalDeliveries" db:"total_deliveries"`
 	ProfileImage       *string            `json:"profileImage" db:"profile_image"`
+	ProfileImageKey    *string            `json:"-" db:"profile_image_key"`
+	BannedAt           *time.Time         `json:"bannedAt,omitempty" db:"banned_at"`
 	CreatedAt          time.Time          `json:"createdAt" db:"created_at"`
 	UpdatedAt          time.Time          `json:"updatedAt" db:"updated_at"`
 }
 
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
 type VerificationDocument struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	UserID    uuid.UUID `json:"userId" db:"user_id"`
-	DocType   string    `json:"docType" db:"doc_type"`
-	URL       string    `json:"url" db:"url"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	ID         uuid.UUID `json:"id" db:"id"`
+	UserID     uuid.UUID `json:"userId" db:"user_id"`
+	DocType    string    `json:"docType" db:"doc_type"`
+	URL        *string   `json:"-" db:"url"`
+	StorageKey *string   `json:"-" db:"storage_key"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
 }
 
 type UploadVerificationDocRequest struct {
 	DocType string `json:"docType" validate:"required,oneof=student_id selfie other"`
 }
 
+// Password limits: bcrypt ignores input beyond 72 bytes.
 type CreateUserRequest struct {
-	FirstName        string  `json:"firstName" validate:"required"`
-	LastName         string  `json:"lastName" validate:"required"`
-	Email            string  `json:"email" validate:"required,email"`
-	Password         string  `json:"password" validate:"required,min=6"`
-	StudentID        string  `json:"studentId" validate:"required"`
-	PhoneNumber      string  `json:"phoneNumber" validate:"required"`
-	Gender           *string `json:"gender"`
-	IndexNumber      *string `json:"indexNumber"`
-	ProgrammeOfStudy *string `json:"programmeOfStudy"`
-	CurrentYear      *int    `json:"currentYear"`
+	FirstName        string  `json:"firstName" validate:"required,max=100"`
+	LastName         string  `json:"lastName" validate:"required,max=100"`
+	Email            string  `json:"email" validate:"required,email,max=255"`
+	Password         string  `json:"password" validate:"required,min=6,max=72"`
+	StudentID        string  `json:"studentId" validate:"required,max=50"`
+	PhoneNumber      string  `json:"phoneNumber" validate:"required,max=20"`
+	Gender           *string `json:"gender" validate:"omitempty,max=20"`
+	IndexNumber      *string `json:"indexNumber" validate:"omitempty,max=50"`
+	ProgrammeOfStudy *string `json:"programmeOfStudy" validate:"omitempty,max=200"`
+	CurrentYear      *int    `json:"currentYear" validate:"omitempty,min=1,max=8"`
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,max=72"`
 }
 
 type UpdateProfileRequest struct {
-	FirstName        *string `json:"firstName"`
-	LastName         *string `json:"lastName"`
-	Gender           *string `json:"gender"`
-	IndexNumber      *string `json:"indexNumber"`
-	ProgrammeOfStudy *string `json:"programmeOfStudy"`
-	CurrentYear      *int    `json:"currentYear"`
+	FirstName        *string `json:"firstName" validate:"omitempty,min=1,max=100"`
+	LastName         *string `json:"lastName" validate:"omitempty,min=1,max=100"`
+	Gender           *string `json:"gender" validate:"omitempty,max=20"`
+	IndexNumber      *string `json:"indexNumber" validate:"omitempty,max=50"`
+	ProgrammeOfStudy *string `json:"programmeOfStudy" validate:"omitempty,max=200"`
+	CurrentYear      *int    `json:"currentYear" validate:"omitempty,min=1,max=8"`
+}
+
+type ChangePhoneRequest struct {
+	PhoneNumber string `json:"phoneNumber" validate:"required,max=20"`
+}
+
+type VerifyPhoneRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
 }
 
 type UserResponse struct {
@@ -87,6 +111,7 @@ type UserResponse struct {
 	ProgrammeOfStudy   *string            `json:"programmeOfStudy"`
 	CurrentYear        *int               `json:"currentYear"`
 	VerificationStatus VerificationStatus `json:"verificationStatus"`
+	Role               UserRole           `json:"role"`
 	Rating             float64            `json:"rating"`
 	TotalDeliveries    int                `json:"totalDeliveries"`
 	ProfileImage       *string            `json:"profileImage"`
@@ -106,6 +131,7 @@ func (u *User) ToResponse() *UserResponse {
 		ProgrammeOfStudy:   u.ProgrammeOfStudy,
 		CurrentYear:        u.CurrentYear,
 		VerificationStatus: u.VerificationStatus,
+		Role:               u.Role,
 		Rating:             u.Rating,
 		TotalDeliveries:    u.TotalDeliveries,
 		ProfileImage:       u.ProfileImage,
@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"context"
+
+	"campus-connect/internal/logging"
+)
+
+// FallbackLimiter checks limits with primary and, whenever primary fails,
+// with fallback instead. Pairing a RedisLimiter with a MemoryLimiter keeps
+// per-instance limits in force during a Redis outage rather than letting
+// every request through.
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+}
+
+func NewFallbackLimiter(primary, fallback Limiter) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, fallback: fallback}
+}
+
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	result, err := l.primary.Allow(ctx, key, limit)
+	if err == nil {
+		return result, nil
+	}
+	logging.FromContext(ctx).Warn("rate limiter unavailable, using fallback", "error", err)
+	return l.fallback.Allow(ctx, key, limit)
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval bounds how often idle keys are dropped from memory.
+const sweepInterval = time.Minute
+
+// MemoryLimiter keeps windows in process memory. Limits are per instance, so
+// it suits tests and single-instance development rather than production.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	length time.Duration
+	// requests holds request times, oldest first.
+	requests []time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		now:     time.Now,
+		windows: make(map[string]*memoryWindow),
+	}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	window, ok := l.windows[key]
+	if !ok {
+		window = &memoryWindow{}
+		l.windows[key] = window
+	}
+	window.length = limit.Window
+	window.prune(now)
+
+	allowed := len(window.requests) < limit.Requests
+	if allowed {
+		window.requests = append(window.requests, now)
+	}
+
+	reset := limit.Window
+	if len(window.requests) > 0 {
+		reset = window.requests[0].Add(limit.Window).Sub(now)
+	}
+
+	return Result{
+		Allowed:   allowed,
+		Remaining: max(limit.Requests-len(window.requests), 0),
+		Reset:     reset,
+	}, nil
+}
+
+// sweep drops keys with no requests left inside their window.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, window := range l.windows {
+		window.prune(now)
+		if len(window.requests) == 0 {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// prune drops requests that have left the window.
+func (w *memoryWindow) prune(now time.Time) {
+	cutoff := now.Add(-w.length)
+	i := 0
+	for i < len(w.requests) && !w.requests[i].After(cutoff) {
+		i++
+	}
+	w.requests = w.requests[i:]
+}
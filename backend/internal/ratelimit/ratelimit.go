@@ -0,0 +1,65 @@
+// Package ratelimit implements sliding-window rate limiting. Each key may make
+// at most Limit.Requests requests in any Limit.Window long period.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests requests per Window. A zero Limit disables limiting.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Window > 0
+}
+
+// String formats l as "requests/window", e.g. "10/1m0s".
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// ParseLimit parses "requests/window", e.g. "10/1m" or "5/15m". "0" and
+// "off" disable limiting.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" || s == "off" {
+		return Limit{}, nil
+	}
+
+	requests, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected requests/window, e.g. 10/1m", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad window", s)
+	}
+	return Limit{Requests: n, Window: d}, nil
+}
+
+// Result describes the state of a key after a call to Allow.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of requests still allowed in the window.
+	Remaining int
+	// Reset is how long until the oldest request in the window expires and
+	// frees a slot. When the request was denied, clients should wait this
+	// long before retrying.
+	Reset time.Duration
+}
+
+// Limiter records a request for key and reports whether it is within limit.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "10/1m", want: Limit{Requests: 10, Window: time.Minute}},
+		{in: " 5/15m ", want: Limit{Requests: 5, Window: 15 * time.Minute}},
+		{in: "off"},
+		{in: "0"},
+		{in: "10", wantErr: true},
+		{in: "x/1m", wantErr: true},
+		{in: "-1/1m", wantErr: true},
+		{in: "10/0s", wantErr: true},
+		{in: "10/soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimit(%q) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryLimiterSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limit := Limit{Requests: 3, Window: time.Minute}
+
+	// Each step advances the clock to at and makes one request
+	steps := []struct {
+		at            time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantReset     time.Duration
+	}{
+		{at: 0, wantAllowed: true, wantRemaining: 2, wantReset: time.Minute},
+		{at: 10 * time.Second, wantAllowed: true, wantRemaining: 1, wantReset: 50 * time.Second},
+		{at: 20 * time.Second, wantAllowed: true, wantRemaining: 0, wantReset: 40 * time.Second},
+		{at: 30 * time.Second, wantAllowed: false, wantRemaining: 0, wantReset: 30 * time.Second},
+		// The first request leaves the window, freeing one slot only
+		{at: 61 * time.Second, wantAllowed: true, wantRemaining: 0, wantReset: 9 * time.Second},
+		{at: 62 * time.Second, wantAllowed: false, wantRemaining: 0, wantReset: 8 * time.Second},
+		// Every request has left the window
+		{at: 5 * time.Minute, wantAllowed: true, wantRemaining: 2, wantReset: time.Minute},
+	}
+
+	limiter := NewMemoryLimiter()
+	for _, step := range steps {
+		limiter.now = func() time.Time { return start.Add(step.at) }
+		got, err := limiter.Allow(ctx, "ip:203.0.113.7", limit)
+		if err != nil {
+			t.Fatalf("at %s: Allow() error = %v", step.at, err)
+		}
+		want := Result{Allowed: step.wantAllowed, Remaining: step.wantRemaining, Reset: step.wantReset}
+		if got != want {
+			t.Errorf("at %s: Allow() = %+v, want %+v", step.at, got, want)
+		}
+	}
+}
+
+func TestMemoryLimiterKeysAreSeparate(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter()
+	limit := Limit{Requests: 1, Window: time.Minute}
+
+	if got, _ := limiter.Allow(ctx, "ip:a", limit); !got.Allowed {
+		t.Fatal("first request for ip:a denied")
+	}
+	if got, _ := limiter.Allow(ctx, "ip:a", limit); got.Allowed {
+		t.Error("second request for ip:a allowed")
+	}
+	if got, _ := limiter.Allow(ctx, "ip:b", limit); !got.Allowed {
+		t.Error("first request for ip:b denied")
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limit := Limit{Requests: 1, Window: time.Second}
+
+	limiter.now = func() time.Time { return start }
+	limiter.Allow(ctx, "ip:a", limit)
+	limiter.now = func() time.Time { return start.Add(2 * sweepInterval) }
+	limiter.Allow(ctx, "ip:b", limit)
+
+	if _, ok := limiter.windows["ip:a"]; ok {
+		t.Error("idle key ip:a was not swept")
+	}
+	if _, ok := limiter.windows["ip:b"]; !ok {
+		t.Error("active key ip:b was swept")
+	}
+}
+
+type fakeLimiter struct {
+	result Result
+	err    error
+	calls  int
+}
+
+func (l *fakeLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.calls++
+	return l.result, l.err
+}
+
+func TestFallbackLimiter(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 1, Window: time.Minute}
+	fromPrimary := Result{Allowed: true, Remaining: 7}
+	fromFallback := Result{Allowed: false, Reset: time.Second}
+
+	tests := []struct {
+		name          string
+		primaryErr    error
+		fallbackErr   error
+		want          Result
+		wantErr       bool
+		wantFallbacks int
+	}{
+		{name: "primary up", want: fromPrimary},
+		{name: "primary down", primaryErr: errors.New("redis down"), want: fromFallback, wantFallbacks: 1},
+		{name: "both down", primaryErr: errors.New("redis down"), fallbackErr: errors.New("fallback down"),
+			wantErr: true, wantFallbacks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &fakeLimiter{result: fromPrimary, err: tt.primaryErr}
+			fallback := &fakeLimiter{result: fromFallback, err: tt.fallbackErr}
+			got, err := NewFallbackLimiter(primary, fallback).Allow(ctx, "ip:a", limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Allow() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Allow() = %+v, want %+v", got, tt.want)
+			}
+			if fallback.calls != tt.wantFallbacks {
+				t.Errorf("fallback called %d times, want %d", fallback.calls, tt.wantFallbacks)
+			}
+		})
+	}
+}
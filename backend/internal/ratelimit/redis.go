@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "ratelimit:"
+
+// slidingWindowScript keeps one sorted-set member per request, scored by its
+// time in milliseconds. It uses the Redis clock so every API instance sees
+// the same window. Returns {allowed, count, reset_ms}.
+var slidingWindowScript = redis.NewScript(`
+local now_parts = redis.call('TIME')
+local now = tonumber(now_parts[1]) * 1000 + math.floor(tonumber(now_parts[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// RedisLimiter shares limits across every API instance using Redis.
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := slidingWindowScript.Run(ctx, l.client,
+		[]string{redisKeyPrefix + key},
+		limit.Window.Milliseconds(), limit.Requests, uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("failed to check rate limit: unexpected script result %v", values)
+	}
+
+	return Result{
+		Allowed:   values[0] == 1,
+		Remaining: max(limit.Requests-int(values[1]), 0),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type BlockRepository interface {
+	// Block records that blockerID blocked blockedID. Blocking a user twice
+	// keeps the first block.
+	Block(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	// ListBlocked returns the users blockerID blocked, newest first.
+	ListBlocked(ctx context.Context, blockerID uuid.UUID) ([]*models.UserBlock, error)
+	// IsBlocked reports whether either user blocked the other.
+	IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error)
+}
+
+type blockRepository struct {
+	db *database.DB
+}
+
+func NewBlockRepository(db *database.DB) BlockRepository {
+	return &blockRepository{db: db}
+}
+
+// notBlocked is a filter that drops rows whose owner, in ownerColumn, blocked
+// or was blocked by the viewer given as param. A NULL viewer drops nothing.
+func notBlocked(ownerColumn, param string) string {
+	return fmt.Sprintf(`(%[2]s::UUID IS NULL OR NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = %[2]s AND b.blocked_id = %[1]s)
+				OR (b.blocker_id = %[1]s AND b.blocked_id = %[2]s)))`, ownerColumn, param)
+}
+
+func (r *blockRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	query := `
+		INSERT INTO user_blocks (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING`
+
+	if _, err := r.db.ExecNamed(ctx, "blocks.Block", query, blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to block user: %w", err)
+	}
+	return nil
+}
+
+func (r *blockRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	_, err := r.db.ExecNamed(ctx, "blocks.Unblock",
+		`DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock user: %w", err)
+	}
+	return nil
+}
+
+func (r *blockRepository) ListBlocked(ctx context.Context, blockerID uuid.UUID) ([]*models.UserBlock, error) {
+	query := `
+		SELECT b.blocker_id, b.blocked_id, b.created_at, u.first_name, u.last_name
+		FROM user_blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC`
+
+	rows, err := r.db.QueryNamed(ctx, "blocks.ListBlocked", query, blockerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked users: %w", err)
+	}
+	defer rows.Close()
+
+	blocks := []*models.UserBlock{}
+	for rows.Next() {
+		b := &models.UserBlock{}
+		var firstName, lastName string
+		if err := rows.Scan(&b.BlockerID, &b.BlockedID, &b.CreatedAt, &firstName, &lastName); err != nil {
+			return nil, fmt.Errorf("failed to scan user block: %w", err)
+		}
+		b.BlockedName = fmt.Sprintf("%s %s", firstName, lastName)
+		blocks = append(blocks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user blocks: %w", err)
+	}
+	return blocks, nil
+}
+
+func (r *blockRepository) IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)`
+
+	var blocked bool
+	if err := r.db.QueryRowNamed(ctx, "blocks.IsBlocked", query, userID, otherID).Scan(&blocked); err != nil {
+		return false, fmt.Errorf("failed to check user block: %w", err)
+	}
+	return blocked, nil
+}
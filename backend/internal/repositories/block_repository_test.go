@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestPendingRequestsHideBlockedUsers(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	blocks := NewBlockRepository(db)
+	deliveries := NewDeliveryRepository(db)
+
+	tests := []struct {
+		name        string
+		block       func(viewer, requester uuid.UUID) error
+		anonymous   bool
+		wantVisible bool
+		wantBlocked bool
+	}{
+		{name: "no block", wantVisible: true},
+		{name: "viewer blocked requester", block: func(viewer, requester uuid.UUID) error {
+			return blocks.Block(ctx, viewer, requester)
+		}, wantBlocked: true},
+		{name: "requester blocked viewer", block: func(viewer, requester uuid.UUID) error {
+			return blocks.Block(ctx, requester, viewer)
+		}, wantBlocked: true},
+		{name: "signed out", anonymous: true, block: func(viewer, requester uuid.UUID) error {
+			return blocks.Block(ctx, viewer, requester)
+		}, wantVisible: true, wantBlocked: true},
+		{name: "unblocked", block: func(viewer, requester uuid.UUID) error {
+			if err := blocks.Block(ctx, viewer, requester); err != nil {
+				return err
+			}
+			return blocks.Unblock(ctx, viewer, requester)
+		}, wantVisible: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viewer := createTestUser(t, db)
+			requester := createTestUser(t, db)
+			request := createTestDelivery(t, db, requester, models.DeliveryPending)
+			if tt.block != nil {
+				if err := tt.block(viewer, requester); err != nil {
+					t.Fatalf("block: %v", err)
+				}
+			}
+
+			viewerID := &viewer
+			if tt.anonymous {
+				viewerID = nil
+			}
+			requests, _, err := deliveries.GetPendingRequests(ctx, viewerID, 1000, 0)
+			if err != nil {
+				t.Fatalf("GetPendingRequests() error = %v", err)
+			}
+			visible := false
+			for _, r := range requests {
+				visible = visible || r.ID == request
+			}
+			if visible != tt.wantVisible {
+				t.Errorf("request visible = %t, want %t", visible, tt.wantVisible)
+			}
+
+			blocked, err := blocks.IsBlocked(ctx, viewer, requester)
+			if err != nil {
+				t.Fatalf("IsBlocked() error = %v", err)
+			}
+			if blocked != tt.wantBlocked {
+				t.Errorf("IsBlocked() = %t, want %t", blocked, tt.wantBlocked)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
+	"campus-connect/internal/apperrors"
 	"campus-connect/internal/database"
 	"campus-connect/internal/models"
 
@@ -11,12 +13,25 @@ import (
 )
 
 type DeliveryRepository interface {
-	Create(request *models.DeliveryRequest) error
-	GetByID(id uuid.UUID) (*models.DeliveryRequest, error)
-	GetPendingRequests(limit, offset int) ([]*models.DeliveryRequest, int, error)
-	GetByUserID(userID uuid.UUID) ([]*models.DeliveryRequest, error)
-	Update(request *models.DeliveryRequest) error
-	UpdateStatus(id uuid.UUID, status models.DeliveryStatus, tripID *uuid.UUID) error
+	Create(ctx context.Context, request *models.DeliveryRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DeliveryRequest, error)
+	// GetPendingRequests returns pending requests, newest first. With a
+	// viewer, requests of users who blocked or were blocked by the viewer are
+	// left out.
+	GetPendingRequests(ctx context.Context, viewerID *uuid.UUID, limit, offset int) ([]*models.DeliveryRequest, int, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.DeliveryRequest, error)
+	// SearchNearby returns pending requests picked up near the search's
+	// origin and, for a corridor, dropped off near its destination, with
+	// their distances.
+	SearchNearby(ctx context.Context, search models.GeoSearch, limit, offset int) ([]*models.DeliveryRequest, int, error)
+	Update(ctx context.Context, request *models.DeliveryRequest) error
+	// TransitionStatus changes the status from one value to another, keeping
+	// the matched trip. It fails with a conflict if the status is no longer
+	// from, so concurrent transitions cannot both apply.
+	TransitionStatus(ctx context.Context, id uuid.UUID, from, to models.DeliveryStatus) error
+	// TransitionMatch is TransitionStatus that also sets the matched trip, or
+	// clears it when tripID is nil, in the same statement.
+	TransitionMatch(ctx context.Context, id uuid.UUID, from, to models.DeliveryStatus, tripID *uuid.UUID) error
 }
 
 type deliveryRepository struct {
@@ -27,21 +42,24 @@ func NewDeliveryRepository(db *database.DB) DeliveryRepository {
 	return &deliveryRepository{db: db}
 }
 
-func (r *deliveryRepository) Create(request *models.DeliveryRequest) error {
+func (r *deliveryRepository) Create(ctx context.Context, request *models.DeliveryRequest) error {
 	query := `
 		INSERT INTO delivery_requests (
 			id, user_id, pickup_location, dropoff_location, item_description,
 			item_size, priority, payment_amount, pickup_date, pickup_time,
-			contact_info, special_instructions, status
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			contact_info, special_instructions, status, pickup_location_id, dropoff_location_id,
+			pickup_latitude, pickup_longitude, dropoff_latitude, dropoff_longitude
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		RETURNING created_at, updated_at`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowNamed(ctx, "deliveries.Create",
 		query,
 		request.ID, request.UserID, request.PickupLocation, request.DropoffLocation,
 		request.ItemDescription, request.ItemSize, request.Priority,
 		request.PaymentAmount, request.PickupDate, request.PickupTime,
 		request.ContactInfo, request.SpecialInstructions, request.Status,
+		request.PickupLocationID, request.DropoffLocationID,
+		request.PickupLatitude, request.PickupLongitude, request.DropoffLatitude, request.DropoffLongitude,
 	).Scan(&request.CreatedAt, &request.UpdatedAt)
 
 	if err != nil {
@@ -51,10 +69,12 @@ func (r *deliveryRepository) Create(request *models.DeliveryRequest) error {
 	return nil
 }
 
-func (r *deliveryRepository) GetByID(id uuid.UUID) (*models.DeliveryRequest, error) {
+func (r *deliveryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DeliveryRequest, error) {
 	request := &models.DeliveryRequest{}
 	query := `
-		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location, 
+		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location,
+			   dr.pickup_location_id, dr.dropoff_location_id,
+			   dr.pickup_latitude, dr.pickup_longitude, dr.dropoff_latitude, dr.dropoff_longitude,
 			   dr.item_description, dr.item_size, dr.priority, dr.payment_amount,
 			   dr.pickup_date, dr.pickup_time, dr.contact_info, dr.special_instructions,
 			   dr.status, dr.matched_trip_id, dr.created_at, dr.updated_at,
@@ -64,8 +84,10 @@ func (r *deliveryRepository) GetByID(id uuid.UUID) (*models.DeliveryRequest, err
 		WHERE dr.id = $1`
 
 	user := &models.User{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowNamed(ctx, "deliveries.GetByID", query, id).Scan(
 		&request.ID, &request.UserID, &request.PickupLocation, &request.DropoffLocation,
+		&request.PickupLocationID, &request.DropoffLocationID,
+		&request.PickupLatitude, &request.PickupLongitude, &request.DropoffLatitude, &request.DropoffLongitude,
 		&request.ItemDescription, &request.ItemSize, &request.Priority,
 		&request.PaymentAmount, &request.PickupDate, &request.PickupTime,
 		&request.ContactInfo, &request.SpecialInstructions, &request.Status,
@@ -75,7 +97,7 @@ func (r *deliveryRepository) GetByID(id uuid.UUID) (*models.DeliveryRequest, err
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("delivery request not found")
+			return nil, apperrors.NotFound(apperrors.CodeRequestNotFound, "Delivery request not found")
 		}
 		return nil, fmt.Errorf("failed to get delivery request: %w", err)
 	}
@@ -87,20 +109,22 @@ func (r *deliveryRepository) GetByID(id uuid.UUID) (*models.DeliveryRequest, err
 	return request, nil
 }
 
-func (r *deliveryRepository) GetPendingRequests(limit, offset int) ([]*models.DeliveryRequest, int, error) {
+func (r *deliveryRepository) GetPendingRequests(ctx context.Context, viewerID *uuid.UUID, limit, offset int) ([]*models.DeliveryRequest, int, error) {
 	query := `
-		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location, 
+		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location,
+			   dr.pickup_location_id, dr.dropoff_location_id,
+			   dr.pickup_latitude, dr.pickup_longitude, dr.dropoff_latitude, dr.dropoff_longitude,
 			   dr.item_description, dr.item_size, dr.priority, dr.payment_amount,
 			   dr.pickup_date, dr.pickup_time, dr.contact_info, dr.special_instructions,
 			   dr.status, dr.matched_trip_id, dr.created_at, dr.updated_at,
 			   u.first_name, u.last_name, u.email, u.student_id
 		FROM delivery_requests dr
 		JOIN users u ON dr.user_id = u.id
-		WHERE dr.status = 'pending'
+		WHERE dr.status = 'pending' AND ` + notBlocked("dr.user_id", "$1") + `
 		ORDER BY dr.created_at DESC
-		LIMIT $1 OFFSET $2`
+		LIMIT $2 OFFSET $3`
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.QueryNamed(ctx, "deliveries.GetPendingRequests", query, viewerID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get pending requests: %w", err)
 	}
@@ -113,6 +137,8 @@ func (r *deliveryRepository) GetPendingRequests(limit, offset int) ([]*models.De
 
 		err := rows.Scan(
 			&request.ID, &request.UserID, &request.PickupLocation, &request.DropoffLocation,
+			&request.PickupLocationID, &request.DropoffLocationID,
+			&request.PickupLatitude, &request.PickupLongitude, &request.DropoffLatitude, &request.DropoffLongitude,
 			&request.ItemDescription, &request.ItemSize, &request.Priority,
 			&request.PaymentAmount, &request.PickupDate, &request.PickupTime,
 			&request.ContactInfo, &request.SpecialInstructions, &request.Status,
@@ -132,8 +158,8 @@ func (r *deliveryRepository) GetPendingRequests(limit, offset int) ([]*models.De
 
 	// Get total count
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM delivery_requests WHERE status = 'pending'`
-	err = r.db.QueryRow(countQuery).Scan(&totalCount)
+	countQuery := `SELECT COUNT(*) FROM delivery_requests dr WHERE dr.status = 'pending' AND ` + notBlocked("dr.user_id", "$1")
+	err = r.db.QueryRowNamed(ctx, "deliveries.GetPendingRequests.count", countQuery, viewerID).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
 	}
@@ -141,9 +167,86 @@ func (r *deliveryRepository) GetPendingRequests(limit, offset int) ([]*models.De
 	return requests, totalCount, nil
 }
 
-func (r *deliveryRepository) GetByUserID(userID uuid.UUID) ([]*models.DeliveryRequest, error) {
+// deliveryGeoFilter limits a proximity search to pending requests within the
+// radius that the viewer may see; see geoSearchArgs for its parameters.
+var deliveryGeoFilter = `
+		CROSS JOIN LATERAL (SELECT
+			haversine_meters($1, $2, dr.pickup_latitude, dr.pickup_longitude) AS distance,
+			haversine_meters($3, $4, dr.dropoff_latitude, dr.dropoff_longitude) AS destination_distance
+		) d
+		WHERE dr.status = 'pending'
+			AND dr.pickup_latitude BETWEEN $6 AND $7
+			AND dr.pickup_longitude BETWEEN $8 AND $9
+			AND d.distance <= $5
+			AND ($3::DOUBLE PRECISION IS NULL OR d.destination_distance <= $5)
+			AND ` + notBlocked("dr.user_id", "$10")
+
+func (r *deliveryRepository) SearchNearby(ctx context.Context, search models.GeoSearch, limit, offset int) ([]*models.DeliveryRequest, int, error) {
 	query := `
-		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location, 
+		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location,
+			   dr.pickup_location_id, dr.dropoff_location_id,
+			   dr.pickup_latitude, dr.pickup_longitude, dr.dropoff_latitude, dr.dropoff_longitude,
+			   dr.item_description, dr.item_size, dr.priority, dr.payment_amount,
+			   dr.pickup_date, dr.pickup_time, dr.contact_info, dr.special_instructions,
+			   dr.status, dr.matched_trip_id, dr.created_at, dr.updated_at,
+			   d.distance, d.destination_distance,
+			   u.first_name, u.last_name, u.email, u.student_id
+		FROM delivery_requests dr
+		JOIN users u ON dr.user_id = u.id` + deliveryGeoFilter + `
+		ORDER BY CASE WHEN $11 THEN d.distance + COALESCE(d.destination_distance, 0) END,
+			dr.created_at DESC
+		LIMIT $12 OFFSET $13`
+
+	args := geoSearchArgs(search)
+	rows, err := r.db.QueryNamed(ctx, "deliveries.SearchNearby", query, append(args, search.SortByDistance, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search nearby requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []*models.DeliveryRequest{}
+	for rows.Next() {
+		request := &models.DeliveryRequest{}
+		user := &models.User{}
+
+		err := rows.Scan(
+			&request.ID, &request.UserID, &request.PickupLocation, &request.DropoffLocation,
+			&request.PickupLocationID, &request.DropoffLocationID,
+			&request.PickupLatitude, &request.PickupLongitude, &request.DropoffLatitude, &request.DropoffLongitude,
+			&request.ItemDescription, &request.ItemSize, &request.Priority,
+			&request.PaymentAmount, &request.PickupDate, &request.PickupTime,
+			&request.ContactInfo, &request.SpecialInstructions, &request.Status,
+			&request.MatchedTripID, &request.CreatedAt, &request.UpdatedAt,
+			&request.Distance, &request.DestinationDistance,
+			&user.FirstName, &user.LastName, &user.Email, &user.StudentID,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan delivery request: %w", err)
+		}
+
+		user.ID = request.UserID
+		request.User = user
+		request.RequesterName = fmt.Sprintf("%s %s", user.FirstName, user.LastName)
+		requests = append(requests, request)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating delivery requests: %w", err)
+	}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM delivery_requests dr` + deliveryGeoFilter
+	if err := r.db.QueryRowNamed(ctx, "deliveries.SearchNearby.count", countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	return requests, totalCount, nil
+}
+
+func (r *deliveryRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.DeliveryRequest, error) {
+	query := `
+		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location,
+			   dr.pickup_location_id, dr.dropoff_location_id,
+			   dr.pickup_latitude, dr.pickup_longitude, dr.dropoff_latitude, dr.dropoff_longitude,
 			   dr.item_description, dr.item_size, dr.priority, dr.payment_amount,
 			   dr.pickup_date, dr.pickup_time, dr.contact_info, dr.special_instructions,
 			   dr.status, dr.matched_trip_id, dr.created_at, dr.updated_at
@@ -151,7 +254,7 @@ func (r *deliveryRepository) GetByUserID(userID uuid.UUID) ([]*models.DeliveryRe
 		WHERE dr.user_id = $1
 		ORDER BY dr.created_at DESC`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryNamed(ctx, "deliveries.GetByUserID", query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user delivery requests: %w", err)
 	}
@@ -163,6 +266,8 @@ func (r *deliveryRepository) GetByUserID(userID uuid.UUID) ([]*models.DeliveryRe
 
 		err := rows.Scan(
 			&request.ID, &request.UserID, &request.PickupLocation, &request.DropoffLocation,
+			&request.PickupLocationID, &request.DropoffLocationID,
+			&request.PickupLatitude, &request.PickupLongitude, &request.DropoffLatitude, &request.DropoffLongitude,
 			&request.ItemDescription, &request.ItemSize, &request.Priority,
 			&request.PaymentAmount, &request.PickupDate, &request.PickupTime,
 			&request.ContactInfo, &request.SpecialInstructions, &request.Status,
@@ -179,22 +284,25 @@ func (r *deliveryRepository) GetByUserID(userID uuid.UUID) ([]*models.DeliveryRe
 	return requests, nil
 }
 
-func (r *deliveryRepository) Update(request *models.DeliveryRequest) error {
+func (r *deliveryRepository) Update(ctx context.Context, request *models.DeliveryRequest) error {
 	query := `
 		UPDATE delivery_requests 
 		SET pickup_location = $2, dropoff_location = $3, item_description = $4,
 			item_size = $5, priority = $6, payment_amount = $7, pickup_date = $8,
 			pickup_time = $9, contact_info = $10, special_instructions = $11,
-			status = $12, matched_trip_id = $13
+			status = $12, matched_trip_id = $13, pickup_location_id = $14,
+			dropoff_location_id = $15, pickup_latitude = $16, pickup_longitude = $17,
+			dropoff_latitude = $18, dropoff_longitude = $19
 		WHERE id = $1`
 
-	_, err := r.db.Exec(
+	_, err := r.db.ExecNamed(ctx, "deliveries.Update",
 		query,
 		request.ID, request.PickupLocation, request.DropoffLocation,
 		request.ItemDescription, request.ItemSize, request.Priority,
 		request.PaymentAmount, request.PickupDate, request.PickupTime,
 		request.ContactInfo, request.SpecialInstructions, request.Status,
-		request.MatchedTripID,
+		request.MatchedTripID, request.PickupLocationID, request.DropoffLocationID,
+		request.PickupLatitude, request.PickupLongitude, request.DropoffLatitude, request.DropoffLongitude,
 	)
 
 	if err != nil {
@@ -204,16 +312,37 @@ func (r *deliveryRepository) Update(request *models.DeliveryRequest) error {
 	return nil
 }
 
-func (r *deliveryRepository) UpdateStatus(id uuid.UUID, status models.DeliveryStatus, tripID *uuid.UUID) error {
+func (r *deliveryRepository) TransitionStatus(ctx context.Context, id uuid.UUID, from, to models.DeliveryStatus) error {
 	query := `
 		UPDATE delivery_requests 
-		SET status = $2, matched_trip_id = $3
-		WHERE id = $1`
+		SET status = $3
+		WHERE id = $1 AND status = $2`
+
+	result, err := r.db.ExecNamed(ctx, "deliveries.TransitionStatus", query, id, from, to)
+	return checkTransition(result, err)
+}
 
-	_, err := r.db.Exec(query, id, status, tripID)
+func (r *deliveryRepository) TransitionMatch(ctx context.Context, id uuid.UUID, from, to models.DeliveryStatus, tripID *uuid.UUID) error {
+	query := `
+		UPDATE delivery_requests
+		SET status = $3, matched_trip_id = $4
+		WHERE id = $1 AND status = $2`
+
+	result, err := r.db.ExecNamed(ctx, "deliveries.TransitionMatch", query, id, from, to, tripID)
+	return checkTransition(result, err)
+}
+
+// checkTransition turns a conditional status update that matched no row into
+// a conflict.
+func checkTransition(result sql.Result, err error) error {
 	if err != nil {
 		return fmt.Errorf("failed to update delivery request status: %w", err)
 	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to update delivery request status: %w", err)
+	} else if n == 0 {
+		return apperrors.Conflict(apperrors.CodeInvalidTransition, "Delivery request status has changed, please reload")
+	}
 
 	return nil
 }
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestTransitionMatch(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	deliveries := NewDeliveryRepository(db)
+
+	traveler := createTestUser(t, db)
+	tripID := uuid.New()
+	_, err := db.Exec(`
+		INSERT INTO trips (id, traveler_id, from_location, to_location, departure_time, transport_method,
+			max_deliveries, price_per_delivery)
+		VALUES ($1, $2, 'Unity Hall', 'Brunei', NOW(), 'walking', 1, 10)`,
+		tripID, traveler)
+	if err != nil {
+		t.Fatalf("create trip: %v", err)
+	}
+	request := createTestDelivery(t, db, createTestUser(t, db), models.DeliveryPending)
+
+	steps := []struct {
+		name       string
+		from, to   models.DeliveryStatus
+		tripID     *uuid.UUID
+		wantErr    apperrors.Code
+		wantStatus models.DeliveryStatus
+		wantTrip   *uuid.UUID
+	}{
+		{name: "match", from: models.DeliveryPending, to: models.DeliveryMatched, tripID: &tripID,
+			wantStatus: models.DeliveryMatched, wantTrip: &tripID},
+		{name: "match again", from: models.DeliveryPending, to: models.DeliveryMatched, tripID: &tripID,
+			wantErr: apperrors.CodeInvalidTransition, wantStatus: models.DeliveryMatched, wantTrip: &tripID},
+		{name: "unmatch", from: models.DeliveryMatched, to: models.DeliveryPending,
+			wantStatus: models.DeliveryPending},
+		{name: "unmatch again", from: models.DeliveryMatched, to: models.DeliveryPending,
+			wantErr: apperrors.CodeInvalidTransition, wantStatus: models.DeliveryPending},
+	}
+	for _, step := range steps {
+		err := deliveries.TransitionMatch(ctx, request, step.from, step.to, step.tripID)
+		if step.wantErr != "" {
+			if !apperrors.HasCode(err, step.wantErr) {
+				t.Errorf("%s: TransitionMatch() error = %v, want %s", step.name, err, step.wantErr)
+			}
+		} else if err != nil {
+			t.Fatalf("%s: TransitionMatch() error = %v", step.name, err)
+		}
+
+		got, err := deliveries.GetByID(ctx, request)
+		if err != nil {
+			t.Fatalf("%s: GetByID() error = %v", step.name, err)
+		}
+		if got.Status != step.wantStatus {
+			t.Errorf("%s: status = %s, want %s", step.name, got.Status, step.wantStatus)
+		}
+		if (got.MatchedTripID == nil) != (step.wantTrip == nil) ||
+			(got.MatchedTripID != nil && *got.MatchedTripID != *step.wantTrip) {
+			t.Errorf("%s: matched trip = %v, want %v", step.name, got.MatchedTripID, step.wantTrip)
+		}
+	}
+}
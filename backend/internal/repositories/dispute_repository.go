@@ -0,0 +1,362 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type DisputeRepository interface {
+	// Create records an open case. It fails with a conflict if the user
+	// already has an unfinished case about the delivery request.
+	Create(ctx context.Context, dispute *models.Dispute) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	// Lock returns the case and locks it until the transaction ends, so
+	// admins and the deadline job do not overwrite each other's changes.
+	Lock(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	// ListForUser returns the cases the user opened or was named in, newest
+	// first.
+	ListForUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Dispute, int, error)
+	// List returns the admin queue: escalated cases first, then by deadline,
+	// then oldest first.
+	List(ctx context.Context, filter models.DisputeFilter, limit, offset int) ([]*models.Dispute, int, error)
+	// ListOverdue returns the IDs of unfinished cases whose deadline has
+	// passed, earliest first.
+	ListOverdue(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	// Update saves the case's status, assignment, deadline and resolution.
+	Update(ctx context.Context, dispute *models.Dispute) error
+	AddNote(ctx context.Context, note *models.DisputeNote) error
+	GetNote(ctx context.Context, id uuid.UUID) (*models.DisputeNote, error)
+	// ListNotes returns the case's history, oldest first, without internal
+	// notes unless includeInternal is set.
+	ListNotes(ctx context.Context, disputeID uuid.UUID, includeInternal bool) ([]*models.DisputeNote, error)
+	AddAttachment(ctx context.Context, attachment *models.DisputeAttachment) error
+	ListAttachments(ctx context.Context, disputeID uuid.UUID) ([]*models.DisputeAttachment, error)
+}
+
+type disputeRepository struct {
+	db *database.DB
+}
+
+func NewDisputeRepository(db *database.DB) DisputeRepository {
+	return &disputeRepository{db: db}
+}
+
+const disputeColumns = `d.id, d.delivery_request_id, d.opened_by, d.respondent_id, d.category, d.description,
+	d.status, d.assigned_to, d.escalated, d.deadline_at, d.resolution, d.refund_amount, d.resolution_note,
+	d.sanctioned_user_id, d.ledger_transaction_id, d.resolved_by, d.resolved_at, d.created_at, d.updated_at,
+	o.first_name, o.last_name, rs.first_name, rs.last_name`
+
+const disputeJoins = `
+	JOIN users o ON o.id = d.opened_by
+	JOIN users rs ON rs.id = d.respondent_id`
+
+func scanDispute(row rowScanner) (*models.Dispute, error) {
+	d := &models.Dispute{}
+	var openerFirst, openerLast, respondentFirst, respondentLast string
+	err := row.Scan(&d.ID, &d.DeliveryRequestID, &d.OpenedBy, &d.RespondentID, &d.Category, &d.Description,
+		&d.Status, &d.AssignedTo, &d.Escalated, &d.DeadlineAt, &d.Resolution, &d.RefundAmount, &d.ResolutionNote,
+		&d.SanctionedUserID, &d.LedgerTransactionID, &d.ResolvedBy, &d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt,
+		&openerFirst, &openerLast, &respondentFirst, &respondentLast)
+	if err != nil {
+		return nil, err
+	}
+	d.OpenerName = fmt.Sprintf("%s %s", openerFirst, openerLast)
+	d.RespondentName = fmt.Sprintf("%s %s", respondentFirst, respondentLast)
+	return d, nil
+}
+
+func scanDisputes(rows *database.Rows) ([]*models.Dispute, error) {
+	defer rows.Close()
+
+	disputes := []*models.Dispute{}
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dispute: %w", err)
+		}
+		disputes = append(disputes, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating disputes: %w", err)
+	}
+	return disputes, nil
+}
+
+var errDisputeNotFound = apperrors.NotFound(apperrors.CodeDisputeNotFound, "Dispute not found")
+
+func (r *disputeRepository) Create(ctx context.Context, dispute *models.Dispute) error {
+	query := `
+		INSERT INTO disputes (id, delivery_request_id, opened_by, respondent_id, category, description,
+			status, deadline_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowNamed(ctx, "disputes.Create", query,
+		dispute.ID, dispute.DeliveryRequestID, dispute.OpenedBy, dispute.RespondentID, dispute.Category,
+		dispute.Description, dispute.Status, dispute.DeadlineAt,
+	).Scan(&dispute.CreatedAt, &dispute.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_disputes_active" {
+			return apperrors.Conflict(apperrors.CodeDisputeExists, "You already have an open dispute about this delivery")
+		}
+		return fmt.Errorf("failed to create dispute: %w", err)
+	}
+	return nil
+}
+
+func (r *disputeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes d` + disputeJoins + ` WHERE d.id = $1`
+
+	dispute, err := scanDispute(r.db.QueryRowNamed(ctx, "disputes.GetByID", query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errDisputeNotFound
+		}
+		return nil, fmt.Errorf("failed to get dispute: %w", err)
+	}
+	return dispute, nil
+}
+
+func (r *disputeRepository) Lock(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes d` + disputeJoins + ` WHERE d.id = $1 FOR UPDATE OF d`
+
+	dispute, err := scanDispute(r.db.QueryRowNamed(ctx, "disputes.Lock", query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errDisputeNotFound
+		}
+		return nil, fmt.Errorf("failed to lock dispute: %w", err)
+	}
+	return dispute, nil
+}
+
+func (r *disputeRepository) ListForUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Dispute, int, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes d` + disputeJoins + `
+		WHERE d.opened_by = $1 OR d.respondent_id = $1
+		ORDER BY d.created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryNamed(ctx, "disputes.ListForUser", query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list disputes: %w", err)
+	}
+	disputes, err := scanDisputes(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM disputes WHERE opened_by = $1 OR respondent_id = $1`
+	if err := r.db.QueryRowNamed(ctx, "disputes.ListForUser.count", countQuery, userID).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	return disputes, totalCount, nil
+}
+
+// disputeFilter matches the admin queue filters, given as $1 to $4.
+const disputeFilter = `
+	($1 = '' OR d.status::TEXT = $1)
+	AND ($2 = '' OR d.category::TEXT = $2)
+	AND ($3::UUID IS NULL OR d.assigned_to = $3)
+	AND ($4::BOOLEAN IS NULL OR d.escalated = $4)`
+
+func (r *disputeRepository) List(ctx context.Context, filter models.DisputeFilter, limit, offset int) ([]*models.Dispute, int, error) {
+	query := `
+		SELECT ` + disputeColumns + `
+		FROM disputes d` + disputeJoins + `
+		WHERE ` + disputeFilter + `
+		ORDER BY d.escalated DESC, d.deadline_at NULLS LAST, d.created_at
+		LIMIT $5 OFFSET $6`
+
+	args := []interface{}{filter.Status, filter.Category, filter.AssignedTo, filter.Escalated}
+	rows, err := r.db.QueryNamed(ctx, "disputes.List", query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list disputes: %w", err)
+	}
+	disputes, err := scanDisputes(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM disputes d WHERE ` + disputeFilter
+	if err := r.db.QueryRowNamed(ctx, "disputes.List.count", countQuery, args...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	return disputes, totalCount, nil
+}
+
+func (r *disputeRepository) ListOverdue(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT id
+		FROM disputes
+		WHERE deadline_at <= $1 AND status NOT IN ('resolved', 'closed')
+		ORDER BY deadline_at
+		LIMIT $2`
+
+	rows, err := r.db.QueryNamed(ctx, "disputes.ListOverdue", query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue disputes: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan dispute ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating overdue disputes: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *disputeRepository) Update(ctx context.Context, dispute *models.Dispute) error {
+	query := `
+		UPDATE disputes
+		SET status = $2, assigned_to = $3, escalated = $4, deadline_at = $5, resolution = $6,
+			refund_amount = $7, resolution_note = $8, sanctioned_user_id = $9, ledger_transaction_id = $10,
+			resolved_by = $11, resolved_at = $12
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRowNamed(ctx, "disputes.Update", query,
+		dispute.ID, dispute.Status, dispute.AssignedTo, dispute.Escalated, dispute.DeadlineAt, dispute.Resolution,
+		dispute.RefundAmount, dispute.ResolutionNote, dispute.SanctionedUserID, dispute.LedgerTransactionID,
+		dispute.ResolvedBy, dispute.ResolvedAt,
+	).Scan(&dispute.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errDisputeNotFound
+		}
+		return fmt.Errorf("failed to update dispute: %w", err)
+	}
+	return nil
+}
+
+func (r *disputeRepository) AddNote(ctx context.Context, note *models.DisputeNote) error {
+	if note.ID == uuid.Nil {
+		note.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO dispute_notes (id, dispute_id, author_id, internal, body)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	err := r.db.QueryRowNamed(ctx, "disputes.AddNote", query,
+		note.ID, note.DisputeID, note.AuthorID, note.Internal, note.Body,
+	).Scan(&note.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add dispute note: %w", err)
+	}
+	return nil
+}
+
+func (r *disputeRepository) GetNote(ctx context.Context, id uuid.UUID) (*models.DisputeNote, error) {
+	query := `
+		SELECT n.id, n.dispute_id, n.author_id, n.internal, n.body, n.created_at,
+			COALESCE(u.first_name || ' ' || u.last_name, '')
+		FROM dispute_notes n
+		LEFT JOIN users u ON u.id = n.author_id
+		WHERE n.id = $1`
+
+	n := &models.DisputeNote{}
+	err := r.db.QueryRowNamed(ctx, "disputes.GetNote", query, id).
+		Scan(&n.ID, &n.DisputeID, &n.AuthorID, &n.Internal, &n.Body, &n.CreatedAt, &n.AuthorName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodeNotFound, "Message not found")
+		}
+		return nil, fmt.Errorf("failed to get dispute note: %w", err)
+	}
+	return n, nil
+}
+
+func (r *disputeRepository) ListNotes(ctx context.Context, disputeID uuid.UUID, includeInternal bool) ([]*models.DisputeNote, error) {
+	query := `
+		SELECT n.id, n.dispute_id, n.author_id, n.internal, n.body, n.created_at,
+			COALESCE(u.first_name || ' ' || u.last_name, '')
+		FROM dispute_notes n
+		LEFT JOIN users u ON u.id = n.author_id
+		WHERE n.dispute_id = $1 AND ($2 OR NOT n.internal)
+		ORDER BY n.created_at, n.id`
+
+	rows, err := r.db.QueryNamed(ctx, "disputes.ListNotes", query, disputeID, includeInternal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dispute notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []*models.DisputeNote{}
+	for rows.Next() {
+		n := &models.DisputeNote{}
+		if err := rows.Scan(&n.ID, &n.DisputeID, &n.AuthorID, &n.Internal, &n.Body, &n.CreatedAt, &n.AuthorName); err != nil {
+			return nil, fmt.Errorf("failed to scan dispute note: %w", err)
+		}
+		notes = append(notes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dispute notes: %w", err)
+	}
+	return notes, nil
+}
+
+func (r *disputeRepository) AddAttachment(ctx context.Context, attachment *models.DisputeAttachment) error {
+	query := `
+		INSERT INTO dispute_attachments (id, dispute_id, uploaded_by, storage_key, content_type)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	err := r.db.QueryRowNamed(ctx, "disputes.AddAttachment", query,
+		attachment.ID, attachment.DisputeID, attachment.UploadedBy, attachment.StorageKey, attachment.ContentType,
+	).Scan(&attachment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add dispute attachment: %w", err)
+	}
+	return nil
+}
+
+func (r *disputeRepository) ListAttachments(ctx context.Context, disputeID uuid.UUID) ([]*models.DisputeAttachment, error) {
+	query := `
+		SELECT id, dispute_id, uploaded_by, storage_key, content_type, created_at
+		FROM dispute_attachments
+		WHERE dispute_id = $1
+		ORDER BY created_at, id`
+
+	rows, err := r.db.QueryNamed(ctx, "disputes.ListAttachments", query, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dispute attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []*models.DisputeAttachment{}
+	for rows.Next() {
+		a := &models.DisputeAttachment{}
+		if err := rows.Scan(&a.ID, &a.DisputeID, &a.UploadedBy, &a.StorageKey, &a.ContentType, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dispute attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dispute attachments: %w", err)
+	}
+	return attachments, nil
+}
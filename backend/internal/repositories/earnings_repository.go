@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// EarningsRepository reports what travelers earned from the ledger. Fees
+// count as earned once released from escrow to the traveler's wallet, and
+// dispute refunds adjust what was earned.
+type EarningsRepository interface {
+	// ListReleases returns the fees released to the traveler in [from, to),
+	// and the dispute refunds that settled or clawed back their fees, oldest
+	// first. Clawbacks have negative amounts.
+	ListReleases(ctx context.Context, travelerID uuid.UUID, from, to time.Time) ([]*models.Earning, error)
+	// ByTrip totals the traveler's released and held fees per trip, net of
+	// dispute refunds. Trips with no ledger activity are left out.
+	ByTrip(ctx context.Context, travelerID uuid.UUID) (map[uuid.UUID]*models.TripEarnings, error)
+	Balances(ctx context.Context, travelerID uuid.UUID) (*models.EarningsBalances, error)
+}
+
+type earningsRepository struct {
+	db *database.DB
+}
+
+func NewEarningsRepository(db *database.DB) EarningsRepository {
+	return &earningsRepository{db: db}
+}
+
+func (r *earningsRepository) ListReleases(ctx context.Context, travelerID uuid.UUID, from, to time.Time) ([]*models.Earning, error) {
+	// Gross is what the traveler and platform got between them, so it also
+	// covers dispute refunds, which split a held fee or claw back a released
+	// one. Refunds that moved nothing of theirs are left out.
+	query := `
+		SELECT t.id, dr.id, dr.matched_trip_id, dr.item_description, dr.pickup_location, dr.dropoff_location,
+			   COALESCE(SUM(p.amount) FILTER (WHERE a.type = 'platform' OR a.user_id = $1), 0),
+			   COALESCE(SUM(p.amount) FILTER (WHERE a.type = 'platform'), 0),
+			   COALESCE(SUM(p.amount) FILTER (WHERE a.user_id = $1), 0),
+			   t.created_at
+		FROM ledger_transactions t
+		JOIN delivery_requests dr ON dr.id = t.delivery_request_id
+		JOIN trips tr ON tr.id = dr.matched_trip_id
+		JOIN ledger_postings p ON p.transaction_id = t.id
+		JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE t.kind IN ('release', 'dispute_refund') AND tr.traveler_id = $1
+			AND t.created_at >= $2 AND t.created_at < $3
+		GROUP BY t.id, dr.id
+		HAVING bool_or(a.type = 'platform' OR a.user_id = $1)
+		ORDER BY t.created_at`
+
+	rows, err := r.db.QueryNamed(ctx, "earnings.ListReleases", query, travelerID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list earnings: %w", err)
+	}
+	defer rows.Close()
+
+	earnings := []*models.Earning{}
+	for rows.Next() {
+		e := &models.Earning{}
+		err := rows.Scan(&e.LedgerTransactionID, &e.DeliveryRequestID, &e.TripID, &e.ItemDescription,
+			&e.PickupLocation, &e.DropoffLocation, &e.Gross, &e.Fee, &e.Net, &e.ReleasedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan earning: %w", err)
+		}
+		earnings = append(earnings, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating earnings: %w", err)
+	}
+	return earnings, nil
+}
+
+func (r *earningsRepository) ByTrip(ctx context.Context, travelerID uuid.UUID) (map[uuid.UUID]*models.TripEarnings, error) {
+	// Each transaction's share for the traveler and platform, as in
+	// ListReleases, and its escrow postings, which across every kind net to
+	// what is still held
+	query := `
+		WITH earned AS (
+			SELECT tr.id AS trip_id, t.kind,
+				   COALESCE(SUM(p.amount) FILTER (WHERE a.type = 'platform'), 0) AS fee,
+				   COALESCE(SUM(p.amount) FILTER (WHERE a.user_id = $1), 0) AS net,
+				   COALESCE(SUM(p.amount) FILTER (WHERE a.type = 'escrow' AND dr.status IN ('matched', 'in_transit')), 0) AS held
+			FROM trips tr
+			JOIN delivery_requests dr ON dr.matched_trip_id = tr.id
+			JOIN ledger_transactions t ON t.delivery_request_id = dr.id
+			JOIN ledger_postings p ON p.transaction_id = t.id
+			JOIN ledger_accounts a ON a.id = p.account_id
+			WHERE tr.traveler_id = $1
+			GROUP BY tr.id, t.id
+		)
+		SELECT trip_id,
+			   COUNT(*) FILTER (WHERE kind IN ('release', 'dispute_refund') AND fee + net > 0),
+			   COALESCE(SUM(fee + net) FILTER (WHERE kind IN ('release', 'dispute_refund')), 0),
+			   COALESCE(SUM(fee) FILTER (WHERE kind IN ('release', 'dispute_refund')), 0),
+			   COALESCE(SUM(net) FILTER (WHERE kind IN ('release', 'dispute_refund')), 0),
+			   SUM(held)
+		FROM earned
+		GROUP BY trip_id`
+
+	rows, err := r.db.QueryNamed(ctx, "earnings.ByTrip", query, travelerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total trip earnings: %w", err)
+	}
+	defer rows.Close()
+
+	byTrip := map[uuid.UUID]*models.TripEarnings{}
+	for rows.Next() {
+		var tripID uuid.UUID
+		e := &models.TripEarnings{}
+		if err := rows.Scan(&tripID, &e.Deliveries, &e.Gross, &e.Fees, &e.Net, &e.Pending); err != nil {
+			return nil, fmt.Errorf("failed to scan trip earnings: %w", err)
+		}
+		byTrip[tripID] = e
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trip earnings: %w", err)
+	}
+	return byTrip, nil
+}
+
+func (r *earningsRepository) Balances(ctx context.Context, travelerID uuid.UUID) (*models.EarningsBalances, error) {
+	query := `
+		SELECT
+			(SELECT COALESCE(SUM(p.amount), 0)
+			 FROM ledger_postings p
+			 JOIN ledger_accounts a ON a.id = p.account_id AND a.type = 'escrow'
+			 JOIN ledger_transactions t ON t.id = p.transaction_id
+			 JOIN delivery_requests dr ON dr.id = t.delivery_request_id
+			 JOIN trips tr ON tr.id = dr.matched_trip_id
+			 WHERE tr.traveler_id = $1 AND dr.status IN ('matched', 'in_transit')),
+			COALESCE((SELECT balance FROM ledger_accounts WHERE type = 'wallet' AND user_id = $1), 0),
+			(SELECT COALESCE(SUM(amount), 0) FROM payouts WHERE user_id = $1 AND status = 'pending'),
+			(SELECT COALESCE(SUM(amount), 0) FROM payouts WHERE user_id = $1 AND status = 'approved')`
+
+	b := &models.EarningsBalances{}
+	var wallet models.Pesewas
+	err := r.db.QueryRowNamed(ctx, "earnings.Balances", query, travelerID).Scan(&b.Pending, &wallet, &b.Requested, &b.PaidOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	// The wallet also pays for the user's own delivery requests, so it can
+	// be negative
+	b.Available = max(wallet-b.Requested, 0)
+	return b, nil
+}
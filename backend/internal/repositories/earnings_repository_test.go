@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// matchTrip gives the fixture's delivery request a trip of the traveler's
+// and marks it delivered.
+func (f *ledgerFixture) matchTrip(t *testing.T) uuid.UUID {
+	t.Helper()
+	tripID := uuid.New()
+	_, err := f.db.Exec(`
+		INSERT INTO trips (id, traveler_id, from_location, to_location, departure_time, transport_method,
+			max_deliveries, price_per_delivery)
+		VALUES ($1, $2, 'Unity Hall', 'Brunei', NOW(), 'walking', 1, 10)`,
+		tripID, f.traveler)
+	if err != nil {
+		t.Fatalf("create trip: %v", err)
+	}
+	_, err = f.db.Exec(`UPDATE delivery_requests SET matched_trip_id = $2, status = 'delivered' WHERE id = $1`,
+		f.delivery, tripID)
+	if err != nil {
+		t.Fatalf("match trip: %v", err)
+	}
+	return tripID
+}
+
+func TestEarningsIncludeDisputeRefunds(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	type earned struct{ gross, fee, net models.Pesewas }
+	tests := []struct {
+		name           string
+		release        bool
+		refund         models.Pesewas
+		wantEarnings   []earned
+		wantDeliveries int
+		wantTotal      earned
+	}{
+		{name: "released", release: true,
+			wantEarnings: []earned{{1000, 100, 900}}, wantDeliveries: 1, wantTotal: earned{1000, 100, 900}},
+		{name: "part clawed back after release", release: true, refund: 500,
+			wantEarnings: []earned{{1000, 100, 900}, {-500, -50, -450}}, wantDeliveries: 1, wantTotal: earned{500, 50, 450}},
+		{name: "part refunded while held", refund: 400,
+			wantEarnings: []earned{{600, 60, 540}}, wantDeliveries: 1, wantTotal: earned{600, 60, 540}},
+		{name: "whole fee refunded while held", refund: 1000,
+			wantEarnings: []earned{}, wantDeliveries: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLedgerFixture(t, db, 1000)
+			tripID := f.matchTrip(t)
+			f.deposit(t, 1000)
+			if _, err := f.ledger.Hold(ctx, f.delivery, f.requester, 1000); err != nil {
+				t.Fatalf("Hold() error = %v", err)
+			}
+			if tt.release {
+				if _, err := f.ledger.Release(ctx, f.delivery, f.traveler); err != nil {
+					t.Fatalf("Release() error = %v", err)
+				}
+			}
+			if tt.refund > 0 {
+				if _, err := f.ledger.DisputeRefund(ctx, uuid.New(), f.delivery, f.traveler, tt.refund); err != nil {
+					t.Fatalf("DisputeRefund() error = %v", err)
+				}
+			}
+
+			earningsRepo := NewEarningsRepository(db)
+			now := time.Now()
+			earnings, err := earningsRepo.ListReleases(ctx, f.traveler, now.Add(-time.Hour), now.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("ListReleases() error = %v", err)
+			}
+			got := []earned{}
+			var totals models.EarningsTotals
+			for _, e := range earnings {
+				got = append(got, earned{e.Gross, e.Fee, e.Net})
+				totals.Add(e)
+			}
+			if len(got) != len(tt.wantEarnings) {
+				t.Fatalf("ListReleases() = %v, want %v", got, tt.wantEarnings)
+			}
+			for i := range got {
+				if got[i] != tt.wantEarnings[i] {
+					t.Errorf("ListReleases()[%d] = %v, want %v", i, got[i], tt.wantEarnings[i])
+				}
+			}
+			if totals.Deliveries != tt.wantDeliveries {
+				t.Errorf("listed deliveries = %d, want %d", totals.Deliveries, tt.wantDeliveries)
+			}
+
+			byTrip, err := earningsRepo.ByTrip(ctx, f.traveler)
+			if err != nil {
+				t.Fatalf("ByTrip() error = %v", err)
+			}
+			trip, ok := byTrip[tripID]
+			if !ok {
+				t.Fatalf("ByTrip() left out the trip")
+			}
+			if trip.Deliveries != tt.wantDeliveries {
+				t.Errorf("trip deliveries = %d, want %d", trip.Deliveries, tt.wantDeliveries)
+			}
+			if total := (earned{trip.Gross, trip.Fees, trip.Net}); total != tt.wantTotal {
+				t.Errorf("trip total = %v, want %v", total, tt.wantTotal)
+			}
+			if trip.Pending != 0 {
+				t.Errorf("trip pending = %s, want 0", trip.Pending)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import "campus-connect/internal/models"
+
+// geoSearchArgs returns the parameters of a proximity search filter, in
+// order: the origin and destination coordinates, the radius, the origin's
+// bounding box and the viewer. The destination is NULL unless the search is a
+// route corridor, and the viewer is NULL for anonymous searches.
+func geoSearchArgs(s models.GeoSearch) []interface{} {
+	var destLat, destLng *float64
+	if s.Destination != nil {
+		destLat, destLng = &s.Destination.Latitude, &s.Destination.Longitude
+	}
+	minLat, maxLat, minLng, maxLng := s.Origin.BoundingBox(s.RadiusMeters)
+	return []interface{}{
+		s.Origin.Latitude, s.Origin.Longitude, destLat, destLng, s.RadiusMeters,
+		minLat, maxLat, minLng, maxLng, s.ViewerID,
+	}
+}
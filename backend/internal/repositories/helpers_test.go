@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// testDB connects to the disposable database named by TEST_DATABASE_URL and
+// migrates it, or skips the test when it is not set. Tests create their own
+// users and delivery requests, so they can share the database.
+func testDB(t *testing.T) *database.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	db := &database.DB{DB: conn}
+	if err := db.RunMigrations("../../migrations"); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	return db
+}
+
+func createTestUser(t *testing.T, db *database.DB) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := db.Exec(`
+		INSERT INTO users (id, first_name, last_name, email, password, student_id, phone_number)
+		VALUES ($1, 'Test', 'User', $2, 'x', $3, '+233241234567')`,
+		id, id.String()+"@st.knust.edu.gh", id.String())
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return id
+}
+
+func createTestDelivery(t *testing.T, db *database.DB, userID uuid.UUID, status models.DeliveryStatus) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := db.Exec(`
+		INSERT INTO delivery_requests (
+			id, user_id, pickup_location, dropoff_location, item_description,
+			item_size, payment_amount, pickup_date, pickup_time, contact_info, status
+		) VALUES ($1, $2, 'Unity Hall', 'Brunei', 'Books', 'small', 10, NOW(), '10:00', '0241234567', $3)`,
+		id, userID, status)
+	if err != nil {
+		t.Fatalf("create delivery request: %v", err)
+	}
+	return id
+}
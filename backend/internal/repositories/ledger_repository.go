@@ -0,0 +1,597 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// LedgerRepository moves delivery fees between wallets, escrow and the
+// platform account. Each method is idempotent: repeating a hold while funds
+// are held, or a release or refund once they have left escrow, returns the
+// earlier transaction instead of posting again.
+type LedgerRepository interface {
+	// Hold moves amount from the payer's wallet into escrow for the
+	// delivery request. It fails if the wallet holds less than amount, so
+	// escrow only ever holds money that was paid in.
+	Hold(ctx context.Context, deliveryID, payerID uuid.UUID, amount models.Pesewas) (*models.LedgerTransaction, error)
+	// Release pays the amount held for the delivery request to the
+	// traveler's wallet, less the platform fee. It returns nil, paying
+	// nothing, if the fee was never held.
+	Release(ctx context.Context, deliveryID, travelerID uuid.UUID) (*models.LedgerTransaction, error)
+	// Refund returns the amount held for the delivery request to the
+	// wallet it came from. It returns nil if nothing was held.
+	Refund(ctx context.Context, deliveryID uuid.UUID) (*models.LedgerTransaction, error)
+	// Deposit credits the payer's wallet with a payment for the delivery
+	// request, identified by the provider's reference.
+	Deposit(ctx context.Context, deliveryID, payerID uuid.UUID, amount models.Pesewas, reference string) (*models.LedgerTransaction, error)
+	// DisputeRefund returns amount of the delivery request's fee to the
+	// requester's wallet for a resolved dispute. While the fee is held, the
+	// rest of it is released to the traveler as usual. Once released, the
+	// refund is taken back from the traveler and the platform in the shares
+	// they were paid, which may leave the traveler's wallet negative. It
+	// returns nil if the fee was never held or was refunded.
+	DisputeRefund(ctx context.Context, disputeID, deliveryID, travelerID uuid.UUID, amount models.Pesewas) (*models.LedgerTransaction, error)
+	// Payout debits the user's wallet for an approved payout sent to them.
+	// It fails if the wallet holds less than amount.
+	Payout(ctx context.Context, payoutID, userID uuid.UUID, amount models.Pesewas) (*models.LedgerTransaction, error)
+	GetWallet(ctx context.Context, userID uuid.UUID) (*models.LedgerAccount, error)
+	ListTransactions(ctx context.Context, deliveryID uuid.UUID) ([]*models.LedgerTransaction, error)
+}
+
+type ledgerRepository struct {
+	db             *database.DB
+	platformFeeBPS int
+}
+
+// NewLedgerRepository returns a ledger that keeps platformFeeBPS basis points
+// of each released fee in the platform account.
+func NewLedgerRepository(db *database.DB, platformFeeBPS int) LedgerRepository {
+	return &ledgerRepository{db: db, platformFeeBPS: platformFeeBPS}
+}
+
+// escrowState is what the ledger holds for one delivery request.
+type escrowState struct {
+	held  models.Pesewas
+	holds int
+	last  *models.LedgerTransaction
+}
+
+func (r *ledgerRepository) Hold(ctx context.Context, deliveryID, payerID uuid.UUID, amount models.Pesewas) (*models.LedgerTransaction, error) {
+	if amount <= 0 {
+		return nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Hold amount must be positive")
+	}
+
+	var txn *models.LedgerTransaction
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		state, err := r.lockEscrow(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+		if state.held > 0 {
+			txn = state.last
+			return nil
+		}
+
+		wallet, balance, err := r.lockWallet(ctx, payerID)
+		if err != nil {
+			return err
+		}
+		if balance < amount {
+			return insufficientBalance(balance, amount)
+		}
+		escrow, err := r.systemAccountID(ctx, models.LedgerEscrow)
+		if err != nil {
+			return err
+		}
+
+		txn, err = r.post(ctx, models.LedgerHold, &deliveryID, escrowKey(models.LedgerHold, deliveryID, state.holds+1), []models.LedgerPosting{
+			{AccountID: wallet, Amount: -amount},
+			{AccountID: escrow, Amount: amount},
+		})
+		return err
+	})
+	return txn, err
+}
+
+func (r *ledgerRepository) Release(ctx context.Context, deliveryID, travelerID uuid.UUID) (*models.LedgerTransaction, error) {
+	var txn *models.LedgerTransaction
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		state, err := r.lockEscrow(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+		if state.held <= 0 {
+			if state.last != nil && state.last.Kind == models.LedgerRelease {
+				txn = state.last
+			}
+			return nil
+		}
+
+		escrow, err := r.systemAccountID(ctx, models.LedgerEscrow)
+		if err != nil {
+			return err
+		}
+		wallet, err := r.walletID(ctx, travelerID)
+		if err != nil {
+			return err
+		}
+
+		fee := state.held.PercentBPS(r.platformFeeBPS)
+		postings := []models.LedgerPosting{{AccountID: escrow, Amount: -state.held}}
+		if payout := state.held - fee; payout > 0 {
+			postings = append(postings, models.LedgerPosting{AccountID: wallet, Amount: payout})
+		}
+		if fee > 0 {
+			platform, err := r.systemAccountID(ctx, models.LedgerPlatform)
+			if err != nil {
+				return err
+			}
+			postings = append(postings, models.LedgerPosting{AccountID: platform, Amount: fee})
+		}
+
+		txn, err = r.post(ctx, models.LedgerRelease, &deliveryID, escrowKey(models.LedgerRelease, deliveryID, state.holds), postings)
+		return err
+	})
+	return txn, err
+}
+
+func (r *ledgerRepository) Refund(ctx context.Context, deliveryID uuid.UUID) (*models.LedgerTransaction, error) {
+	var txn *models.LedgerTransaction
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		state, err := r.lockEscrow(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+		if state.held <= 0 {
+			if state.last != nil && state.last.Kind == models.LedgerRefund {
+				txn = state.last
+			}
+			return nil
+		}
+
+		wallet, err := r.payerWalletID(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+		escrow, err := r.systemAccountID(ctx, models.LedgerEscrow)
+		if err != nil {
+			return err
+		}
+
+		txn, err = r.post(ctx, models.LedgerRefund, &deliveryID, escrowKey(models.LedgerRefund, deliveryID, state.holds), []models.LedgerPosting{
+			{AccountID: escrow, Amount: -state.held},
+			{AccountID: wallet, Amount: state.held},
+		})
+		return err
+	})
+	return txn, err
+}
+
+func (r *ledgerRepository) DisputeRefund(ctx context.Context, disputeID, deliveryID, travelerID uuid.UUID, amount models.Pesewas) (*models.LedgerTransaction, error) {
+	if amount <= 0 {
+		return nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Refund amount must be positive")
+	}
+
+	var txn *models.LedgerTransaction
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		state, err := r.lockEscrow(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("%s:%s", models.LedgerDisputeRefund, disputeID)
+		existing, err := r.getByKey(ctx, key)
+		if err != nil || existing != nil {
+			txn = existing
+			return err
+		}
+
+		var postings []models.LedgerPosting
+		switch {
+		case state.held > 0:
+			postings, err = r.refundHeld(ctx, deliveryID, travelerID, state.held, amount)
+		case state.last != nil && (state.last.Kind == models.LedgerRelease || state.last.Kind == models.LedgerDisputeRefund):
+			postings, err = r.refundReleased(ctx, deliveryID, travelerID, amount)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		txn, err = r.post(ctx, models.LedgerDisputeRefund, &deliveryID, key, postings)
+		return err
+	})
+	return txn, err
+}
+
+// refundHeld settles a held fee for a dispute: amount goes to the payer and
+// the rest to the traveler, less the platform fee.
+func (r *ledgerRepository) refundHeld(ctx context.Context, deliveryID, travelerID uuid.UUID, held, amount models.Pesewas) ([]models.LedgerPosting, error) {
+	if amount > held {
+		return nil, apperrors.InvalidInput(apperrors.CodeBadRequest,
+			fmt.Sprintf("Refund cannot be more than the %s held", held))
+	}
+
+	escrow, err := r.systemAccountID(ctx, models.LedgerEscrow)
+	if err != nil {
+		return nil, err
+	}
+	payer, err := r.payerWalletID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	postings := []models.LedgerPosting{
+		{AccountID: escrow, Amount: -held},
+		{AccountID: payer, Amount: amount},
+	}
+
+	rest := held - amount
+	fee := rest.PercentBPS(r.platformFeeBPS)
+	if payout := rest - fee; payout > 0 {
+		wallet, err := r.walletID(ctx, travelerID)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, models.LedgerPosting{AccountID: wallet, Amount: payout})
+	}
+	if fee > 0 {
+		platform, err := r.systemAccountID(ctx, models.LedgerPlatform)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, models.LedgerPosting{AccountID: platform, Amount: fee})
+	}
+	return postings, nil
+}
+
+// refundReleased takes amount back from a released fee for a dispute. The
+// platform gives back its share of the fee and the traveler the rest, and
+// earlier dispute refunds count against what can be refunded.
+func (r *ledgerRepository) refundReleased(ctx context.Context, deliveryID, travelerID uuid.UUID, amount models.Pesewas) ([]models.LedgerPosting, error) {
+	// What the latest release took out of escrow and paid the platform, and
+	// what dispute refunds since then have moved back
+	query := `
+		WITH release AS (
+			SELECT id, created_at FROM ledger_transactions
+			WHERE delivery_request_id = $1 AND kind = 'release'
+			ORDER BY created_at DESC
+			LIMIT 1
+		)
+		SELECT
+			COALESCE(-SUM(p.amount) FILTER (WHERE t.kind = 'release' AND a.type = 'escrow'), 0),
+			COALESCE(SUM(p.amount) FILTER (WHERE t.kind = 'release' AND a.type = 'platform'), 0),
+			COALESCE(-SUM(p.amount) FILTER (WHERE t.kind = 'dispute_refund' AND a.type <> 'escrow' AND p.amount < 0), 0)
+		FROM ledger_transactions t
+		JOIN ledger_postings p ON p.transaction_id = t.id
+		JOIN ledger_accounts a ON a.id = p.account_id
+		JOIN release ON t.id = release.id OR (t.kind = 'dispute_refund' AND t.created_at >= release.created_at)
+		WHERE t.delivery_request_id = $1`
+	var released, platformShare, refunded models.Pesewas
+	err := r.db.QueryRowNamed(ctx, "ledger.refundReleased.released", query, deliveryID).
+		Scan(&released, &platformShare, &refunded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum released fee: %w", err)
+	}
+	if available := released - refunded; amount > available {
+		return nil, apperrors.InvalidInput(apperrors.CodeBadRequest,
+			fmt.Sprintf("Refund cannot be more than the %s paid out and not yet refunded", max(available, 0)))
+	}
+
+	payer, err := r.payerWalletID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	fromPlatform := models.Pesewas(int64(platformShare) * int64(amount) / int64(released))
+	fromTraveler := amount - fromPlatform
+	postings := []models.LedgerPosting{{AccountID: payer, Amount: amount}}
+	if fromTraveler > 0 {
+		wallet, err := r.walletID(ctx, travelerID)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, models.LedgerPosting{AccountID: wallet, Amount: -fromTraveler})
+	}
+	if fromPlatform > 0 {
+		platform, err := r.systemAccountID(ctx, models.LedgerPlatform)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, models.LedgerPosting{AccountID: platform, Amount: -fromPlatform})
+	}
+	return postings, nil
+}
+
+func (r *ledgerRepository) Deposit(ctx context.Context, deliveryID, payerID uuid.UUID, amount models.Pesewas, reference string) (*models.LedgerTransaction, error) {
+	if amount <= 0 {
+		return nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Deposit amount must be positive")
+	}
+
+	var txn *models.LedgerTransaction
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		if _, err := r.lockEscrow(ctx, deliveryID); err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("%s:%s", models.LedgerDeposit, reference)
+		existing, err := r.getByKey(ctx, key)
+		if err != nil || existing != nil {
+			txn = existing
+			return err
+		}
+
+		provider, err := r.systemAccountID(ctx, models.LedgerProvider)
+		if err != nil {
+			return err
+		}
+		wallet, err := r.walletID(ctx, payerID)
+		if err != nil {
+			return err
+		}
+
+		txn, err = r.post(ctx, models.LedgerDeposit, &deliveryID, key, []models.LedgerPosting{
+			{AccountID: provider, Amount: -amount},
+			{AccountID: wallet, Amount: amount},
+		})
+		return err
+	})
+	return txn, err
+}
+
+func (r *ledgerRepository) Payout(ctx context.Context, payoutID, userID uuid.UUID, amount models.Pesewas) (*models.LedgerTransaction, error) {
+	var txn *models.LedgerTransaction
+	err := r.db.InTx(ctx, func(ctx context.Context) error {
+		wallet, balance, err := r.lockWallet(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("%s:%s", models.LedgerPayout, payoutID)
+		existing, err := r.getByKey(ctx, key)
+		if err != nil || existing != nil {
+			txn = existing
+			return err
+		}
+		if balance < amount {
+			return insufficientBalance(balance, amount)
+		}
+
+		provider, err := r.systemAccountID(ctx, models.LedgerProvider)
+		if err != nil {
+			return err
+		}
+		txn, err = r.post(ctx, models.LedgerPayout, nil, key, []models.LedgerPosting{
+			{AccountID: wallet, Amount: -amount},
+			{AccountID: provider, Amount: amount},
+		})
+		return err
+	})
+	return txn, err
+}
+
+func (r *ledgerRepository) GetWallet(ctx context.Context, userID uuid.UUID) (*models.LedgerAccount, error) {
+	id, err := r.walletID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.LedgerAccount{}
+	query := `SELECT id, type, user_id, balance, created_at FROM ledger_accounts WHERE id = $1`
+	err = r.db.QueryRowNamed(ctx, "ledger.GetWallet", query, id).Scan(
+		&account.ID, &account.Type, &account.UserID, &account.Balance, &account.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return account, nil
+}
+
+func (r *ledgerRepository) ListTransactions(ctx context.Context, deliveryID uuid.UUID) ([]*models.LedgerTransaction, error) {
+	query := `
+		SELECT t.id, t.kind, t.delivery_request_id, t.idempotency_key, t.created_at, p.account_id, p.amount
+		FROM ledger_transactions t
+		JOIN ledger_postings p ON p.transaction_id = t.id
+		WHERE t.delivery_request_id = $1
+		ORDER BY t.created_at, t.id, p.id`
+	rows, err := r.db.QueryNamed(ctx, "ledger.ListTransactions", query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger transactions: %w", err)
+	}
+	defer rows.Close()
+
+	txns := []*models.LedgerTransaction{}
+	for rows.Next() {
+		var txn models.LedgerTransaction
+		var posting models.LedgerPosting
+		err := rows.Scan(&txn.ID, &txn.Kind, &txn.DeliveryRequestID, &txn.IdempotencyKey, &txn.CreatedAt,
+			&posting.AccountID, &posting.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ledger transaction: %w", err)
+		}
+		if n := len(txns); n == 0 || txns[n-1].ID != txn.ID {
+			txns = append(txns, &txn)
+		}
+		last := txns[len(txns)-1]
+		last.Postings = append(last.Postings, posting)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ledger transactions: %w", err)
+	}
+	return txns, nil
+}
+
+// lockEscrow locks the delivery request row, serialising ledger changes for
+// it until the transaction ends, and returns what escrow holds for it.
+func (r *ledgerRepository) lockEscrow(ctx context.Context, deliveryID uuid.UUID) (*escrowState, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowNamed(ctx, "ledger.lockEscrow.lock",
+		`SELECT id FROM delivery_requests WHERE id = $1 FOR UPDATE`, deliveryID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodeRequestNotFound, "Delivery request not found")
+		}
+		return nil, fmt.Errorf("failed to lock delivery request: %w", err)
+	}
+
+	state := &escrowState{}
+	query := `
+		SELECT
+			COALESCE(SUM(p.amount) FILTER (WHERE a.type = 'escrow'), 0),
+			COUNT(DISTINCT t.id) FILTER (WHERE t.kind = 'hold')
+		FROM ledger_transactions t
+		JOIN ledger_postings p ON p.transaction_id = t.id
+		JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE t.delivery_request_id = $1`
+	err = r.db.QueryRowNamed(ctx, "ledger.lockEscrow.sum", query, deliveryID).Scan(&state.held, &state.holds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum escrow: %w", err)
+	}
+
+	txns, err := r.ListTransactions(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, txn := range txns {
+		if txn.Kind != models.LedgerDeposit {
+			state.last = txn
+		}
+	}
+	return state, nil
+}
+
+// lockWallet locks the user's wallet until the transaction ends and returns
+// its ID and balance.
+func (r *ledgerRepository) lockWallet(ctx context.Context, userID uuid.UUID) (uuid.UUID, models.Pesewas, error) {
+	wallet, err := r.walletID(ctx, userID)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	var balance models.Pesewas
+	err = r.db.QueryRowNamed(ctx, "ledger.lockWallet",
+		`SELECT balance FROM ledger_accounts WHERE id = $1 FOR UPDATE`, wallet).Scan(&balance)
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to lock wallet: %w", err)
+	}
+	return wallet, balance, nil
+}
+
+func insufficientBalance(balance, amount models.Pesewas) error {
+	return apperrors.InvalidInput(apperrors.CodeInsufficientBalance,
+		fmt.Sprintf("Wallet holds %s, less than the %s needed", balance, amount))
+}
+
+// getByKey returns the transaction recorded under an idempotency key, or nil.
+func (r *ledgerRepository) getByKey(ctx context.Context, key string) (*models.LedgerTransaction, error) {
+	txn := &models.LedgerTransaction{IdempotencyKey: key}
+	query := `SELECT id, kind, delivery_request_id, created_at FROM ledger_transactions WHERE idempotency_key = $1`
+	err := r.db.QueryRowNamed(ctx, "ledger.getByKey", query, key).Scan(
+		&txn.ID, &txn.Kind, &txn.DeliveryRequestID, &txn.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ledger transaction: %w", err)
+	}
+	return txn, nil
+}
+
+// escrowKey ties a hold, release or refund to the nth hold on the delivery
+// request, so a retried or concurrent transition cannot post twice.
+func escrowKey(kind models.LedgerTransactionKind, deliveryID uuid.UUID, n int) string {
+	return fmt.Sprintf("%s:%s:%d", kind, deliveryID, n)
+}
+
+// post records a balanced transaction under an idempotency key and applies
+// it to the account balances.
+func (r *ledgerRepository) post(ctx context.Context, kind models.LedgerTransactionKind, deliveryID *uuid.UUID, key string, postings []models.LedgerPosting) (*models.LedgerTransaction, error) {
+	var sum models.Pesewas
+	for _, p := range postings {
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return nil, fmt.Errorf("ledger transaction %s does not balance: off by %d pesewas", key, sum)
+	}
+
+	txn := &models.LedgerTransaction{
+		ID:                uuid.New(),
+		Kind:              kind,
+		DeliveryRequestID: deliveryID,
+		IdempotencyKey:    key,
+		Postings:          postings,
+	}
+	query := `
+		INSERT INTO ledger_transactions (id, kind, delivery_request_id, idempotency_key)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+	err := r.db.QueryRowNamed(ctx, "ledger.post.transaction", query, txn.ID, txn.Kind, txn.DeliveryRequestID, txn.IdempotencyKey).Scan(&txn.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record ledger transaction: %w", err)
+	}
+
+	for _, p := range postings {
+		_, err := r.db.ExecNamed(ctx, "ledger.post.posting",
+			`INSERT INTO ledger_postings (transaction_id, account_id, amount) VALUES ($1, $2, $3)`,
+			txn.ID, p.AccountID, p.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record ledger posting: %w", err)
+		}
+		_, err = r.db.ExecNamed(ctx, "ledger.post.balance",
+			`UPDATE ledger_accounts SET balance = balance + $2 WHERE id = $1`,
+			p.AccountID, p.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update ledger balance: %w", err)
+		}
+	}
+	return txn, nil
+}
+
+// payerWalletID returns the wallet the delivery request's latest hold took
+// its fee from.
+func (r *ledgerRepository) payerWalletID(ctx context.Context, deliveryID uuid.UUID) (uuid.UUID, error) {
+	query := `
+		SELECT p.account_id
+		FROM ledger_postings p
+		JOIN ledger_transactions t ON t.id = p.transaction_id
+		WHERE t.delivery_request_id = $1 AND t.kind = 'hold' AND p.amount < 0
+		ORDER BY t.created_at DESC
+		LIMIT 1`
+	var wallet uuid.UUID
+	if err := r.db.QueryRowNamed(ctx, "ledger.payerWalletID", query, deliveryID).Scan(&wallet); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find held funds: %w", err)
+	}
+	return wallet, nil
+}
+
+// walletID returns the ID of the user's wallet, creating it on first use.
+func (r *ledgerRepository) walletID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	_, err := r.db.ExecNamed(ctx, "ledger.walletID.create", `
+		INSERT INTO ledger_accounts (type, user_id) VALUES ('wallet', $1)
+		ON CONFLICT (user_id) WHERE type = 'wallet' DO NOTHING`, userID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+
+	var id uuid.UUID
+	err = r.db.QueryRowNamed(ctx, "ledger.walletID.get",
+		`SELECT id FROM ledger_accounts WHERE type = 'wallet' AND user_id = $1`, userID).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return id, nil
+}
+
+func (r *ledgerRepository) systemAccountID(ctx context.Context, accountType models.LedgerAccountType) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowNamed(ctx, "ledger.systemAccountID",
+		`SELECT id FROM ledger_accounts WHERE type = $1 AND user_id IS NULL`, accountType).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get %s account: %w", accountType, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,379 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ledgerFixture is a delivery request between a requester and a traveler.
+type ledgerFixture struct {
+	db        *database.DB
+	ledger    LedgerRepository
+	delivery  uuid.UUID
+	requester uuid.UUID
+	traveler  uuid.UUID
+}
+
+func newLedgerFixture(t *testing.T, db *database.DB, platformFeeBPS int) *ledgerFixture {
+	t.Helper()
+	requester := createTestUser(t, db)
+	return &ledgerFixture{
+		db:        db,
+		ledger:    NewLedgerRepository(db, platformFeeBPS),
+		delivery:  createTestDelivery(t, db, requester, models.DeliveryMatched),
+		requester: requester,
+		traveler:  createTestUser(t, db),
+	}
+}
+
+// deposit credits the requester's wallet as a successful payment would.
+func (f *ledgerFixture) deposit(t *testing.T, amount models.Pesewas) {
+	t.Helper()
+	if _, err := f.ledger.Deposit(context.Background(), f.delivery, f.requester, amount, uuid.NewString()); err != nil {
+		t.Fatalf("deposit: %v", err)
+	}
+}
+
+func (f *ledgerFixture) wallet(t *testing.T, userID uuid.UUID) models.Pesewas {
+	t.Helper()
+	wallet, err := f.ledger.GetWallet(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("get wallet: %v", err)
+	}
+	return wallet.Balance
+}
+
+// held is what escrow holds for the fixture's delivery request.
+func (f *ledgerFixture) held(t *testing.T) models.Pesewas {
+	t.Helper()
+	var held models.Pesewas
+	err := f.db.QueryRow(`
+		SELECT COALESCE(SUM(p.amount), 0)
+		FROM ledger_postings p
+		JOIN ledger_transactions t ON t.id = p.transaction_id
+		JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE t.delivery_request_id = $1 AND a.type = 'escrow'`, f.delivery).Scan(&held)
+	if err != nil {
+		t.Fatalf("sum escrow: %v", err)
+	}
+	return held
+}
+
+func (f *ledgerFixture) transactions(t *testing.T) int {
+	t.Helper()
+	txns, err := f.ledger.ListTransactions(context.Background(), f.delivery)
+	if err != nil {
+		t.Fatalf("list transactions: %v", err)
+	}
+	return len(txns)
+}
+
+func TestLedgerHold(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		deposit    models.Pesewas
+		hold       models.Pesewas
+		wantCode   apperrors.Code
+		wantHeld   models.Pesewas
+		wantWallet models.Pesewas
+	}{
+		{name: "wallet covers the fee", deposit: 1000, hold: 1000, wantHeld: 1000, wantWallet: 0},
+		{name: "wallet holds more than the fee", deposit: 1500, hold: 1000, wantHeld: 1000, wantWallet: 500},
+		{name: "wallet short of the fee", deposit: 500, hold: 1000, wantCode: apperrors.CodeInsufficientBalance, wantWallet: 500},
+		{name: "empty wallet", hold: 1000, wantCode: apperrors.CodeInsufficientBalance},
+		{name: "zero amount", deposit: 1000, hold: 0, wantCode: apperrors.CodeBadRequest, wantWallet: 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLedgerFixture(t, db, 0)
+			if tt.deposit > 0 {
+				f.deposit(t, tt.deposit)
+			}
+
+			txn, err := f.ledger.Hold(ctx, f.delivery, f.requester, tt.hold)
+			if tt.wantCode != "" {
+				if !apperrors.HasCode(err, tt.wantCode) {
+					t.Fatalf("Hold() error = %v, want %s", err, tt.wantCode)
+				}
+			} else if err != nil || txn == nil {
+				t.Fatalf("Hold() = %v, %v, want a transaction", txn, err)
+			}
+
+			if got := f.held(t); got != tt.wantHeld {
+				t.Errorf("held = %s, want %s", got, tt.wantHeld)
+			}
+			if got := f.wallet(t, f.requester); got != tt.wantWallet {
+				t.Errorf("requester wallet = %s, want %s", got, tt.wantWallet)
+			}
+		})
+	}
+
+	t.Run("repeated hold", func(t *testing.T) {
+		f := newLedgerFixture(t, db, 0)
+		f.deposit(t, 2000)
+		first, err := f.ledger.Hold(ctx, f.delivery, f.requester, 1000)
+		if err != nil {
+			t.Fatalf("Hold() error = %v", err)
+		}
+		second, err := f.ledger.Hold(ctx, f.delivery, f.requester, 1000)
+		if err != nil {
+			t.Fatalf("repeated Hold() error = %v", err)
+		}
+		if second.ID != first.ID {
+			t.Errorf("repeated Hold() posted %s, want the earlier %s", second.ID, first.ID)
+		}
+		if got := f.wallet(t, f.requester); got != 1000 {
+			t.Errorf("requester wallet = %s, want %s", got, models.Pesewas(1000))
+		}
+	})
+}
+
+func TestLedgerRelease(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		platformFeeBPS int
+		hold           models.Pesewas
+		refundFirst    bool
+		wantTxn        bool
+		wantTraveler   models.Pesewas
+	}{
+		{name: "no platform fee", hold: 1000, wantTxn: true, wantTraveler: 1000},
+		{name: "platform fee", platformFeeBPS: 1000, hold: 1000, wantTxn: true, wantTraveler: 900},
+		{name: "fee rounds down", platformFeeBPS: 250, hold: 999, wantTxn: true, wantTraveler: 975},
+		{name: "never funded", wantTraveler: 0},
+		{name: "refunded", hold: 1000, refundFirst: true, wantTraveler: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLedgerFixture(t, db, tt.platformFeeBPS)
+			if tt.hold > 0 {
+				f.deposit(t, tt.hold)
+				if _, err := f.ledger.Hold(ctx, f.delivery, f.requester, tt.hold); err != nil {
+					t.Fatalf("Hold() error = %v", err)
+				}
+			}
+			if tt.refundFirst {
+				if _, err := f.ledger.Refund(ctx, f.delivery); err != nil {
+					t.Fatalf("Refund() error = %v", err)
+				}
+			}
+			before := f.transactions(t)
+
+			txn, err := f.ledger.Release(ctx, f.delivery, f.traveler)
+			if err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+			if (txn != nil) != tt.wantTxn {
+				t.Fatalf("Release() = %v, want a transaction: %t", txn, tt.wantTxn)
+			}
+			if !tt.wantTxn && f.transactions(t) != before {
+				t.Errorf("Release() posted a transaction for an unfunded fee")
+			}
+			if got := f.held(t); got != 0 {
+				t.Errorf("held = %s, want 0", got)
+			}
+			if got := f.wallet(t, f.traveler); got != tt.wantTraveler {
+				t.Errorf("traveler wallet = %s, want %s", got, tt.wantTraveler)
+			}
+
+			again, err := f.ledger.Release(ctx, f.delivery, f.traveler)
+			if err != nil {
+				t.Fatalf("repeated Release() error = %v", err)
+			}
+			if txn != nil && (again == nil || again.ID != txn.ID) {
+				t.Errorf("repeated Release() = %v, want the earlier %s", again, txn.ID)
+			}
+			if got := f.wallet(t, f.traveler); got != tt.wantTraveler {
+				t.Errorf("traveler wallet after repeat = %s, want %s", got, tt.wantTraveler)
+			}
+		})
+	}
+}
+
+func TestLedgerDisputeRefund(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		platformFeeBPS int
+		hold           models.Pesewas
+		release        bool
+		refund         models.Pesewas
+		wantCode       apperrors.Code
+		wantTxn        bool
+		wantRequester  models.Pesewas
+		wantTraveler   models.Pesewas
+	}{
+		{name: "whole fee while held", hold: 1000, refund: 1000, wantTxn: true, wantRequester: 1000},
+		{name: "part of fee while held", platformFeeBPS: 1000, hold: 1000, refund: 400,
+			wantTxn: true, wantRequester: 400, wantTraveler: 540},
+		{name: "more than held", hold: 1000, refund: 1500, wantCode: apperrors.CodeBadRequest},
+		{name: "whole fee after release", platformFeeBPS: 1000, hold: 1000, release: true, refund: 1000,
+			wantTxn: true, wantRequester: 1000, wantTraveler: 0},
+		{name: "part of fee after release", platformFeeBPS: 1000, hold: 1000, release: true, refund: 500,
+			wantTxn: true, wantRequester: 500, wantTraveler: 450},
+		{name: "more than released", hold: 1000, release: true, refund: 1001,
+			wantCode: apperrors.CodeBadRequest, wantTraveler: 1000},
+		{name: "never funded", refund: 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLedgerFixture(t, db, tt.platformFeeBPS)
+			if tt.hold > 0 {
+				f.deposit(t, tt.hold)
+				if _, err := f.ledger.Hold(ctx, f.delivery, f.requester, tt.hold); err != nil {
+					t.Fatalf("Hold() error = %v", err)
+				}
+			}
+			if tt.release {
+				if _, err := f.ledger.Release(ctx, f.delivery, f.traveler); err != nil {
+					t.Fatalf("Release() error = %v", err)
+				}
+			}
+
+			disputeID := uuid.New()
+			txn, err := f.ledger.DisputeRefund(ctx, disputeID, f.delivery, f.traveler, tt.refund)
+			if tt.wantCode != "" {
+				if !apperrors.HasCode(err, tt.wantCode) {
+					t.Fatalf("DisputeRefund() error = %v, want %s", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Fatalf("DisputeRefund() error = %v", err)
+			}
+			if (txn != nil) != tt.wantTxn {
+				t.Fatalf("DisputeRefund() = %v, want a transaction: %t", txn, tt.wantTxn)
+			}
+
+			if got := f.wallet(t, f.requester); got != tt.wantRequester {
+				t.Errorf("requester wallet = %s, want %s", got, tt.wantRequester)
+			}
+			if got := f.wallet(t, f.traveler); got != tt.wantTraveler {
+				t.Errorf("traveler wallet = %s, want %s", got, tt.wantTraveler)
+			}
+			if tt.wantTxn {
+				if got := f.held(t); got != 0 {
+					t.Errorf("held = %s, want 0", got)
+				}
+				again, err := f.ledger.DisputeRefund(ctx, disputeID, f.delivery, f.traveler, tt.refund)
+				if err != nil || again == nil || again.ID != txn.ID {
+					t.Errorf("repeated DisputeRefund() = %v, %v, want the earlier %s", again, err, txn.ID)
+				}
+				if got := f.wallet(t, f.requester); got != tt.wantRequester {
+					t.Errorf("requester wallet after repeat = %s, want %s", got, tt.wantRequester)
+				}
+			}
+		})
+	}
+}
+
+func TestLedgerPayout(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		earned     models.Pesewas
+		payout     models.Pesewas
+		wantCode   apperrors.Code
+		wantWallet models.Pesewas
+	}{
+		{name: "whole balance", earned: 1000, payout: 1000, wantWallet: 0},
+		{name: "part of balance", earned: 1000, payout: 300, wantWallet: 700},
+		{name: "more than balance", earned: 1000, payout: 1001, wantCode: apperrors.CodeInsufficientBalance, wantWallet: 1000},
+		{name: "unfunded delivery earns nothing", payout: 1000, wantCode: apperrors.CodeInsufficientBalance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLedgerFixture(t, db, 0)
+			if tt.earned > 0 {
+				f.deposit(t, tt.earned)
+				if _, err := f.ledger.Hold(ctx, f.delivery, f.requester, tt.earned); err != nil {
+					t.Fatalf("Hold() error = %v", err)
+				}
+			}
+			if _, err := f.ledger.Release(ctx, f.delivery, f.traveler); err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+
+			payoutID := uuid.New()
+			_, err := f.ledger.Payout(ctx, payoutID, f.traveler, tt.payout)
+			if tt.wantCode != "" {
+				if !apperrors.HasCode(err, tt.wantCode) {
+					t.Fatalf("Payout() error = %v, want %s", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Fatalf("Payout() error = %v", err)
+			} else if _, err := f.ledger.Payout(ctx, payoutID, f.traveler, tt.payout); err != nil {
+				t.Fatalf("repeated Payout() error = %v", err)
+			}
+			if got := f.wallet(t, f.traveler); got != tt.wantWallet {
+				t.Errorf("traveler wallet = %s, want %s", got, tt.wantWallet)
+			}
+		})
+	}
+}
+
+// TestLedgerPostingsBalance checks the database rejects a transaction whose
+// postings do not sum to zero, even when written around the repository.
+func TestLedgerPostingsBalance(t *testing.T) {
+	db := testDB(t)
+	f := newLedgerFixture(t, db, 0)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		amounts []models.Pesewas
+		wantErr bool
+	}{
+		{name: "balanced", amounts: []models.Pesewas{-100, 100}},
+		{name: "balanced across three postings", amounts: []models.Pesewas{-100, 60, 40}},
+		{name: "unbalanced", amounts: []models.Pesewas{-100, 90}, wantErr: true},
+		{name: "single posting", amounts: []models.Pesewas{100}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escrow, err := f.ledger.(*ledgerRepository).systemAccountID(ctx, models.LedgerEscrow)
+			if err != nil {
+				t.Fatalf("get escrow account: %v", err)
+			}
+
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatalf("begin: %v", err)
+			}
+			defer tx.Rollback()
+
+			txnID := uuid.New()
+			_, err = tx.Exec(`
+				INSERT INTO ledger_transactions (id, kind, delivery_request_id, idempotency_key)
+				VALUES ($1, 'hold', $2, $3)`, txnID, f.delivery, "test:"+txnID.String())
+			if err != nil {
+				t.Fatalf("insert transaction: %v", err)
+			}
+			for _, amount := range tt.amounts {
+				_, err := tx.Exec(`INSERT INTO ledger_postings (transaction_id, account_id, amount) VALUES ($1, $2, $3)`,
+					txnID, escrow, amount)
+				if err != nil {
+					t.Fatalf("insert posting: %v", err)
+				}
+			}
+
+			if err := tx.Commit(); (err != nil) != tt.wantErr {
+				t.Errorf("Commit() error = %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type LocationRepository interface {
+	// List returns catalogued locations by name, only those in category
+	// unless it is empty.
+	List(ctx context.Context, category models.LocationCategory) ([]*models.Location, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Location, error)
+	// Resolve finds the location whose name or an alias matches text,
+	// ignoring case and surrounding spaces.
+	Resolve(ctx context.Context, text string) (*models.Location, error)
+	// Search returns locations whose name or an alias contains q, exact
+	// matches first, then prefixes, then the rest.
+	Search(ctx context.Context, q string, limit int) ([]*models.Location, error)
+}
+
+type locationRepository struct {
+	db *database.DB
+}
+
+func NewLocationRepository(db *database.DB) LocationRepository {
+	return &locationRepository{db: db}
+}
+
+const locationColumns = `
+	l.id, l.slug, l.name, l.category, l.latitude, l.longitude, l.created_at,
+	ARRAY(SELECT a.alias FROM location_aliases a WHERE a.location_id = l.id ORDER BY a.alias)`
+
+func (r *locationRepository) List(ctx context.Context, category models.LocationCategory) ([]*models.Location, error) {
+	query := `
+		SELECT ` + locationColumns + `
+		FROM locations l
+		WHERE $1 = '' OR l.category::TEXT = $1
+		ORDER BY l.name`
+
+	rows, err := r.db.QueryNamed(ctx, "locations.List", query, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locations: %w", err)
+	}
+	return scanLocations(rows)
+}
+
+func (r *locationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Location, error) {
+	query := `
+		SELECT ` + locationColumns + `
+		FROM locations l
+		WHERE l.id = $1`
+
+	location, err := scanLocation(r.db.QueryRowNamed(ctx, "locations.GetByID", query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodeLocationNotFound, "Location not found")
+		}
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	return location, nil
+}
+
+func (r *locationRepository) Resolve(ctx context.Context, text string) (*models.Location, error) {
+	query := `
+		SELECT ` + locationColumns + `
+		FROM locations l
+		WHERE lower(l.name) = lower(btrim($1))
+			OR l.id = (SELECT location_id FROM location_aliases WHERE alias = lower(btrim($1)))
+		LIMIT 1`
+
+	location, err := scanLocation(r.db.QueryRowNamed(ctx, "locations.Resolve", query, text))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodeLocationNotFound, "Location not found")
+		}
+		return nil, fmt.Errorf("failed to resolve location: %w", err)
+	}
+	return location, nil
+}
+
+func (r *locationRepository) Search(ctx context.Context, q string, limit int) ([]*models.Location, error) {
+	query := `
+		WITH names AS (
+			SELECT id AS location_id, lower(name) AS name FROM locations
+			UNION ALL
+			SELECT location_id, alias FROM location_aliases
+		), matches AS (
+			SELECT location_id, MIN(CASE
+				WHEN name = $1 THEN 0
+				WHEN name LIKE $2 || '%' THEN 1
+				ELSE 2
+			END) AS rank
+			FROM names
+			WHERE name LIKE '%' || $2 || '%'
+			GROUP BY location_id
+		)
+		SELECT ` + locationColumns + `
+		FROM matches m
+		JOIN locations l ON l.id = m.location_id
+		ORDER BY m.rank, l.name
+		LIMIT $3`
+
+	q = strings.ToLower(strings.TrimSpace(q))
+	rows, err := r.db.QueryNamed(ctx, "locations.Search", query, q, escapeLike(q), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search locations: %w", err)
+	}
+	return scanLocations(rows)
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLocation(row rowScanner) (*models.Location, error) {
+	l := &models.Location{}
+	err := row.Scan(&l.ID, &l.Slug, &l.Name, &l.Category, &l.Latitude, &l.Longitude, &l.CreatedAt,
+		pq.Array(&l.Aliases))
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func scanLocations(rows *database.Rows) ([]*models.Location, error) {
+	defer rows.Close()
+
+	locations := []*models.Location{}
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan location: %w", err)
+		}
+		locations = append(locations, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating locations: %w", err)
+	}
+	return locations, nil
+}
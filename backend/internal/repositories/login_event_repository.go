@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// unmatchedLoginEventsPerIP caps the failed sign-ins for emails without an
+// account kept per IP in any hour, so guessing emails cannot fill the table.
+const unmatchedLoginEventsPerIP = 20
+
+type LoginEventRepository interface {
+	// Create records a sign-in attempt. Attempts for emails without an
+	// account are dropped once their IP has made unmatchedLoginEventsPerIP
+	// in the past hour; event.CreatedAt stays zero when one is dropped.
+	Create(ctx context.Context, event *models.LoginEvent) error
+	ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]*models.LoginEvent, error)
+	// CountSuccessful returns how many successful sign-ins userID has made
+	// from deviceHash and in total.
+	CountSuccessful(ctx context.Context, userID uuid.UUID, deviceHash string) (fromDevice, total int, err error)
+}
+
+type loginEventRepository struct {
+	db *database.DB
+}
+
+func NewLoginEventRepository(db *database.DB) LoginEventRepository {
+	return &loginEventRepository{db: db}
+}
+
+func (r *loginEventRepository) Create(ctx context.Context, event *models.LoginEvent) error {
+	query := `
+		INSERT INTO login_events (id, user_id, email, ip_address, user_agent, device_hash, success, failure_reason, new_device)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9
+		WHERE $2::UUID IS NOT NULL OR (
+			SELECT COUNT(*) FROM login_events
+			WHERE user_id IS NULL AND ip_address = $4 AND created_at > CURRENT_TIMESTAMP - INTERVAL '1 hour'
+		) < $10
+		RETURNING created_at`
+	err := r.db.QueryRowNamed(ctx, "loginEvents.Create", query,
+		event.ID, event.UserID, event.Email, event.IPAddress, event.UserAgent,
+		event.DeviceHash, event.Success, event.FailureReason, event.NewDevice, unmatchedLoginEventsPerIP,
+	).Scan(&event.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to create login event: %w", err)
+	}
+	return nil
+}
+
+func (r *loginEventRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]*models.LoginEvent, error) {
+	query := `
+		SELECT id, user_id, email, ip_address, user_agent, device_hash, success, failure_reason, new_device, created_at
+		FROM login_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`
+	rows, err := r.db.QueryNamed(ctx, "loginEvents.ListByUser", query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*models.LoginEvent{}
+	for rows.Next() {
+		event := &models.LoginEvent{}
+		err := rows.Scan(&event.ID, &event.UserID, &event.Email, &event.IPAddress, &event.UserAgent,
+			&event.DeviceHash, &event.Success, &event.FailureReason, &event.NewDevice, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan login event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating login events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *loginEventRepository) CountSuccessful(ctx context.Context, userID uuid.UUID, deviceHash string) (int, int, error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE device_hash = $2), COUNT(*)
+		FROM login_events
+		WHERE user_id = $1 AND success`
+	var fromDevice, total int
+	err := r.db.QueryRowNamed(ctx, "loginEvents.CountSuccessful", query, userID, deviceHash).Scan(&fromDevice, &total)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count login events: %w", err)
+	}
+	return fromDevice, total, nil
+}
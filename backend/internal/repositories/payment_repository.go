@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type PaymentRepository interface {
+	// Create records a pending payment. It fails with a conflict if the
+	// delivery request already has a pending or successful payment.
+	Create(ctx context.Context, payment *models.Payment) error
+	GetByReference(ctx context.Context, reference string) (*models.Payment, error)
+	// UpdatePending updates the display text of a payment still pending.
+	UpdatePending(ctx context.Context, reference string, displayText *string) error
+	// Finish moves a pending payment to a final status, or a failed or
+	// abandoned one to success when the charge went through after all. A
+	// late success keeps the earlier status, with the ledger transaction
+	// recorded, if the delivery request has another pending or successful
+	// payment by then. It reports false, changing nothing, if the payment
+	// was already finished another way.
+	Finish(ctx context.Context, payment *models.Payment) (bool, error)
+	// ListPending returns payments pending since before the given time,
+	// oldest first.
+	ListPending(ctx context.Context, before time.Time, limit int) ([]*models.Payment, error)
+}
+
+type paymentRepository struct {
+	db *database.DB
+}
+
+func NewPaymentRepository(db *database.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+const paymentColumns = `id, delivery_request_id, user_id, provider, reference, amount, currency,
+	network, phone_number, status, display_text, failure_reason, ledger_transaction_id,
+	paid_at, created_at, updated_at`
+
+func scanPayment(row rowScanner, p *models.Payment) error {
+	return row.Scan(&p.ID, &p.DeliveryRequestID, &p.UserID, &p.Provider, &p.Reference, &p.Amount, &p.Currency,
+		&p.Network, &p.PhoneNumber, &p.Status, &p.DisplayText, &p.FailureReason, &p.LedgerTransactionID,
+		&p.PaidAt, &p.CreatedAt, &p.UpdatedAt)
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	query := `
+		INSERT INTO payments (
+			id, delivery_request_id, user_id, provider, reference, amount, currency,
+			network, phone_number, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowNamed(ctx, "payments.Create", query,
+		payment.ID, payment.DeliveryRequestID, payment.UserID, payment.Provider, payment.Reference,
+		payment.Amount, payment.Currency, payment.Network, payment.PhoneNumber, payment.Status,
+	).Scan(&payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_payments_delivery_active" {
+			return apperrors.Conflict(apperrors.CodePaymentExists, "Delivery request already has a pending or completed payment")
+		}
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentRepository) GetByReference(ctx context.Context, reference string) (*models.Payment, error) {
+	payment := &models.Payment{}
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE reference = $1`
+	if err := scanPayment(r.db.QueryRowNamed(ctx, "payments.GetByReference", query, reference), payment); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodePaymentNotFound, "Payment not found")
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return payment, nil
+}
+
+func (r *paymentRepository) UpdatePending(ctx context.Context, reference string, displayText *string) error {
+	query := `UPDATE payments SET display_text = $2 WHERE reference = $1 AND status = 'pending'`
+	if _, err := r.db.ExecNamed(ctx, "payments.UpdatePending", query, reference, displayText); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentRepository) Finish(ctx context.Context, payment *models.Payment) (bool, error) {
+	// A late success takes the payment's place only if no other payment for
+	// the delivery request holds it; the status checks are repeated in the
+	// UPDATE so they are made again once the row is locked
+	query := `
+		WITH target AS (
+			SELECT p.id, CASE
+					WHEN p.status = 'pending' OR NOT EXISTS (
+						SELECT 1 FROM payments o
+						WHERE o.delivery_request_id = p.delivery_request_id AND o.id <> p.id
+							AND o.status IN ('pending', 'success')
+					) THEN $2::payment_status
+					ELSE p.status
+				END AS status
+			FROM payments p
+			WHERE p.reference = $1
+		)
+		UPDATE payments p
+		SET status = target.status,
+			failure_reason = CASE WHEN target.status = $2::payment_status THEN $3 ELSE p.failure_reason END,
+			ledger_transaction_id = $4, paid_at = $5
+		FROM target
+		WHERE p.id = target.id AND (p.status = 'pending' OR (
+			p.status IN ('failed', 'abandoned') AND $2::payment_status = 'success' AND p.ledger_transaction_id IS NULL))
+		RETURNING p.status, p.updated_at`
+
+	err := r.db.QueryRowNamed(ctx, "payments.Finish", query,
+		payment.Reference, payment.Status, payment.FailureReason, payment.LedgerTransactionID, payment.PaidAt,
+	).Scan(&payment.Status, &payment.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to finish payment: %w", err)
+	}
+	return true, nil
+}
+
+func (r *paymentRepository) ListPending(ctx context.Context, before time.Time, limit int) ([]*models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE status = 'pending' AND created_at < $1
+		ORDER BY created_at
+		LIMIT $2`
+
+	rows, err := r.db.QueryNamed(ctx, "payments.ListPending", query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []*models.Payment{}
+	for rows.Next() {
+		payment := &models.Payment{}
+		if err := scanPayment(rows, payment); err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payments: %w", err)
+	}
+	return payments, nil
+}
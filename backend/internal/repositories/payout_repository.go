@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type PayoutRepository interface {
+	// Create records a pending payout. It fails if the user's wallet does not
+	// cover the amount on top of their other pending payouts.
+	Create(ctx context.Context, payout *models.Payout) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Payout, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Payout, error)
+	// List returns payouts with the given status, or all of them if status is
+	// empty, oldest first so the review queue is worked in order.
+	List(ctx context.Context, status models.PayoutStatus, limit, offset int) ([]*models.Payout, int, error)
+	// Review records an admin's decision on a pending payout. It fails with a
+	// conflict if the payout was already reviewed.
+	Review(ctx context.Context, payout *models.Payout) error
+}
+
+type payoutRepository struct {
+	db *database.DB
+}
+
+func NewPayoutRepository(db *database.DB) PayoutRepository {
+	return &payoutRepository{db: db}
+}
+
+const payoutColumns = `p.id, p.user_id, p.amount, p.network, p.phone_number, p.status,
+	p.transfer_reference, p.rejection_reason, p.ledger_transaction_id, p.reviewed_by,
+	p.reviewed_at, p.created_at, p.updated_at, u.first_name, u.last_name`
+
+func scanPayout(row rowScanner, p *models.Payout) error {
+	var firstName, lastName string
+	err := row.Scan(&p.ID, &p.UserID, &p.Amount, &p.Network, &p.PhoneNumber, &p.Status,
+		&p.TransferReference, &p.RejectionReason, &p.LedgerTransactionID, &p.ReviewedBy,
+		&p.ReviewedAt, &p.CreatedAt, &p.UpdatedAt, &firstName, &lastName)
+	if err != nil {
+		return err
+	}
+	p.RequesterName = fmt.Sprintf("%s %s", firstName, lastName)
+	return nil
+}
+
+func (r *payoutRepository) Create(ctx context.Context, payout *models.Payout) error {
+	return r.db.InTx(ctx, func(ctx context.Context) error {
+		// Locking the wallet serializes the user's payout requests, so two
+		// cannot both be checked against the same balance
+		var balance models.Pesewas
+		err := r.db.QueryRowNamed(ctx, "payouts.Create.lock",
+			`SELECT balance FROM ledger_accounts WHERE type = 'wallet' AND user_id = $1 FOR UPDATE`,
+			payout.UserID).Scan(&balance)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to lock wallet: %w", err)
+		}
+
+		var requested models.Pesewas
+		err = r.db.QueryRowNamed(ctx, "payouts.Create.pending",
+			`SELECT COALESCE(SUM(amount), 0) FROM payouts WHERE user_id = $1 AND status = 'pending'`,
+			payout.UserID).Scan(&requested)
+		if err != nil {
+			return fmt.Errorf("failed to sum pending payouts: %w", err)
+		}
+		if available := balance - requested; payout.Amount > available {
+			return apperrors.InvalidInput(apperrors.CodeInsufficientBalance,
+				fmt.Sprintf("Only %s is available to pay out", max(available, 0)))
+		}
+
+		query := `
+			INSERT INTO payouts (id, user_id, amount, network, phone_number, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING created_at, updated_at`
+		err = r.db.QueryRowNamed(ctx, "payouts.Create", query,
+			payout.ID, payout.UserID, payout.Amount, payout.Network, payout.PhoneNumber, payout.Status,
+		).Scan(&payout.CreatedAt, &payout.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create payout: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *payoutRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Payout, error) {
+	payout := &models.Payout{}
+	query := `SELECT ` + payoutColumns + ` FROM payouts p JOIN users u ON u.id = p.user_id WHERE p.id = $1`
+	if err := scanPayout(r.db.QueryRowNamed(ctx, "payouts.GetByID", query, id), payout); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodePayoutNotFound, "Payout not found")
+		}
+		return nil, fmt.Errorf("failed to get payout: %w", err)
+	}
+	return payout, nil
+}
+
+func (r *payoutRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Payout, error) {
+	query := `
+		SELECT ` + payoutColumns + `
+		FROM payouts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.user_id = $1
+		ORDER BY p.created_at DESC`
+
+	rows, err := r.db.QueryNamed(ctx, "payouts.GetByUserID", query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user payouts: %w", err)
+	}
+	defer rows.Close()
+
+	payouts := []*models.Payout{}
+	for rows.Next() {
+		payout := &models.Payout{}
+		if err := scanPayout(rows, payout); err != nil {
+			return nil, fmt.Errorf("failed to scan payout: %w", err)
+		}
+		payouts = append(payouts, payout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payouts: %w", err)
+	}
+	return payouts, nil
+}
+
+func (r *payoutRepository) List(ctx context.Context, status models.PayoutStatus, limit, offset int) ([]*models.Payout, int, error) {
+	query := `
+		SELECT ` + payoutColumns + `
+		FROM payouts p
+		JOIN users u ON u.id = p.user_id
+		WHERE $1 = '' OR p.status::text = $1
+		ORDER BY p.created_at
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryNamed(ctx, "payouts.List", query, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list payouts: %w", err)
+	}
+	defer rows.Close()
+
+	payouts := []*models.Payout{}
+	for rows.Next() {
+		payout := &models.Payout{}
+		if err := scanPayout(rows, payout); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan payout: %w", err)
+		}
+		payouts = append(payouts, payout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating payouts: %w", err)
+	}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM payouts WHERE $1 = '' OR status::text = $1`
+	if err := r.db.QueryRowNamed(ctx, "payouts.List.count", countQuery, status).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	return payouts, totalCount, nil
+}
+
+func (r *payoutRepository) Review(ctx context.Context, payout *models.Payout) error {
+	query := `
+		UPDATE payouts
+		SET status = $2, transfer_reference = $3, rejection_reason = $4,
+			ledger_transaction_id = $5, reviewed_by = $6, reviewed_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING reviewed_at, updated_at`
+
+	err := r.db.QueryRowNamed(ctx, "payouts.Review", query,
+		payout.ID, payout.Status, payout.TransferReference, payout.RejectionReason,
+		payout.LedgerTransactionID, payout.ReviewedBy,
+	).Scan(&payout.ReviewedAt, &payout.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.Conflict(apperrors.CodePayoutReviewed, "Payout has already been reviewed")
+		}
+		return fmt.Errorf("failed to review payout: %w", err)
+	}
+	return nil
+}
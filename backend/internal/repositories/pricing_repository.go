@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+)
+
+type PricingRepository interface {
+	// ListSamples returns deliveries completed since the given time that
+	// start or end at either location, compared case-insensitively, most
+	// recent first.
+	ListSamples(ctx context.Context, pickup, dropoff string, since time.Time, limit int) ([]*models.PriceSample, error)
+	CreateFlag(ctx context.Context, flag *models.PriceFlag) error
+	ListFlags(ctx context.Context, limit, offset int) ([]*models.PriceFlag, int, error)
+}
+
+type pricingRepository struct {
+	db *database.DB
+}
+
+func NewPricingRepository(db *database.DB) PricingRepository {
+	return &pricingRepository{db: db}
+}
+
+func (r *pricingRepository) ListSamples(ctx context.Context, pickup, dropoff string, since time.Time, limit int) ([]*models.PriceSample, error) {
+	query := `
+		SELECT pickup_location, dropoff_location, item_size, priority, payment_amount,
+			   EXTRACT(EPOCH FROM pickup_date - created_at)::BIGINT
+		FROM delivery_requests
+		WHERE status = 'delivered' AND updated_at >= $3
+			AND (lower(btrim(pickup_location)) IN (lower(btrim($1)), lower(btrim($2)))
+				OR lower(btrim(dropoff_location)) IN (lower(btrim($1)), lower(btrim($2))))
+		ORDER BY updated_at DESC
+		LIMIT $4`
+
+	rows, err := r.db.QueryNamed(ctx, "pricing.ListSamples", query, pickup, dropoff, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price samples: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []*models.PriceSample
+	for rows.Next() {
+		s := &models.PriceSample{}
+		var leadSeconds int64
+		err := rows.Scan(&s.PickupLocation, &s.DropoffLocation, &s.ItemSize, &s.Priority, &s.PaymentAmount, &leadSeconds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price sample: %w", err)
+		}
+		s.LeadTime = time.Duration(leadSeconds) * time.Second
+		samples = append(samples, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price samples: %w", err)
+	}
+	return samples, nil
+}
+
+func (r *pricingRepository) CreateFlag(ctx context.Context, flag *models.PriceFlag) error {
+	query := `
+		INSERT INTO price_flags (
+			id, subject_type, subject_id, user_id, amount, outlier,
+			suggested_low, suggested_median, suggested_high, basis
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at`
+
+	err := r.db.QueryRowNamed(ctx, "pricing.CreateFlag", query,
+		flag.ID, flag.SubjectType, flag.SubjectID, flag.UserID, flag.Amount, flag.Outlier,
+		flag.Low, flag.Median, flag.High, flag.Basis,
+	).Scan(&flag.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create price flag: %w", err)
+	}
+	return nil
+}
+
+func (r *pricingRepository) ListFlags(ctx context.Context, limit, offset int) ([]*models.PriceFlag, int, error) {
+	query := `
+		SELECT id, subject_type, subject_id, user_id, amount, outlier,
+			   suggested_low, suggested_median, suggested_high, basis, created_at
+		FROM price_flags
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.QueryNamed(ctx, "pricing.ListFlags", query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list price flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []*models.PriceFlag{}
+	for rows.Next() {
+		f := &models.PriceFlag{}
+		err := rows.Scan(&f.ID, &f.SubjectType, &f.SubjectID, &f.UserID, &f.Amount, &f.Outlier,
+			&f.Low, &f.Median, &f.High, &f.Basis, &f.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan price flag: %w", err)
+		}
+		flags = append(flags, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating price flags: %w", err)
+	}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM price_flags`
+	if err := r.db.QueryRowNamed(ctx, "pricing.ListFlags.count", countQuery).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	return flags, totalCount, nil
+}
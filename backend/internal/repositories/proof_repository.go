@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type ProofRepository interface {
+	// IssueHandoverCode stores the delivery request's handover code,
+	// replacing any earlier one, so a request matched again gets a fresh code.
+	IssueHandoverCode(ctx context.Context, requestID uuid.UUID, code string) error
+	// GetHandoverCode returns the delivery request's unused handover code.
+	GetHandoverCode(ctx context.Context, requestID uuid.UUID) (*models.HandoverCode, error)
+	DeleteHandoverCode(ctx context.Context, requestID uuid.UUID) error
+	// CheckHandoverCode compares code with the delivery request's handover
+	// code, counting a wrong one as a failed attempt. The count is kept even
+	// if the caller's transaction rolls back, so it must be called outside
+	// one. It fails with HANDOVER_CODE_LOCKED once too many attempts failed.
+	CheckHandoverCode(ctx context.Context, requestID uuid.UUID, code string) error
+	// UseHandoverCode marks the handover code used. It fails with a conflict
+	// if it already was.
+	UseHandoverCode(ctx context.Context, requestID uuid.UUID) error
+	CreateProof(ctx context.Context, proof *models.DeliveryProof) error
+	// ListProofs returns the delivery request's proofs, oldest first.
+	ListProofs(ctx context.Context, requestID uuid.UUID) ([]*models.DeliveryProof, error)
+}
+
+type proofRepository struct {
+	db *database.DB
+}
+
+func NewProofRepository(db *database.DB) ProofRepository {
+	return &proofRepository{db: db}
+}
+
+var (
+	errHandoverCodeNotFound = apperrors.NotFound(apperrors.CodeHandoverCodeNotFound, "Delivery request has no unused handover code")
+	errHandoverCodeLocked   = apperrors.Conflict(apperrors.CodeHandoverCodeLocked,
+		"Too many incorrect handover codes; the requester must confirm delivery")
+)
+
+func (r *proofRepository) IssueHandoverCode(ctx context.Context, requestID uuid.UUID, code string) error {
+	query := `
+		INSERT INTO handover_codes (delivery_request_id, code)
+		VALUES ($1, $2)
+		ON CONFLICT (delivery_request_id) DO UPDATE
+		SET code = EXCLUDED.code, failed_attempts = 0, used_at = NULL, created_at = CURRENT_TIMESTAMP`
+
+	if _, err := r.db.ExecNamed(ctx, "proofs.IssueHandoverCode", query, requestID, code); err != nil {
+		return fmt.Errorf("failed to issue handover code: %w", err)
+	}
+	return nil
+}
+
+func (r *proofRepository) GetHandoverCode(ctx context.Context, requestID uuid.UUID) (*models.HandoverCode, error) {
+	query := `
+		SELECT delivery_request_id, code, failed_attempts, used_at, created_at
+		FROM handover_codes
+		WHERE delivery_request_id = $1 AND used_at IS NULL`
+
+	c := &models.HandoverCode{}
+	err := r.db.QueryRowNamed(ctx, "proofs.GetHandoverCode", query, requestID).
+		Scan(&c.DeliveryRequestID, &c.Code, &c.FailedAttempts, &c.UsedAt, &c.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errHandoverCodeNotFound
+		}
+		return nil, fmt.Errorf("failed to get handover code: %w", err)
+	}
+	return c, nil
+}
+
+func (r *proofRepository) DeleteHandoverCode(ctx context.Context, requestID uuid.UUID) error {
+	_, err := r.db.ExecNamed(ctx, "proofs.DeleteHandoverCode",
+		`DELETE FROM handover_codes WHERE delivery_request_id = $1`, requestID)
+	if err != nil {
+		return fmt.Errorf("failed to delete handover code: %w", err)
+	}
+	return nil
+}
+
+func (r *proofRepository) CheckHandoverCode(ctx context.Context, requestID uuid.UUID, code string) error {
+	// Checking and counting in one statement keeps concurrent guesses from
+	// getting past the attempt limit
+	query := `
+		UPDATE handover_codes
+		SET failed_attempts = failed_attempts + CASE WHEN code = $2 THEN 0 ELSE 1 END
+		WHERE delivery_request_id = $1 AND used_at IS NULL AND failed_attempts < $3
+		RETURNING code = $2, failed_attempts`
+
+	var matched bool
+	var failed int
+	err := r.db.QueryRowNamed(ctx, "proofs.CheckHandoverCode", query, requestID, code, models.MaxHandoverCodeAttempts).
+		Scan(&matched, &failed)
+	if err == sql.ErrNoRows {
+		// Either there is no unused code or it is already locked
+		if _, err := r.GetHandoverCode(ctx, requestID); err != nil {
+			return err
+		}
+		return errHandoverCodeLocked
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check handover code: %w", err)
+	}
+
+	if !matched {
+		if failed >= models.MaxHandoverCodeAttempts {
+			return errHandoverCodeLocked
+		}
+		return apperrors.InvalidInput(apperrors.CodeInvalidHandoverCode,
+			fmt.Sprintf("Incorrect handover code; %d attempts left", models.MaxHandoverCodeAttempts-failed))
+	}
+	return nil
+}
+
+func (r *proofRepository) UseHandoverCode(ctx context.Context, requestID uuid.UUID) error {
+	result, err := r.db.ExecNamed(ctx, "proofs.UseHandoverCode",
+		`UPDATE handover_codes SET used_at = CURRENT_TIMESTAMP WHERE delivery_request_id = $1 AND used_at IS NULL`,
+		requestID)
+	if err != nil {
+		return fmt.Errorf("failed to use handover code: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return apperrors.Conflict(apperrors.CodeHandoverCodeNotFound, "Handover code was already used")
+	}
+	return nil
+}
+
+func (r *proofRepository) CreateProof(ctx context.Context, proof *models.DeliveryProof) error {
+	if proof.ID == uuid.Nil {
+		proof.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO delivery_proofs (id, delivery_request_id, kind, submitted_by, storage_key, content_type,
+			latitude, longitude, accuracy, captured_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at`
+
+	err := r.db.QueryRowNamed(ctx, "proofs.CreateProof", query,
+		proof.ID, proof.DeliveryRequestID, proof.Kind, proof.SubmittedBy, proof.StorageKey, proof.ContentType,
+		proof.Latitude, proof.Longitude, proof.Accuracy, proof.CapturedAt,
+	).Scan(&proof.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create delivery proof: %w", err)
+	}
+	return nil
+}
+
+func (r *proofRepository) ListProofs(ctx context.Context, requestID uuid.UUID) ([]*models.DeliveryProof, error) {
+	query := `
+		SELECT p.id, p.delivery_request_id, p.kind, p.submitted_by, p.storage_key, p.content_type,
+			p.latitude, p.longitude, p.accuracy, p.captured_at, p.created_at, u.first_name, u.last_name
+		FROM delivery_proofs p
+		JOIN users u ON u.id = p.submitted_by
+		WHERE p.delivery_request_id = $1
+		ORDER BY p.created_at, p.id`
+
+	rows, err := r.db.QueryNamed(ctx, "proofs.ListProofs", query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delivery proofs: %w", err)
+	}
+	defer rows.Close()
+
+	proofs := []*models.DeliveryProof{}
+	for rows.Next() {
+		p := &models.DeliveryProof{}
+		var firstName, lastName string
+		err := rows.Scan(&p.ID, &p.DeliveryRequestID, &p.Kind, &p.SubmittedBy, &p.StorageKey, &p.ContentType,
+			&p.Latitude, &p.Longitude, &p.Accuracy, &p.CapturedAt, &p.CreatedAt, &firstName, &lastName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery proof: %w", err)
+		}
+		p.SubmitterName = fmt.Sprintf("%s %s", firstName, lastName)
+		proofs = append(proofs, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating delivery proofs: %w", err)
+	}
+	return proofs, nil
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/models"
+)
+
+func TestCheckHandoverCode(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	proofs := NewProofRepository(db)
+
+	tests := []struct {
+		name      string
+		wrong     int
+		code      string
+		wantCode  apperrors.Code
+		wantTries int
+	}{
+		{name: "right code", code: "123456", wantTries: 0},
+		{name: "wrong code", code: "654321", wantCode: apperrors.CodeInvalidHandoverCode, wantTries: 1},
+		{name: "right code after wrong ones", wrong: models.MaxHandoverCodeAttempts - 1, code: "123456",
+			wantTries: models.MaxHandoverCodeAttempts - 1},
+		{name: "last wrong code locks", wrong: models.MaxHandoverCodeAttempts - 1, code: "654321",
+			wantCode: apperrors.CodeHandoverCodeLocked, wantTries: models.MaxHandoverCodeAttempts},
+		{name: "right code once locked", wrong: models.MaxHandoverCodeAttempts, code: "123456",
+			wantCode: apperrors.CodeHandoverCodeLocked, wantTries: models.MaxHandoverCodeAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := createTestDelivery(t, db, createTestUser(t, db), models.DeliveryInTransit)
+			if err := proofs.IssueHandoverCode(ctx, request, "123456"); err != nil {
+				t.Fatalf("IssueHandoverCode() error = %v", err)
+			}
+			for i := 0; i < tt.wrong; i++ {
+				if err := proofs.CheckHandoverCode(ctx, request, "000000"); err == nil {
+					t.Fatalf("CheckHandoverCode() accepted a wrong code")
+				}
+			}
+
+			err := proofs.CheckHandoverCode(ctx, request, tt.code)
+			if tt.wantCode != "" {
+				if !apperrors.HasCode(err, tt.wantCode) {
+					t.Errorf("CheckHandoverCode() error = %v, want %s", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Errorf("CheckHandoverCode() error = %v", err)
+			}
+
+			code, err := proofs.GetHandoverCode(ctx, request)
+			if err != nil {
+				t.Fatalf("GetHandoverCode() error = %v", err)
+			}
+			if code.FailedAttempts != tt.wantTries {
+				t.Errorf("failed attempts = %d, want %d", code.FailedAttempts, tt.wantTries)
+			}
+		})
+	}
+}
+
+func TestIssueHandoverCodeResetsAttempts(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	proofs := NewProofRepository(db)
+
+	request := createTestDelivery(t, db, createTestUser(t, db), models.DeliveryInTransit)
+	if err := proofs.IssueHandoverCode(ctx, request, "123456"); err != nil {
+		t.Fatalf("IssueHandoverCode() error = %v", err)
+	}
+	for i := 0; i < models.MaxHandoverCodeAttempts; i++ {
+		proofs.CheckHandoverCode(ctx, request, "000000")
+	}
+	if err := proofs.IssueHandoverCode(ctx, request, "246810"); err != nil {
+		t.Fatalf("IssueHandoverCode() error = %v", err)
+	}
+
+	if err := proofs.CheckHandoverCode(ctx, request, "123456"); !apperrors.HasCode(err, apperrors.CodeInvalidHandoverCode) {
+		t.Errorf("CheckHandoverCode(old code) error = %v, want %s", err, apperrors.CodeInvalidHandoverCode)
+	}
+	if err := proofs.CheckHandoverCode(ctx, request, "246810"); err != nil {
+		t.Errorf("CheckHandoverCode(new code) error = %v", err)
+	}
+	if err := proofs.UseHandoverCode(ctx, request); err != nil {
+		t.Fatalf("UseHandoverCode() error = %v", err)
+	}
+	if err := proofs.CheckHandoverCode(ctx, request, "246810"); !apperrors.HasCode(err, apperrors.CodeHandoverCodeNotFound) {
+		t.Errorf("CheckHandoverCode(used code) error = %v, want %s", err, apperrors.CodeHandoverCodeNotFound)
+	}
+}
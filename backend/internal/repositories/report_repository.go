@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/database"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ReportRepository interface {
+	// Create records an open report. It fails with a conflict if the
+	// reporter already has an open report about the target.
+	Create(ctx context.Context, report *models.Report) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Report, error)
+	// ListByReporter returns the user's reports, newest first.
+	ListByReporter(ctx context.Context, reporterID uuid.UUID, limit, offset int) ([]*models.Report, int, error)
+	// List returns the moderation queue, oldest first, with how often each
+	// reported user has been reported.
+	List(ctx context.Context, filter models.ReportFilter, limit, offset int) ([]*models.Report, int, error)
+	// Review records an admin's decision on an open report. It fails with
+	// REPORT_ALREADY_REVIEWED if the report is no longer open.
+	Review(ctx context.Context, report *models.Report) error
+}
+
+type reportRepository struct {
+	db *database.DB
+}
+
+func NewReportRepository(db *database.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+const reportColumns = `rp.id, rp.reporter_id, rp.target_type, rp.target_id, rp.reported_user_id, rp.reason,
+	rp.details, rp.status, rp.review_note, rp.reviewed_by, rp.reviewed_at, rp.created_at, rp.updated_at,
+	re.first_name, re.last_name, ru.first_name, ru.last_name`
+
+const reportJoins = `
+	JOIN users re ON re.id = rp.reporter_id
+	JOIN users ru ON ru.id = rp.reported_user_id`
+
+func scanReport(row rowScanner, extra ...interface{}) (*models.Report, error) {
+	rp := &models.Report{}
+	var reporterFirst, reporterLast, reportedFirst, reportedLast string
+	dest := []interface{}{&rp.ID, &rp.ReporterID, &rp.TargetType, &rp.TargetID, &rp.ReportedUserID, &rp.Reason,
+		&rp.Details, &rp.Status, &rp.ReviewNote, &rp.ReviewedBy, &rp.ReviewedAt, &rp.CreatedAt, &rp.UpdatedAt,
+		&reporterFirst, &reporterLast, &reportedFirst, &reportedLast}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	rp.ReporterName = fmt.Sprintf("%s %s", reporterFirst, reporterLast)
+	rp.ReportedUserName = fmt.Sprintf("%s %s", reportedFirst, reportedLast)
+	return rp, nil
+}
+
+func (r *reportRepository) Create(ctx context.Context, report *models.Report) error {
+	if report.ID == uuid.Nil {
+		report.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO reports (id, reporter_id, target_type, target_id, reported_user_id, reason, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING status, created_at, updated_at`
+
+	err := r.db.QueryRowNamed(ctx, "reports.Create", query,
+		report.ID, report.ReporterID, report.TargetType, report.TargetID, report.ReportedUserID,
+		report.Reason, report.Details,
+	).Scan(&report.Status, &report.CreatedAt, &report.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_reports_open" {
+			return apperrors.Conflict(apperrors.CodeReportExists, "You have already reported this")
+		}
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	return nil
+}
+
+func (r *reportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports rp` + reportJoins + ` WHERE rp.id = $1`
+
+	report, err := scanReport(r.db.QueryRowNamed(ctx, "reports.GetByID", query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodeReportNotFound, "Report not found")
+		}
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+	return report, nil
+}
+
+func (r *reportRepository) ListByReporter(ctx context.Context, reporterID uuid.UUID, limit, offset int) ([]*models.Report, int, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports rp` + reportJoins + `
+		WHERE rp.reporter_id = $1
+		ORDER BY rp.created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryNamed(ctx, "reports.ListByReporter", query, reporterID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []*models.Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating reports: %w", err)
+	}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM reports WHERE reporter_id = $1`
+	if err := r.db.QueryRowNamed(ctx, "reports.ListByReporter.count", countQuery, reporterID).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	return reports, totalCount, nil
+}
+
+func (r *reportRepository) List(ctx context.Context, filter models.ReportFilter, limit, offset int) ([]*models.Report, int, error) {
+	query := `
+		SELECT ` + reportColumns + `,
+			(SELECT COUNT(*) FROM reports o WHERE o.reported_user_id = rp.reported_user_id)
+		FROM reports rp` + reportJoins + `
+		WHERE ($1 = '' OR rp.status::TEXT = $1) AND ($2 = '' OR rp.target_type::TEXT = $2)
+		ORDER BY rp.created_at
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryNamed(ctx, "reports.List", query, filter.Status, filter.TargetType, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []*models.Report{}
+	for rows.Next() {
+		var count int
+		report, err := scanReport(rows, &count)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan report: %w", err)
+		}
+		report.ReportCount = count
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating reports: %w", err)
+	}
+
+	var totalCount int
+	countQuery := `
+		SELECT COUNT(*) FROM reports
+		WHERE ($1 = '' OR status::TEXT = $1) AND ($2 = '' OR target_type::TEXT = $2)`
+	err = r.db.QueryRowNamed(ctx, "reports.List.count", countQuery, filter.Status, filter.TargetType).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	return reports, totalCount, nil
+}
+
+func (r *reportRepository) Review(ctx context.Context, report *models.Report) error {
+	query := `
+		UPDATE reports
+		SET status = $2, review_note = $3, reviewed_by = $4, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'open'
+		RETURNING reviewed_at, updated_at`
+
+	err := r.db.QueryRowNamed(ctx, "reports.Review", query,
+		report.ID, report.Status, report.ReviewNote, report.ReviewedBy,
+	).Scan(&report.ReviewedAt, &report.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.Conflict(apperrors.CodeReportReviewed, "Report was already reviewed")
+		}
+		return fmt.Errorf("failed to review report: %w", err)
+	}
+	return nil
+}
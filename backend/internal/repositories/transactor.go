@@ -0,0 +1,9 @@
+package repositories
+
+import "context"
+
+// Transactor runs fn in a database transaction. Repository calls made with
+// the context passed to fn join the transaction. *database.DB implements it.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -1,9 +1,11 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
+	"campus-connect/internal/apperrors"
 	"campus-connect/internal/database"
 	"campus-connect/internal/models"
 
@@ -11,17 +13,23 @@ import (
 )
 
 type TripRepository interface {
-	Create(trip *models.Trip) error
-	GetByID(id uuid.UUID) (*models.Trip, error)
-	GetActiveTrips(limit, offset int) ([]*models.Trip, int, error)
-	GetByTravelerID(travelerID uuid.UUID) ([]*models.Trip, error)
-	Update(trip *models.Trip) error
-	AddParticipant(tripID, userID uuid.UUID) error
-	RemoveParticipant(tripID, userID uuid.UUID) error
-	AddDeliveryRequest(tripID, requestID uuid.UUID) error
-	RemoveDeliveryRequest(tripID, requestID uuid.UUID) error
-	GetParticipants(tripID uuid.UUID) ([]*models.User, error)
-	GetMatchedRequests(tripID uuid.UUID) ([]*models.DeliveryRequest, error)
+	Create(ctx context.Context, trip *models.Trip) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Trip, error)
+	// GetActiveTrips returns active trips, newest first. With a viewer, trips
+	// of users who blocked or were blocked by the viewer are left out.
+	GetActiveTrips(ctx context.Context, viewerID *uuid.UUID, limit, offset int) ([]*models.Trip, int, error)
+	// SearchNearby returns active trips leaving from near the search's origin
+	// and, for a corridor, going to near its destination, with their
+	// distances.
+	SearchNearby(ctx context.Context, search models.GeoSearch, limit, offset int) ([]*models.Trip, int, error)
+	GetByTravelerID(ctx context.Context, travelerID uuid.UUID) ([]*models.Trip, error)
+	Update(ctx context.Context, trip *models.Trip) error
+	AddParticipant(ctx context.Context, tripID, userID uuid.UUID) error
+	RemoveParticipant(ctx context.Context, tripID, userID uuid.UUID) error
+	AddDeliveryRequest(ctx context.Context, tripID, requestID uuid.UUID) error
+	RemoveDeliveryRequest(ctx context.Context, tripID, requestID uuid.UUID) error
+	GetParticipants(ctx context.Context, tripID uuid.UUID) ([]*models.User, error)
+	GetMatchedRequests(ctx context.Context, tripID uuid.UUID) ([]*models.DeliveryRequest, error)
 }
 
 type tripRepository struct {
@@ -32,21 +40,24 @@ func NewTripRepository(db *database.DB) TripRepository {
 	return &tripRepository{db: db}
 }
 
-func (r *tripRepository) Create(trip *models.Trip) error {
+func (r *tripRepository) Create(ctx context.Context, trip *models.Trip) error {
 	query := `
 		INSERT INTO trips (
 			id, traveler_id, from_location, to_location, departure_time,
 			transport_method, max_deliveries, current_deliveries, price_per_delivery,
-			is_recurring, status, description, contact_info
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			is_recurring, status, description, contact_info, from_location_id, to_location_id,
+			from_latitude, from_longitude, to_latitude, to_longitude
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		RETURNING created_at, updated_at`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowNamed(ctx, "trips.Create",
 		query,
 		trip.ID, trip.TravelerID, trip.FromLocation, trip.ToLocation,
 		trip.DepartureTime, trip.TransportMethod, trip.MaxDeliveries,
 		trip.CurrentDeliveries, trip.PricePerDelivery, trip.IsRecurring,
 		trip.Status, trip.Description, trip.ContactInfo,
+		trip.FromLocationID, trip.ToLocationID,
+		trip.FromLatitude, trip.FromLongitude, trip.ToLatitude, trip.ToLongitude,
 	).Scan(&trip.CreatedAt, &trip.UpdatedAt)
 
 	if err != nil {
@@ -56,11 +67,13 @@ func (r *tripRepository) Create(trip *models.Trip) error {
 	return nil
 }
 
-func (r *tripRepository) GetByID(id uuid.UUID) (*models.Trip, error) {
+func (r *tripRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Trip, error) {
 	trip := &models.Trip{}
 	query := `
-		SELECT t.id, t.traveler_id, t.from_location, t.to_location, t.departure_time,
-			   t.transport_method, t.max_deliveries, t.current_deliveries, 
+		SELECT t.id, t.traveler_id, t.from_location, t.to_location,
+			   t.from_location_id, t.to_location_id,
+			   t.from_latitude, t.from_longitude, t.to_latitude, t.to_longitude,
+			   t.departure_time, t.transport_method, t.max_deliveries, t.current_deliveries, 
 			   t.price_per_delivery, t.is_recurring, t.status, t.description,
 			   t.contact_info, t.created_at, t.updated_at,
 			   u.first_name, u.last_name, u.email, u.student_id
@@ -69,8 +82,10 @@ func (r *tripRepository) GetByID(id uuid.UUID) (*models.Trip, error) {
 		WHERE t.id = $1`
 
 	traveler := &models.User{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowNamed(ctx, "trips.GetByID", query, id).Scan(
 		&trip.ID, &trip.TravelerID, &trip.FromLocation, &trip.ToLocation,
+		&trip.FromLocationID, &trip.ToLocationID,
+		&trip.FromLatitude, &trip.FromLongitude, &trip.ToLatitude, &trip.ToLongitude,
 		&trip.DepartureTime, &trip.TransportMethod, &trip.MaxDeliveries,
 		&trip.CurrentDeliveries, &trip.PricePerDelivery, &trip.IsRecurring,
 		&trip.Status, &trip.Description, &trip.ContactInfo,
@@ -80,7 +95,7 @@ func (r *tripRepository) GetByID(id uuid.UUID) (*models.Trip, error) {
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("trip not found")
+			return nil, apperrors.NotFound(apperrors.CodeTripNotFound, "Trip not found")
 		}
 		return nil, fmt.Errorf("failed to get trip: %w", err)
 	}
@@ -92,20 +107,22 @@ func (r *tripRepository) GetByID(id uuid.UUID) (*models.Trip, error) {
 	return trip, nil
 }
 
-func (r *tripRepository) GetActiveTrips(limit, offset int) ([]*models.Trip, int, error) {
+func (r *tripRepository) GetActiveTrips(ctx context.Context, viewerID *uuid.UUID, limit, offset int) ([]*models.Trip, int, error) {
 	query := `
-		SELECT t.id, t.traveler_id, t.from_location, t.to_location, t.departure_time,
-			   t.transport_method, t.max_deliveries, t.current_deliveries, 
+		SELECT t.id, t.traveler_id, t.from_location, t.to_location,
+			   t.from_location_id, t.to_location_id,
+			   t.from_latitude, t.from_longitude, t.to_latitude, t.to_longitude,
+			   t.departure_time, t.transport_method, t.max_deliveries, t.current_deliveries, 
 			   t.price_per_delivery, t.is_recurring, t.status, t.description,
 			   t.contact_info, t.created_at, t.updated_at,
 			   u.first_name, u.last_name, u.email, u.student_id
 		FROM trips t
 		JOIN users u ON t.traveler_id = u.id
-		WHERE t.status = 'active'
+		WHERE t.status = 'active' AND ` + notBlocked("t.traveler_id", "$1") + `
 		ORDER BY t.created_at DESC
-		LIMIT $1 OFFSET $2`
+		LIMIT $2 OFFSET $3`
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.QueryNamed(ctx, "trips.GetActiveTrips", query, viewerID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get active trips: %w", err)
 	}
@@ -118,6 +135,8 @@ func (r *tripRepository) GetActiveTrips(limit, offset int) ([]*models.Trip, int,
 
 		err := rows.Scan(
 			&trip.ID, &trip.TravelerID, &trip.FromLocation, &trip.ToLocation,
+			&trip.FromLocationID, &trip.ToLocationID,
+			&trip.FromLatitude, &trip.FromLongitude, &trip.ToLatitude, &trip.ToLongitude,
 			&trip.DepartureTime, &trip.TransportMethod, &trip.MaxDeliveries,
 			&trip.CurrentDeliveries, &trip.PricePerDelivery, &trip.IsRecurring,
 			&trip.Status, &trip.Description, &trip.ContactInfo,
@@ -137,26 +156,103 @@ func (r *tripRepository) GetActiveTrips(limit, offset int) ([]*models.Trip, int,
 
 	// Get total count
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM trips WHERE status = 'active'`
-	err = r.db.QueryRow(countQuery).Scan(&totalCount)
+	countQuery := `SELECT COUNT(*) FROM trips t WHERE t.status = 'active' AND ` + notBlocked("t.traveler_id", "$1")
+	err = r.db.QueryRowNamed(ctx, "trips.GetActiveTrips.count", countQuery, viewerID).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	return trips, totalCount, nil
+}
+
+// tripGeoFilter limits a proximity search to active trips within the
+// radius that the viewer may see; see geoSearchArgs for its parameters.
+var tripGeoFilter = `
+		CROSS JOIN LATERAL (SELECT
+			haversine_meters($1, $2, t.from_latitude, t.from_longitude) AS distance,
+			haversine_meters($3, $4, t.to_latitude, t.to_longitude) AS destination_distance
+		) d
+		WHERE t.status = 'active'
+			AND t.from_latitude BETWEEN $6 AND $7
+			AND t.from_longitude BETWEEN $8 AND $9
+			AND d.distance <= $5
+			AND ($3::DOUBLE PRECISION IS NULL OR d.destination_distance <= $5)
+			AND ` + notBlocked("t.traveler_id", "$10")
+
+func (r *tripRepository) SearchNearby(ctx context.Context, search models.GeoSearch, limit, offset int) ([]*models.Trip, int, error) {
+	query := `
+		SELECT t.id, t.traveler_id, t.from_location, t.to_location,
+			   t.from_location_id, t.to_location_id,
+			   t.from_latitude, t.from_longitude, t.to_latitude, t.to_longitude,
+			   t.departure_time, t.transport_method, t.max_deliveries, t.current_deliveries,
+			   t.price_per_delivery, t.is_recurring, t.status, t.description,
+			   t.contact_info, t.created_at, t.updated_at,
+			   d.distance, d.destination_distance,
+			   u.first_name, u.last_name, u.email, u.student_id
+		FROM trips t
+		JOIN users u ON t.traveler_id = u.id` + tripGeoFilter + `
+		ORDER BY CASE WHEN $11 THEN d.distance + COALESCE(d.destination_distance, 0) END,
+			t.created_at DESC
+		LIMIT $12 OFFSET $13`
+
+	args := geoSearchArgs(search)
+	rows, err := r.db.QueryNamed(ctx, "trips.SearchNearby", query, append(args, search.SortByDistance, limit, offset)...)
 	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search nearby trips: %w", err)
+	}
+	defer rows.Close()
+
+	trips := []*models.Trip{}
+	for rows.Next() {
+		trip := &models.Trip{}
+		traveler := &models.User{}
+
+		err := rows.Scan(
+			&trip.ID, &trip.TravelerID, &trip.FromLocation, &trip.ToLocation,
+			&trip.FromLocationID, &trip.ToLocationID,
+			&trip.FromLatitude, &trip.FromLongitude, &trip.ToLatitude, &trip.ToLongitude,
+			&trip.DepartureTime, &trip.TransportMethod, &trip.MaxDeliveries,
+			&trip.CurrentDeliveries, &trip.PricePerDelivery, &trip.IsRecurring,
+			&trip.Status, &trip.Description, &trip.ContactInfo,
+			&trip.CreatedAt, &trip.UpdatedAt,
+			&trip.Distance, &trip.DestinationDistance,
+			&traveler.FirstName, &traveler.LastName, &traveler.Email, &traveler.StudentID,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan trip: %w", err)
+		}
+
+		traveler.ID = trip.TravelerID
+		trip.Traveler = traveler
+		trip.TravelerName = fmt.Sprintf("%s %s", traveler.FirstName, traveler.LastName)
+		trips = append(trips, trip)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating trips: %w", err)
+	}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM trips t` + tripGeoFilter
+	if err := r.db.QueryRowNamed(ctx, "trips.SearchNearby.count", countQuery, args...).Scan(&totalCount); err != nil {
 		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
 	}
 
 	return trips, totalCount, nil
 }
 
-func (r *tripRepository) GetByTravelerID(travelerID uuid.UUID) ([]*models.Trip, error) {
+func (r *tripRepository) GetByTravelerID(ctx context.Context, travelerID uuid.UUID) ([]*models.Trip, error) {
 	query := `
-		SELECT t.id, t.traveler_id, t.from_location, t.to_location, t.departure_time,
-			   t.transport_method, t.max_deliveries, t.current_deliveries, 
+		SELECT t.id, t.traveler_id, t.from_location, t.to_location,
+			   t.from_location_id, t.to_location_id,
+			   t.from_latitude, t.from_longitude, t.to_latitude, t.to_longitude,
+			   t.departure_time, t.transport_method, t.max_deliveries, t.current_deliveries, 
 			   t.price_per_delivery, t.is_recurring, t.status, t.description,
 			   t.contact_info, t.created_at, t.updated_at
 		FROM trips t
 		WHERE t.traveler_id = $1
 		ORDER BY t.created_at DESC`
 
-	rows, err := r.db.Query(query, travelerID)
+	rows, err := r.db.QueryNamed(ctx, "trips.GetByTravelerID", query, travelerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get traveler trips: %w", err)
 	}
@@ -168,6 +264,8 @@ func (r *tripRepository) GetByTravelerID(travelerID uuid.UUID) ([]*models.Trip,
 
 		err := rows.Scan(
 			&trip.ID, &trip.TravelerID, &trip.FromLocation, &trip.ToLocation,
+			&trip.FromLocationID, &trip.ToLocationID,
+			&trip.FromLatitude, &trip.FromLongitude, &trip.ToLatitude, &trip.ToLongitude,
 			&trip.DepartureTime, &trip.TransportMethod, &trip.MaxDeliveries,
 			&trip.CurrentDeliveries, &trip.PricePerDelivery, &trip.IsRecurring,
 			&trip.Status, &trip.Description, &trip.ContactInfo,
@@ -184,21 +282,24 @@ func (r *tripRepository) GetByTravelerID(travelerID uuid.UUID) ([]*models.Trip,
 	return trips, nil
 }
 
-func (r *tripRepository) Update(trip *models.Trip) error {
+func (r *tripRepository) Update(ctx context.Context, trip *models.Trip) error {
 	query := `
 		UPDATE trips 
 		SET from_location = $2, to_location = $3, departure_time = $4,
 			transport_method = $5, max_deliveries = $6, current_deliveries = $7,
 			price_per_delivery = $8, is_recurring = $9, status = $10,
-			description = $11, contact_info = $12
+			description = $11, contact_info = $12, from_location_id = $13,
+			to_location_id = $14, from_latitude = $15, from_longitude = $16,
+			to_latitude = $17, to_longitude = $18
 		WHERE id = $1`
 
-	_, err := r.db.Exec(
+	_, err := r.db.ExecNamed(ctx, "trips.Update",
 		query,
 		trip.ID, trip.FromLocation, trip.ToLocation, trip.DepartureTime,
 		trip.TransportMethod, trip.MaxDeliveries, trip.CurrentDeliveries,
 		trip.PricePerDelivery, trip.IsRecurring, trip.Status,
-		trip.Description, trip.ContactInfo,
+		trip.Description, trip.ContactInfo, trip.FromLocationID, trip.ToLocationID,
+		trip.FromLatitude, trip.FromLongitude, trip.ToLatitude, trip.ToLongitude,
 	)
 
 	if err != nil {
@@ -208,17 +309,17 @@ func (r *tripRepository) Update(trip *models.Trip) error {
 	return nil
 }
 
-func (r *tripRepository) AddParticipant(tripID, userID uuid.UUID) error {
+func (r *tripRepository) AddParticipant(ctx context.Context, tripID, userID uuid.UUID) error {
 	// First update the current_deliveries count
 	updateQuery := `UPDATE trips SET current_deliveries = current_deliveries + 1 WHERE id = $1`
-	_, err := r.db.Exec(updateQuery, tripID)
+	_, err := r.db.ExecNamed(ctx, "trips.AddParticipant.update", updateQuery, tripID)
 	if err != nil {
 		return fmt.Errorf("failed to update trip deliveries count: %w", err)
 	}
 
 	// Then add the participant
 	insertQuery := `INSERT INTO trip_participants (trip_id, user_id) VALUES ($1, $2)`
-	_, err = r.db.Exec(insertQuery, tripID, userID)
+	_, err = r.db.ExecNamed(ctx, "trips.AddParticipant.insert", insertQuery, tripID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to add trip participant: %w", err)
 	}
@@ -226,17 +327,17 @@ func (r *tripRepository) AddParticipant(tripID, userID uuid.UUID) error {
 	return nil
 }
 
-func (r *tripRepository) RemoveParticipant(tripID, userID uuid.UUID) error {
+func (r *tripRepository) RemoveParticipant(ctx context.Context, tripID, userID uuid.UUID) error {
 	// First remove the participant
 	deleteQuery := `DELETE FROM trip_participants WHERE trip_id = $1 AND user_id = $2`
-	_, err := r.db.Exec(deleteQuery, tripID, userID)
+	_, err := r.db.ExecNamed(ctx, "trips.RemoveParticipant.delete", deleteQuery, tripID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to remove trip participant: %w", err)
 	}
 
 	// Then update the current_deliveries count
 	updateQuery := `UPDATE trips SET current_deliveries = GREATEST(current_deliveries - 1, 0) WHERE id = $1`
-	_, err = r.db.Exec(updateQuery, tripID)
+	_, err = r.db.ExecNamed(ctx, "trips.RemoveParticipant.update", updateQuery, tripID)
 	if err != nil {
 		return fmt.Errorf("failed to update trip deliveries count: %w", err)
 	}
@@ -244,17 +345,17 @@ func (r *tripRepository) RemoveParticipant(tripID, userID uuid.UUID) error {
 	return nil
 }
 
-func (r *tripRepository) AddDeliveryRequest(tripID, requestID uuid.UUID) error {
+func (r *tripRepository) AddDeliveryRequest(ctx context.Context, tripID, requestID uuid.UUID) error {
 	// First update the current_deliveries count
 	updateQuery := `UPDATE trips SET current_deliveries = current_deliveries + 1 WHERE id = $1`
-	_, err := r.db.Exec(updateQuery, tripID)
+	_, err := r.db.ExecNamed(ctx, "trips.AddDeliveryRequest.update", updateQuery, tripID)
 	if err != nil {
 		return fmt.Errorf("failed to update trip deliveries count: %w", err)
 	}
 
 	// Then add the delivery request
 	insertQuery := `INSERT INTO trip_delivery_requests (trip_id, delivery_request_id) VALUES ($1, $2)`
-	_, err = r.db.Exec(insertQuery, tripID, requestID)
+	_, err = r.db.ExecNamed(ctx, "trips.AddDeliveryRequest.insert", insertQuery, tripID, requestID)
 	if err != nil {
 		return fmt.Errorf("failed to add trip delivery request: %w", err)
 	}
@@ -262,17 +363,17 @@ func (r *tripRepository) AddDeliveryRequest(tripID, requestID uuid.UUID) error {
 	return nil
 }
 
-func (r *tripRepository) RemoveDeliveryRequest(tripID, requestID uuid.UUID) error {
+func (r *tripRepository) RemoveDeliveryRequest(ctx context.Context, tripID, requestID uuid.UUID) error {
 	// First remove the delivery request
 	deleteQuery := `DELETE FROM trip_delivery_requests WHERE trip_id = $1 AND delivery_request_id = $2`
-	_, err := r.db.Exec(deleteQuery, tripID, requestID)
+	_, err := r.db.ExecNamed(ctx, "trips.RemoveDeliveryRequest.delete", deleteQuery, tripID, requestID)
 	if err != nil {
 		return fmt.Errorf("failed to remove trip delivery request: %w", err)
 	}
 
 	// Then update the current_deliveries count
 	updateQuery := `UPDATE trips SET current_deliveries = GREATEST(current_deliveries - 1, 0) WHERE id = $1`
-	_, err = r.db.Exec(updateQuery, tripID)
+	_, err = r.db.ExecNamed(ctx, "trips.RemoveDeliveryRequest.update", updateQuery, tripID)
 	if err != nil {
 		return fmt.Errorf("failed to update trip deliveries count: %w", err)
 	}
@@ -280,14 +381,14 @@ func (r *tripRepository) RemoveDeliveryRequest(tripID, requestID uuid.UUID) erro
 	return nil
 }
 
-func (r *tripRepository) GetParticipants(tripID uuid.UUID) ([]*models.User, error) {
+func (r *tripRepository) GetParticipants(ctx context.Context, tripID uuid.UUID) ([]*models.User, error) {
 	query := `
 		SELECT u.id, u.first_name, u.last_name, u.email, u.student_id
 		FROM users u
 		JOIN trip_participants tp ON u.id = tp.user_id
 		WHERE tp.trip_id = $1`
 
-	rows, err := r.db.Query(query, tripID)
+	rows, err := r.db.QueryNamed(ctx, "trips.GetParticipants", query, tripID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trip participants: %w", err)
 	}
@@ -306,9 +407,11 @@ func (r *tripRepository) GetParticipants(tripID uuid.UUID) ([]*models.User, erro
 	return participants, nil
 }
 
-func (r *tripRepository) GetMatchedRequests(tripID uuid.UUID) ([]*models.DeliveryRequest, error) {
+func (r *tripRepository) GetMatchedRequests(ctx context.Context, tripID uuid.UUID) ([]*models.DeliveryRequest, error) {
 	query := `
-		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location, 
+		SELECT dr.id, dr.user_id, dr.pickup_location, dr.dropoff_location,
+			   dr.pickup_location_id, dr.dropoff_location_id,
+			   dr.pickup_latitude, dr.pickup_longitude, dr.dropoff_latitude, dr.dropoff_longitude,
 			   dr.item_description, dr.item_size, dr.priority, dr.payment_amount,
 			   dr.pickup_date, dr.pickup_time, dr.contact_info, dr.special_instructions,
 			   dr.status, dr.matched_trip_id, dr.created_at, dr.updated_at
@@ -316,7 +419,7 @@ func (r *tripRepository) GetMatchedRequests(tripID uuid.UUID) ([]*models.Deliver
 		JOIN trip_delivery_requests tdr ON dr.id = tdr.delivery_request_id
 		WHERE tdr.trip_id = $1`
 
-	rows, err := r.db.Query(query, tripID)
+	rows, err := r.db.QueryNamed(ctx, "trips.GetMatchedRequests", query, tripID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get matched requests: %w", err)
 	}
@@ -328,6 +431,8 @@ func (r *tripRepository) GetMatchedRequests(tripID uuid.UUID) ([]*models.Deliver
 
 		err := rows.Scan(
 			&request.ID, &request.UserID, &request.PickupLocation, &request.DropoffLocation,
+			&request.PickupLocationID, &request.DropoffLocationID,
+			&request.PickupLatitude, &request.PickupLongitude, &request.DropoffLatitude, &request.DropoffLongitude,
 			&request.ItemDescription, &request.ItemSize, &request.Priority,
 			&request.PaymentAmount, &request.PickupDate, &request.PickupTime,
 			&request.ContactInfo, &request.SpecialInstructions, &request.Status,
@@ -1,9 +1,11 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
+	"campus-connect/internal/apperrors"
 	"campus-connect/internal/database"
 	"campus-connect/internal/models"
 
@@ -11,15 +13,26 @@ import (
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id uuid.UUID) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetByStudentID(studentID string) (*models.User, error)
-	Update(user *models.User) error
-	UpdateProfile(userID uuid.UUID, updates *models.UpdateProfileRequest) (*models.User, error)
-	SetVerificationStatus(userID uuid.UUID, status models.VerificationStatus) error
-	AddVerificationDocument(userID uuid.UUID, docType string, url string) error
-	ListVerificationDocuments(userID uuid.UUID) ([]*models.VerificationDocument, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByStudentID(ctx context.Context, studentID string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	UpdateProfile(ctx context.Context, userID uuid.UUID, updates *models.UpdateProfileRequest) (*models.User, error)
+	SetVerificationStatus(ctx context.Context, userID uuid.UUID, status models.VerificationStatus) error
+	SetProfileImage(ctx context.Context, userID uuid.UUID, key *string) (*string, error)
+	UpdatePhoneNumber(ctx context.Context, userID uuid.UUID, phoneNumber string) error
+	// SetPhoneVerified marks the user's phone verified, provided it is still
+	// phoneNumber. It fails with a conflict if the number has changed.
+	SetPhoneVerified(ctx context.Context, userID uuid.UUID, phoneNumber string) error
+	// Ban stops the user signing in. Banning a banned user keeps the
+	// original time.
+	Ban(ctx context.Context, userID uuid.UUID) error
+	// ListBannedIDs returns the IDs of every banned user.
+	ListBannedIDs(ctx context.Context) ([]uuid.UUID, error)
+	AddVerificationDocument(ctx context.Context, doc *models.VerificationDocument) error
+	GetVerificationDocument(ctx context.Context, id uuid.UUID) (*models.VerificationDocument, error)
+	ListVerificationDocuments(ctx context.Context, userID uuid.UUID) ([]*models.VerificationDocument, error)
 }
 
 type userRepository struct {
@@ -30,21 +43,21 @@ func NewUserRepository(db *database.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
 		INSERT INTO users (
 			id, first_name, last_name, email, password, student_id, 
 			phone_number, gender, index_number, programme_of_study, current_year
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		RETURNING created_at, updated_at, verification_status, rating, total_deliveries, phone_verified`
+		RETURNING created_at, updated_at, verification_status, role, rating, total_deliveries, phone_verified`
 
-	err := r.db.QueryRow(
+	err := r.db.QueryRowNamed(ctx, "users.Create",
 		query,
 		user.ID, user.FirstName, user.LastName, user.Email, user.Password,
 		user.StudentID, user.PhoneNumber, user.Gender, user.IndexNumber,
 		user.ProgrammeOfStudy, user.CurrentYear,
 	).Scan(
-		&user.CreatedAt, &user.UpdatedAt, &user.VerificationStatus,
+		&user.CreatedAt, &user.UpdatedAt, &user.VerificationStatus, &user.Role,
 		&user.Rating, &user.TotalDeliveries, &user.PhoneVerified,
 	)
 
@@ -55,27 +68,27 @@ func (r *userRepository) Create(user *models.User) error {
 	return nil
 }
 
-func (r *userRepository) GetByID(id uuid.UUID) (*models.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	user := &models.User{}
 	query := `
 		SELECT id, first_name, last_name, email, password, student_id, 
 			   phone_number, phone_verified, gender, index_number, programme_of_study, 
-			   current_year, verification_status, rating, total_deliveries, 
-			   profile_image, created_at, updated_at
+			   current_year, verification_status, role, rating, total_deliveries, 
+			   profile_image, profile_image_key, banned_at, created_at, updated_at
 		FROM users 
 		WHERE id = $1`
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowNamed(ctx, "users.GetByID", query, id).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password,
 		&user.StudentID, &user.PhoneNumber, &user.PhoneVerified, &user.Gender,
 		&user.IndexNumber, &user.ProgrammeOfStudy, &user.CurrentYear,
-		&user.VerificationStatus, &user.Rating, &user.TotalDeliveries,
-		&user.ProfileImage, &user.CreatedAt, &user.UpdatedAt,
+		&user.VerificationStatus, &user.Role, &user.Rating, &user.TotalDeliveries,
+		&user.ProfileImage, &user.ProfileImageKey, &user.BannedAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound(apperrors.CodeUserNotFound, "User not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -83,27 +96,27 @@ func (r *userRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return user, nil
 }
 
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
 	query := `
 		SELECT id, first_name, last_name, email, password, student_id, 
 			   phone_number, phone_verified, gender, index_number, programme_of_study, 
-			   current_year, verification_status, rating, total_deliveries, 
-			   profile_image, created_at, updated_at
+			   current_year, verification_status, role, rating, total_deliveries, 
+			   profile_image, profile_image_key, banned_at, created_at, updated_at
 		FROM users 
 		WHERE email = $1`
 
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.db.QueryRowNamed(ctx, "users.GetByEmail", query, email).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password,
 		&user.StudentID, &user.PhoneNumber, &user.PhoneVerified, &user.Gender,
 		&user.IndexNumber, &user.ProgrammeOfStudy, &user.CurrentYear,
-		&user.VerificationStatus, &user.Rating, &user.TotalDeliveries,
-		&user.ProfileImage, &user.CreatedAt, &user.UpdatedAt,
+		&user.VerificationStatus, &user.Role, &user.Rating, &user.TotalDeliveries,
+		&user.ProfileImage, &user.ProfileImageKey, &user.BannedAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound(apperrors.CodeUserNotFound, "User not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -111,27 +124,27 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	return user, nil
 }
 
-func (r *userRepository) GetByStudentID(studentID string) (*models.User, error) {
+func (r *userRepository) GetByStudentID(ctx context.Context, studentID string) (*models.User, error) {
 	user := &models.User{}
 	query := `
 		SELECT id, first_name, last_name, email, password, student_id, 
 			   phone_number, phone_verified, gender, index_number, programme_of_study, 
-			   current_year, verification_status, rating, total_deliveries, 
-			   profile_image, created_at, updated_at
+			   current_year, verification_status, role, rating, total_deliveries, 
+			   profile_image, profile_image_key, banned_at, created_at, updated_at
 		FROM users 
 		WHERE student_id = $1`
 
-	err := r.db.QueryRow(query, studentID).Scan(
+	err := r.db.QueryRowNamed(ctx, "users.GetByStudentID", query, studentID).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password,
 		&user.StudentID, &user.PhoneNumber, &user.PhoneVerified, &user.Gender,
 		&user.IndexNumber, &user.ProgrammeOfStudy, &user.CurrentYear,
-		&user.VerificationStatus, &user.Rating, &user.TotalDeliveries,
-		&user.ProfileImage, &user.CreatedAt, &user.UpdatedAt,
+		&user.VerificationStatus, &user.Role, &user.Rating, &user.TotalDeliveries,
+		&user.ProfileImage, &user.ProfileImageKey, &user.BannedAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound(apperrors.CodeUserNotFound, "User not found")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -139,14 +152,14 @@ func (r *userRepository) GetByStudentID(studentID string) (*models.User, error)
 	return user, nil
 }
 
-func (r *userRepository) Update(user *models.User) error {
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users 
 		SET first_name = $2, last_name = $3, gender = $4, index_number = $5, 
 			programme_of_study = $6, current_year = $7, profile_image = $8
 		WHERE id = $1`
 
-	_, err := r.db.Exec(
+	_, err := r.db.ExecNamed(ctx, "users.Update",
 		query,
 		user.ID, user.FirstName, user.LastName, user.Gender,
 		user.IndexNumber, user.ProgrammeOfStudy, user.CurrentYear, user.ProfileImage,
@@ -159,7 +172,7 @@ func (r *userRepository) Update(user *models.User) error {
 	return nil
 }
 
-func (r *userRepository) UpdateProfile(userID uuid.UUID, updates *models.UpdateProfileRequest) (*models.User, error) {
+func (r *userRepository) UpdateProfile(ctx context.Context, userID uuid.UUID, updates *models.UpdateProfileRequest) (*models.User, error) {
 	setParts := []string{}
 	args := []interface{}{userID}
 	argIndex := 2
@@ -196,7 +209,7 @@ func (r *userRepository) UpdateProfile(userID uuid.UUID, updates *models.UpdateP
 	}
 
 	if len(setParts) == 0 {
-		return r.GetByID(userID)
+		return r.GetByID(ctx, userID)
 	}
 
 	query := fmt.Sprintf(`
@@ -205,8 +218,8 @@ func (r *userRepository) UpdateProfile(userID uuid.UUID, updates *models.UpdateP
 		WHERE id = $1
 		RETURNING id, first_name, last_name, email, student_id, 
 				  phone_number, phone_verified, gender, index_number, programme_of_study, 
-				  current_year, verification_status, rating, total_deliveries, 
-				  profile_image, created_at, updated_at`,
+				  current_year, verification_status, role, rating, total_deliveries, 
+				  profile_image, profile_image_key, banned_at, created_at, updated_at`,
 		fmt.Sprintf("%s", setParts[0:]))
 
 	if len(setParts) > 1 {
@@ -220,18 +233,18 @@ func (r *userRepository) UpdateProfile(userID uuid.UUID, updates *models.UpdateP
 			WHERE id = $1
 			RETURNING id, first_name, last_name, email, student_id, 
 					  phone_number, phone_verified, gender, index_number, programme_of_study, 
-					  current_year, verification_status, rating, total_deliveries, 
-					  profile_image, created_at, updated_at`,
+					  current_year, verification_status, role, rating, total_deliveries, 
+					  profile_image, profile_image_key, banned_at, created_at, updated_at`,
 			setClause)
 	}
 
 	user := &models.User{}
-	err := r.db.QueryRow(query, args...).Scan(
+	err := r.db.QueryRowNamed(ctx, "users.UpdateProfile", query, args...).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.StudentID,
 		&user.PhoneNumber, &user.PhoneVerified, &user.Gender, &user.IndexNumber,
 		&user.ProgrammeOfStudy, &user.CurrentYear, &user.VerificationStatus,
-		&user.Rating, &user.TotalDeliveries, &user.ProfileImage,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Role, &user.Rating, &user.TotalDeliveries, &user.ProfileImage,
+		&user.ProfileImageKey, &user.BannedAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
@@ -241,42 +254,163 @@ func (r *userRepository) UpdateProfile(userID uuid.UUID, updates *models.UpdateP
 	return user, nil
 }
 
-func (r *userRepository) SetVerificationStatus(userID uuid.UUID, status models.VerificationStatus) error {
+func (r *userRepository) SetVerificationStatus(ctx context.Context, userID uuid.UUID, status models.VerificationStatus) error {
 	query := `
 		UPDATE users 
 		SET verification_status = $2
 		WHERE id = $1`
 
-	_, err := r.db.Exec(query, userID, status)
+	_, err := r.db.ExecNamed(ctx, "users.SetVerificationStatus", query, userID, status)
 	if err != nil {
 		return fmt.Errorf("failed to update verification status: %w", err)
 	}
 	return nil
 }
 
-func (r *userRepository) AddVerificationDocument(userID uuid.UUID, docType string, url string) error {
+// SetProfileImage replaces the stored profile image key in a single statement
+// and returns the key of the image it replaced, so the caller can clean it up.
+// The image's URL is signed from the key when read, and any URL stored before
+// keys were used is cleared. Passing nil clears the profile image.
+func (r *userRepository) SetProfileImage(ctx context.Context, userID uuid.UUID, key *string) (*string, error) {
 	query := `
-		INSERT INTO user_verification_documents (user_id, doc_type, url)
-		VALUES ($1, $2, $3)`
-	_, err := r.db.Exec(query, userID, docType, url)
+		UPDATE users u
+		SET profile_image = NULL, profile_image_key = $2
+		FROM (SELECT id, profile_image_key FROM users WHERE id = $1 FOR UPDATE) prev
+		WHERE u.id = prev.id
+		RETURNING prev.profile_image_key`
+
+	var previousKey *string
+	err := r.db.QueryRowNamed(ctx, "users.SetProfileImage", query, userID, key).Scan(&previousKey)
 	if err != nil {
-		return fmt.Errorf("failed to add verification document: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodeUserNotFound, "User not found")
+		}
+		return nil, fmt.Errorf("failed to update profile image: %w", err)
 	}
 
-	_, err = r.db.Exec("UPDATE users SET verification_status = 'pending' WHERE id = $1", userID)
+	return previousKey, nil
+}
+
+// UpdatePhoneNumber stores a new phone number and resets its verification.
+func (r *userRepository) UpdatePhoneNumber(ctx context.Context, userID uuid.UUID, phoneNumber string) error {
+	query := `
+		UPDATE users 
+		SET phone_number = $2, phone_verified = FALSE
+		WHERE id = $1`
+
+	result, err := r.db.ExecNamed(ctx, "users.UpdatePhoneNumber", query, userID, phoneNumber)
 	if err != nil {
-		return fmt.Errorf("failed to update verification status: %w", err)
+		return fmt.Errorf("failed to update phone number: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return apperrors.NotFound(apperrors.CodeUserNotFound, "User not found")
+	}
+	return nil
+}
+
+func (r *userRepository) SetPhoneVerified(ctx context.Context, userID uuid.UUID, phoneNumber string) error {
+	query := `
+		UPDATE users 
+		SET phone_verified = TRUE
+		WHERE id = $1 AND phone_number = $2`
+
+	result, err := r.db.ExecNamed(ctx, "users.SetPhoneVerified", query, userID, phoneNumber)
+	if err != nil {
+		return fmt.Errorf("failed to update phone verification: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return apperrors.Conflict(apperrors.CodeConflict, "Phone number changed before it was verified")
 	}
 	return nil
 }
 
-func (r *userRepository) ListVerificationDocuments(userID uuid.UUID) ([]*models.VerificationDocument, error) {
+func (r *userRepository) Ban(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE users SET banned_at = COALESCE(banned_at, CURRENT_TIMESTAMP) WHERE id = $1`
+
+	result, err := r.db.ExecNamed(ctx, "users.Ban", query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to ban user: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return apperrors.NotFound(apperrors.CodeUserNotFound, "User not found")
+	}
+	return nil
+}
+
+func (r *userRepository) ListBannedIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryNamed(ctx, "users.ListBannedIDs", `SELECT id FROM users WHERE banned_at IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list banned users: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan banned user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating banned users: %w", err)
+	}
+	return ids, nil
+}
+
+// AddVerificationDocument records the document and puts the user's
+// verification back to pending, together.
+func (r *userRepository) AddVerificationDocument(ctx context.Context, doc *models.VerificationDocument) error {
+	return r.db.InTx(ctx, func(ctx context.Context) error {
+		query := `
+			INSERT INTO user_verification_documents (id, user_id, doc_type, url, storage_key)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING created_at`
+		err := r.db.QueryRowNamed(ctx, "users.AddVerificationDocument", query, doc.ID, doc.UserID, doc.DocType, doc.URL, doc.StorageKey).Scan(&doc.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to add verification document: %w", err)
+		}
+
+		_, err = r.db.ExecNamed(ctx, "users.AddVerificationDocument.status",
+			`UPDATE users SET verification_status = 'pending' WHERE id = $1`, doc.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to update verification status: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *userRepository) GetVerificationDocument(ctx context.Context, id uuid.UUID) (*models.VerificationDocument, error) {
+	doc := &models.VerificationDocument{}
+	query := `
+		SELECT id, user_id, doc_type, url, storage_key, created_at
+		FROM user_verification_documents
+		WHERE id = $1`
+	err := r.db.QueryRowNamed(ctx, "users.GetVerificationDocument", query, id).Scan(&doc.ID, &doc.UserID, &doc.DocType, &doc.URL, &doc.StorageKey, &doc.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound(apperrors.CodeDocumentNotFound, "Verification document not found")
+		}
+		return nil, fmt.Errorf("failed to get verification document: %w", err)
+	}
+	return doc, nil
+}
+
+func (r *userRepository) ListVerificationDocuments(ctx context.Context, userID uuid.UUID) ([]*models.VerificationDocument, error) {
 	query := `
-		SELECT id, user_id, doc_type, url, created_at
+		SELECT id, user_id, doc_type, url, storage_key, created_at
 		FROM user_verification_documents
 		WHERE user_id = $1
 		ORDER BY created_at DESC`
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryNamed(ctx, "users.ListVerificationDocuments", query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list verification documents: %w", err)
 	}
@@ -285,7 +419,7 @@ func (r *userRepository) ListVerificationDocuments(userID uuid.UUID) ([]*models.
 	var docs []*models.VerificationDocument
 	for rows.Next() {
 		doc := &models.VerificationDocument{}
-		if err := rows.Scan(&doc.ID, &doc.UserID, &doc.DocType, &doc.URL, &doc.CreatedAt); err != nil {
+		if err := rows.Scan(&doc.ID, &doc.UserID, &doc.DocType, &doc.URL, &doc.StorageKey, &doc.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan verification document: %w", err)
 		}
 		docs = append(docs, doc)
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestSetProfileImage(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	users := NewUserRepository(db)
+
+	userID := createTestUser(t, db)
+	if _, err := db.Exec(`UPDATE users SET profile_image = 'https://example.com/old.jpg' WHERE id = $1`, userID); err != nil {
+		t.Fatalf("set old profile image: %v", err)
+	}
+
+	key := func(s string) *string { return &s }
+	steps := []struct {
+		name         string
+		key          *string
+		wantPrevious *string
+	}{
+		{name: "first image", key: key("profile_images/a.jpg")},
+		{name: "replace image", key: key("profile_images/b.jpg"), wantPrevious: key("profile_images/a.jpg")},
+		{name: "clear image", wantPrevious: key("profile_images/b.jpg")},
+		{name: "clear again"},
+	}
+	for _, step := range steps {
+		previous, err := users.SetProfileImage(ctx, userID, step.key)
+		if err != nil {
+			t.Fatalf("%s: SetProfileImage() error = %v", step.name, err)
+		}
+		if !equalKeys(previous, step.wantPrevious) {
+			t.Errorf("%s: SetProfileImage() = %v, want %v", step.name, deref(previous), deref(step.wantPrevious))
+		}
+
+		user, err := users.GetByID(ctx, userID)
+		if err != nil {
+			t.Fatalf("%s: GetByID() error = %v", step.name, err)
+		}
+		if !equalKeys(user.ProfileImageKey, step.key) {
+			t.Errorf("%s: stored key = %v, want %v", step.name, deref(user.ProfileImageKey), deref(step.key))
+		}
+		if user.ProfileImage != nil {
+			t.Errorf("%s: stored URL = %q, want none", step.name, *user.ProfileImage)
+		}
+	}
+
+	if _, err := users.SetProfileImage(ctx, uuid.New(), key("profile_images/c.jpg")); !apperrors.HasCode(err, apperrors.CodeUserNotFound) {
+		t.Errorf("SetProfileImage(unknown user) error = %v, want %s", err, apperrors.CodeUserNotFound)
+	}
+}
+
+func TestAddVerificationDocument(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	users := NewUserRepository(db)
+
+	userID := createTestUser(t, db)
+	if err := users.SetVerificationStatus(ctx, userID, models.VerificationRejected); err != nil {
+		t.Fatalf("SetVerificationStatus() error = %v", err)
+	}
+
+	key := "verification_docs/" + userID.String() + "/id.pdf"
+	doc := &models.VerificationDocument{ID: uuid.New(), UserID: userID, DocType: "student_id", StorageKey: &key}
+	if err := users.AddVerificationDocument(ctx, doc); err != nil {
+		t.Fatalf("AddVerificationDocument() error = %v", err)
+	}
+	if doc.CreatedAt.IsZero() {
+		t.Error("AddVerificationDocument() did not set CreatedAt")
+	}
+
+	user, err := users.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if user.VerificationStatus != models.VerificationPending {
+		t.Errorf("verification status = %s, want %s", user.VerificationStatus, models.VerificationPending)
+	}
+	docs, err := users.ListVerificationDocuments(ctx, userID)
+	if err != nil {
+		t.Fatalf("ListVerificationDocuments() error = %v", err)
+	}
+	if len(docs) != 1 || docs[0].ID != doc.ID {
+		t.Errorf("ListVerificationDocuments() = %v, want the added document", docs)
+	}
+}
+
+func equalKeys(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
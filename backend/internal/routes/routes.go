@@ -1,18 +1,22 @@
 package routes
 
 import (
+	"log/slog"
 	"net/http"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"campus-connect/internal/auth"
 	"campus-connect/internal/config"
 	"campus-connect/internal/database"
 	"campus-connect/internal/handlers"
+	"campus-connect/internal/metrics"
 	"campus-connect/internal/middleware"
+	"campus-connect/internal/ratelimit"
 	"campus-connect/internal/repositories"
 	"campus-connect/internal/services"
 )
@@ -20,69 +24,168 @@ import (
 func SetupRoutes(
 	db *database.DB,
 	authService *auth.AuthService,
-	cloudinaryService *services.CloudinaryService,
+	store services.ObjectStore,
+	redisClient *redis.Client,
+	payments *services.PaymentService,
+	disputes *services.DisputeService,
 	cfg *config.Config,
+	logger *slog.Logger,
 ) http.Handler {
 	r := chi.NewRouter()
 
-	r.Use(chiMiddleware.Logger)
-	r.Use(chiMiddleware.Recoverer)
 	r.Use(chiMiddleware.RequestID)
 	r.Use(chiMiddleware.RealIP)
+	r.Use(middleware.ExposeRequestID)
+	r.Use(middleware.RequestLogger(logger))
+	r.Use(middleware.Metrics)
+	r.Use(middleware.TraceRoute)
+	r.Use(middleware.Recoverer)
 
+	r.Use(middleware.SecurityHeaders(middleware.SecurityHeadersConfig(cfg.Security)))
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+		AllowedMethods: cfg.CORS.AllowedMethods,
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		ExposedHeaders: []string{
+			"Link", middleware.RequestIDHeader, "Retry-After",
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+		},
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           int(cfg.CORS.MaxAge.Seconds()),
 	}))
 
 	userRepo := repositories.NewUserRepository(db)
 	deliveryRepo := repositories.NewDeliveryRepository(db)
 	tripRepo := repositories.NewTripRepository(db)
+	loginEventRepo := repositories.NewLoginEventRepository(db)
+	ledgerRepo := repositories.NewLedgerRepository(db, cfg.Ledger.PlatformFeeBPS)
+	earningsRepo := repositories.NewEarningsRepository(db)
+	payoutRepo := repositories.NewPayoutRepository(db)
+	pricingRepo := repositories.NewPricingRepository(db)
+	locationRepo := repositories.NewLocationRepository(db)
+	proofRepo := repositories.NewProofRepository(db)
+	blockRepo := repositories.NewBlockRepository(db)
+	reportRepo := repositories.NewReportRepository(db)
 
 	verificationService := services.NewVerificationService(
-		cfg.Redis.Addr,
-		cfg.Redis.Password,
-		cfg.Redis.DB,
+		redisClient,
 		cfg.Brevo.APIKey,
 		cfg.Brevo.SenderName,
 		cfg.Brevo.SenderEmail,
 	)
 
 	authHandler := handlers.NewAuthHandler(userRepo, authService).
-		WithCloudinary(cloudinaryService).
-		WithVerifier(verificationService)
-	deliveryHandler := handlers.NewDeliveryHandler(deliveryRepo, tripRepo)
-	tripHandler := handlers.NewTripHandler(tripRepo)
+		WithStore(store).
+		WithVerifier(verificationService).
+		WithSMS(services.NewLogSMSProvider(cfg.SMS.SenderID)).
+		WithLoginEvents(loginEventRepo).
+		WithLoginThrottle(services.NewLoginThrottle(redisClient, cfg.Lockout))
+	pricingService := services.NewPricingService(pricingRepo, cfg.Pricing)
 
-	authMiddleware := middleware.NewAuthMiddleware(authService)
+	deliveryHandler := handlers.NewDeliveryHandler(deliveryRepo, tripRepo).
+		WithLedger(ledgerRepo, db).
+		WithPricing(pricingService).
+		WithLocations(locationRepo).
+		WithProofs(proofRepo).
+		WithBlocks(blockRepo)
+	tripHandler := handlers.NewTripHandler(tripRepo).
+		WithPricing(pricingService).
+		WithLocations(locationRepo).
+		WithBlocks(blockRepo)
+	locationHandler := handlers.NewLocationHandler(locationRepo)
+	trackingService := services.NewTrackingService(redisClient, cfg.Tracking)
+	deliveryHandler.WithTracking(trackingService)
+	trackingHandler := handlers.NewTrackingHandler(deliveryRepo, tripRepo, trackingService)
+	proofHandler := handlers.NewProofHandler(deliveryRepo, tripRepo, proofRepo, store)
+	pricingHandler := handlers.NewPricingHandler(pricingService)
+	earningsHandler := handlers.NewEarningsHandler(earningsRepo, tripRepo, payoutRepo, userRepo)
+	payoutHandler := handlers.NewPayoutHandler(payoutRepo, ledgerRepo, db)
+	disputeHandler := handlers.NewDisputeHandler(disputes, store)
+	moderationHandler := handlers.NewModerationHandler(
+		blockRepo, reportRepo, userRepo, tripRepo, deliveryRepo, repositories.NewDisputeRepository(db),
+	)
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok","service":"campus-connect-api", "version":"1.0.0", "timestamp":"` + time.Now().Format(time.RFC3339) + `"}`))
-	})
+	authMiddleware := middleware.NewAuthMiddleware(authService).WithBans(services.NewBanList(userRepo))
+
+	var limiter ratelimit.Limiter = ratelimit.NewFallbackLimiter(
+		ratelimit.NewRedisLimiter(redisClient), ratelimit.NewMemoryLimiter(),
+	)
+	if cfg.RateLimit.Store == "memory" {
+		limiter = ratelimit.NewMemoryLimiter()
+	}
+	authLimit := middleware.RateLimit(limiter, "auth", cfg.RateLimit.Auth, middleware.RateLimitByIP)
+	emailVerifyLimit := middleware.RateLimit(limiter, "verify-email", cfg.RateLimit.Verify, middleware.RateLimitByIP)
+	phoneVerifyLimit := middleware.RateLimit(limiter, "verify-phone", cfg.RateLimit.Verify, middleware.RateLimitByUser)
+	phoneCodeLimit := middleware.RateLimit(limiter, "phone-code", cfg.RateLimit.PhoneCode, middleware.RateLimitByUser)
+	trackingLimit := middleware.RateLimit(limiter, "tracking", cfg.RateLimit.Tracking, middleware.RateLimitByUser)
+
+	expectedMigration, err := database.LatestMigrationVersion(cfg.Database.MigrationsPath)
+	if err != nil {
+		logger.Warn("readiness will not check the schema version", "error", err)
+	}
+	healthHandler := handlers.NewHealthHandler(db, redisClient, expectedMigration)
+
+	r.Get("/livez", healthHandler.Livez)
+	r.Get("/readyz", healthHandler.Readyz)
+	// Kept for existing clients; equivalent to /livez
+	r.Get("/health", healthHandler.Livez)
+
+	jwksHandler := handlers.NewJWKSHandler(authService.Keyring())
+	r.Get("/.well-known/jwks.json", jwksHandler.ServeJWKS)
+
+	// With a dedicated metrics listener, main serves /metrics there instead
+	if cfg.Metrics.Addr == "" && cfg.Metrics.Token != "" {
+		r.Handle("/metrics", metrics.Handler(cfg.Metrics.Token))
+	}
+
+	if localStore, ok := store.(*services.LocalStore); ok {
+		fileHandler := handlers.NewFileHandler(localStore)
+		r.Get("/files/*", fileHandler.ServeFile)
+	}
 
 	r.Route("/api", func(r chi.Router) {
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/signup", authHandler.SignUp)
-			r.Post("/signin", authHandler.SignIn)
+			r.With(authLimit).Post("/signup", authHandler.SignUp)
+			r.With(authLimit).Post("/signin", authHandler.SignIn)
 			r.Post("/logout", authHandler.Logout)
-			r.Post("/verify-email", authHandler.VerifyEmail)
+			r.With(emailVerifyLimit).Post("/verify-email", authHandler.VerifyEmail)
 
 			r.Group(func(r chi.Router) {
 				r.Use(authMiddleware.RequireAuth)
 				r.Get("/me", authHandler.Me)
+				r.Get("/login-history", authHandler.LoginHistory)
 				r.Put("/update-profile", authHandler.UpdateProfile)
 				r.Post("/upload-verification", authHandler.UploadVerificationDocument)
+				r.Get("/verification-documents", authHandler.ListVerificationDocuments)
+				r.Get("/verification-documents/{id}/url", authHandler.GetVerificationDocumentURL)
+				r.Post("/profile-image", authHandler.UploadProfileImage)
+				r.Delete("/profile-image", authHandler.DeleteProfileImage)
+				r.Put("/phone", authHandler.ChangePhoneNumber)
+				r.With(phoneCodeLimit).Post("/phone/send-code", authHandler.SendPhoneCode)
+				r.With(phoneVerifyLimit).Post("/phone/verify", authHandler.VerifyPhone)
 			})
 		})
 
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(authMiddleware.RequireAuth)
+			r.Use(authMiddleware.RequireAdmin)
+			r.Get("/users/{id}/verification-documents", authHandler.ListUserVerificationDocuments)
+			r.Get("/payouts", payoutHandler.ListPayouts)
+			r.Post("/payouts/{id}/approve", payoutHandler.ApprovePayout)
+			r.Post("/payouts/{id}/reject", payoutHandler.RejectPayout)
+			r.Get("/price-flags", pricingHandler.ListPriceFlags)
+			r.Get("/disputes", disputeHandler.ListDisputes)
+			r.Post("/disputes/{id}/assign", disputeHandler.AssignDispute)
+			r.Post("/disputes/{id}/notes", disputeHandler.AddNote)
+			r.Post("/disputes/{id}/status", disputeHandler.UpdateDisputeStatus)
+			r.Post("/disputes/{id}/resolve", disputeHandler.ResolveDispute)
+			r.Get("/reports", moderationHandler.ListReports)
+			r.Post("/reports/{id}/review", moderationHandler.ReviewReport)
+		})
+
 		r.Route("/delivery-requests", func(r chi.Router) {
 			r.With(authMiddleware.OptionalAuth).Get("/", deliveryHandler.GetDeliveryRequests)
+			r.With(authMiddleware.OptionalAuth).Get("/nearby", deliveryHandler.SearchNearbyRequests)
 			r.With(authMiddleware.OptionalAuth).Get("/{id}", deliveryHandler.GetDeliveryRequestByID)
 
 			r.Group(func(r chi.Router) {
@@ -90,11 +193,77 @@ func SetupRoutes(
 				r.Post("/create", deliveryHandler.CreateDeliveryRequest)
 				r.Post("/offer", deliveryHandler.OfferDelivery)
 				r.Delete("/cancel", deliveryHandler.CancelDeliveryOffer)
+				r.Post("/{id}/status", deliveryHandler.UpdateDeliveryStatus)
+				r.With(trackingLimit).Post("/{id}/location", trackingHandler.ShareLocation)
+				r.Get("/{id}/location", trackingHandler.GetLocation)
+				r.Get("/{id}/location/stream", trackingHandler.StreamLocation)
+				r.Get("/{id}/handover-code", proofHandler.GetHandoverCode)
+				r.Post("/{id}/proofs", proofHandler.UploadPhotoProof)
+				r.Get("/{id}/proofs", proofHandler.ListProofs)
+			})
+		})
+
+		if payments != nil {
+			paymentHandler := handlers.NewPaymentHandler(payments)
+			r.Route("/payments", func(r chi.Router) {
+				// Authenticated by its signature, not a token
+				r.Post("/webhook", paymentHandler.Webhook)
+
+				r.Group(func(r chi.Router) {
+					r.Use(authMiddleware.RequireAuth)
+					r.Post("/charge", paymentHandler.InitiatePayment)
+					r.Get("/{reference}", paymentHandler.GetPayment)
+					r.Post("/{reference}/otp", paymentHandler.SubmitOTP)
+				})
 			})
+		}
+
+		r.Get("/pricing/estimate", pricingHandler.GetEstimate)
+
+		r.Route("/locations", func(r chi.Router) {
+			r.Get("/", locationHandler.GetLocations)
+			r.Get("/autocomplete", locationHandler.Autocomplete)
+			r.Get("/{id}", locationHandler.GetLocation)
+		})
+
+		r.Route("/earnings", func(r chi.Router) {
+			r.Use(authMiddleware.RequireAuth)
+			r.Get("/", earningsHandler.GetEarnings)
+			r.Get("/trips", earningsHandler.GetTripEarnings)
+			r.Get("/statement", earningsHandler.ExportStatement)
+		})
+
+		r.Route("/payouts", func(r chi.Router) {
+			r.Use(authMiddleware.RequireAuth)
+			r.Post("/", payoutHandler.RequestPayout)
+			r.Get("/", payoutHandler.GetMyPayouts)
+		})
+
+		r.Route("/disputes", func(r chi.Router) {
+			r.Use(authMiddleware.RequireAuth)
+			r.Post("/", disputeHandler.OpenDispute)
+			r.Get("/", disputeHandler.GetMyDisputes)
+			r.Get("/{id}", disputeHandler.GetDispute)
+			r.Post("/{id}/messages", disputeHandler.AddMessage)
+			r.Post("/{id}/attachments", disputeHandler.UploadAttachment)
+		})
+
+		r.Route("/blocks", func(r chi.Router) {
+			r.Use(authMiddleware.RequireAuth)
+			r.Post("/", moderationHandler.BlockUser)
+			r.Get("/", moderationHandler.GetBlockedUsers)
+			r.Delete("/{userId}", moderationHandler.UnblockUser)
+		})
+
+		r.Route("/reports", func(r chi.Router) {
+			r.Use(authMiddleware.RequireAuth)
+			r.Post("/", moderationHandler.CreateReport)
+			r.Get("/", moderationHandler.GetMyReports)
 		})
 
 		r.Route("/trips", func(r chi.Router) {
 			r.With(authMiddleware.OptionalAuth).Get("/", tripHandler.GetTrips)
+			r.With(authMiddleware.OptionalAuth).Get("/nearby", tripHandler.SearchNearbyTrips)
 			r.With(authMiddleware.OptionalAuth).Get("/{id}", tripHandler.GetTripDetails)
 
 			r.Group(func(r chi.Router) {
@@ -107,5 +276,15 @@ func SetupRoutes(
 		})
 	})
 
-	return r
+	// otelhttp starts the server span and extracts W3C trace context before
+	// chi routes the request; TraceRoute names the span after the route
+	return otelhttp.NewHandler(r, "http.server",
+		otelhttp.WithFilter(func(req *http.Request) bool {
+			switch req.URL.Path {
+			case "/metrics", "/livez", "/readyz", "/health":
+				return false
+			}
+			return true
+		}),
+	)
 }
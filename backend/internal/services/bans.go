@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"campus-connect/internal/logging"
+	"campus-connect/internal/repositories"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// banListTTL is how long a loaded list of banned users is trusted, and so
+// how long a ban can take to reach requests made with an existing token.
+const banListTTL = 30 * time.Second
+
+// banListLoadTimeout bounds a reload, which runs apart from the request that
+// triggered it.
+const banListLoadTimeout = 5 * time.Second
+
+// BanList answers whether a user is banned from a copy of the banned users
+// reloaded every banListTTL, so tokens issued before a ban stop working
+// without a database query per request.
+type BanList struct {
+	users  repositories.UserRepository
+	now    func() time.Time
+	reload singleflight.Group
+
+	mu       sync.Mutex
+	banned   map[uuid.UUID]struct{}
+	loadedAt time.Time
+}
+
+func NewBanList(users repositories.UserRepository) *BanList {
+	return &BanList{users: users, now: time.Now}
+}
+
+// IsBanned reports whether the user is banned. Requests that find the list
+// due for a reload share one query. If it fails, or ctx ends first, the
+// previous copy is used; it only fails if the list was never loaded.
+func (b *BanList) IsBanned(ctx context.Context, userID uuid.UUID) (bool, error) {
+	b.mu.Lock()
+	banned, loadedAt := b.banned, b.loadedAt
+	b.mu.Unlock()
+
+	if banned == nil || b.now().Sub(loadedAt) >= banListTTL {
+		select {
+		case res := <-b.reload.DoChan("", b.load):
+			if res.Err == nil {
+				banned = res.Val.(map[uuid.UUID]struct{})
+			} else if banned == nil {
+				return false, res.Err
+			}
+		case <-ctx.Done():
+			if banned == nil {
+				return false, ctx.Err()
+			}
+		}
+	}
+
+	_, isBanned := banned[userID]
+	return isBanned, nil
+}
+
+// load reloads the list on a context of its own, so the request that
+// started it cannot cancel it for the others waiting.
+func (b *BanList) load() (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), banListLoadTimeout)
+	defer cancel()
+	ids, err := b.users.ListBannedIDs(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		if b.banned != nil {
+			// Keep the previous list until the next reload is due
+			logging.FromContext(ctx).Warn("failed to reload banned users, using the previous list", "error", err)
+			b.loadedAt = b.now()
+		}
+		return nil, err
+	}
+	banned := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		banned[id] = struct{}{}
+	}
+	b.banned, b.loadedAt = banned, b.now()
+	return banned, nil
+}
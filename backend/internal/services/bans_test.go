@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"campus-connect/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type fakeBannedUsers struct {
+	repositories.UserRepository
+	banned []uuid.UUID
+	err    error
+	loads  int
+	// hold, when set, keeps loads waiting until it is closed
+	hold       chan struct{}
+	loadCtxErr error
+}
+
+func (f *fakeBannedUsers) ListBannedIDs(ctx context.Context) ([]uuid.UUID, error) {
+	f.loads++
+	if f.hold != nil {
+		<-f.hold
+	}
+	f.loadCtxErr = ctx.Err()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return append([]uuid.UUID(nil), f.banned...), nil
+}
+
+// banListStep advances the clock by after, sets the stored bans and checks
+// the user.
+type banListStep struct {
+	after      time.Duration
+	banned     bool
+	err        error
+	wantBanned bool
+	wantErr    bool
+}
+
+func TestBanList(t *testing.T) {
+	ctx := context.Background()
+	user := uuid.New()
+	errDown := errors.New("database down")
+
+	tests := []struct {
+		name      string
+		steps     []banListStep
+		wantLoads int
+	}{
+		{
+			name:      "banned user",
+			steps:     []banListStep{{banned: true, wantBanned: true}},
+			wantLoads: 1,
+		},
+		{
+			name: "ban reaches the list once it is reloaded",
+			steps: []banListStep{
+				{},
+				{after: banListTTL / 2, banned: true},
+				{after: banListTTL / 2, banned: true, wantBanned: true},
+			},
+			wantLoads: 2,
+		},
+		{
+			name: "failed reload keeps the previous list",
+			steps: []banListStep{
+				{banned: true, wantBanned: true},
+				{after: banListTTL, err: errDown, wantBanned: true},
+				{after: time.Second, err: errDown, wantBanned: true},
+				{after: banListTTL, wantBanned: false},
+			},
+			wantLoads: 3,
+		},
+		{
+			name: "failed first load",
+			steps: []banListStep{
+				{err: errDown, wantErr: true},
+				{wantBanned: false},
+			},
+			wantLoads: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeBannedUsers{}
+			now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+			bans := NewBanList(users)
+			bans.now = func() time.Time { return now }
+
+			for i, step := range tt.steps {
+				now = now.Add(step.after)
+				users.banned, users.err = nil, step.err
+				if step.banned {
+					users.banned = []uuid.UUID{uuid.New(), user}
+				}
+
+				banned, err := bans.IsBanned(ctx, user)
+				if (err != nil) != step.wantErr {
+					t.Fatalf("step %d: IsBanned() error = %v, want error: %t", i, err, step.wantErr)
+				}
+				if banned != step.wantBanned {
+					t.Errorf("step %d: IsBanned() = %t, want %t", i, banned, step.wantBanned)
+				}
+			}
+			if users.loads != tt.wantLoads {
+				t.Errorf("loads = %d, want %d", users.loads, tt.wantLoads)
+			}
+		})
+	}
+}
+
+func TestBanListSlowReload(t *testing.T) {
+	user := uuid.New()
+	users := &fakeBannedUsers{banned: []uuid.UUID{user}}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	bans := NewBanList(users)
+	bans.now = func() time.Time { return now }
+
+	if banned, err := bans.IsBanned(context.Background(), user); err != nil || !banned {
+		t.Fatalf("IsBanned() = %t, %v, want banned", banned, err)
+	}
+
+	// A request that gives up on a slow reload gets the previous list, and
+	// the reload carries on without it
+	now = now.Add(banListTTL)
+	users.banned, users.hold = nil, make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if banned, err := bans.IsBanned(ctx, user); err != nil || !banned {
+		t.Fatalf("IsBanned() during reload = %t, %v, want the previous list", banned, err)
+	}
+	close(users.hold)
+	if banned, err := bans.IsBanned(context.Background(), user); err != nil || banned {
+		t.Fatalf("IsBanned() after reload = %t, %v, want not banned", banned, err)
+	}
+	if users.loadCtxErr != nil {
+		t.Errorf("reload's context ended with the request's: %v", users.loadCtxErr)
+	}
+}
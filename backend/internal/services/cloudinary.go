@@ -4,19 +4,33 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"net/http"
+	"time"
+
+	"campus-connect/internal/tracing"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
+const (
+	cloudinaryRootFolder = "campus-connect"
+	// Private download URLs must name a format, which comes from the key's
+	// extension. Private keys without one were normalised to JPEG on upload.
+	cloudinaryPrivateFormat = "jpg"
+)
+
 type CloudinaryConfig struct {
 	CloudName string
 	APIKey    string
 	APISecret string
 }
 
+// CloudinaryService is an ObjectStore backed by Cloudinary. Objects, PDFs
+// included, are stored as images under the campus-connect folder with the key
+// less its extension as public ID. Private objects use Cloudinary's "private"
+// delivery type and can only be fetched through signed, expiring download URLs.
 type CloudinaryService struct {
 	client *cloudinary.Cloudinary
 }
@@ -26,37 +40,42 @@ func NewCloudinaryService(config CloudinaryConfig) (*CloudinaryService, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Cloudinary: %w", err)
 	}
+	cld.Upload.Client = http.Client{Transport: tracing.NewTransport(nil)}
 
 	return &CloudinaryService{
 		client: cld,
 	}, nil
 }
 
-func (c *CloudinaryService) UploadProfileImage(file io.Reader, fileName string, userID string) (string, error) {
-	ctx := context.Background()
-
+func (c *CloudinaryService) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*StoredObject, error) {
 	uploadParams := uploader.UploadParams{
-		PublicID:       fmt.Sprintf("profile_images/%s", userID),
-		Folder:         "campus-connect/profiles",
-		ResourceType:   "image",
-		Overwrite:      api.Bool(true),
-		Format:         "jpg",
-		Transformation: "c_fill,w_300,h_300,q_auto:good",
+		PublicID:     c.publicID(key),
+		ResourceType: "image",
+		Overwrite:    api.Bool(true),
+	}
+	if opts.Visibility == Private {
+		uploadParams.Type = api.Private
+		uploadParams.Format = privateFormat(key)
 	}
 
-	result, err := c.client.Upload.Upload(ctx, file, uploadParams)
+	result, err := c.client.Upload.Upload(ctx, body, uploadParams)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload image to Cloudinary: %w", err)
+		return nil, fmt.Errorf("failed to upload image to Cloudinary: %w", err)
+	}
+	if result.Error.Message != "" {
+		return nil, fmt.Errorf("failed to upload image to Cloudinary: %s", result.Error.Message)
 	}
 
-	return result.SecureURL, nil
+	if opts.Visibility == Private {
+		return &StoredObject{Key: key}, nil
+	}
+	return &StoredObject{Key: key, URL: result.SecureURL}, nil
 }
 
-func (c *CloudinaryService) DeleteProfileImage(publicID string) error {
-	ctx := context.Background()
-
+func (c *CloudinaryService) Delete(ctx context.Context, key string, visibility Visibility) error {
 	_, err := c.client.Upload.Destroy(ctx, uploader.DestroyParams{
-		PublicID:     publicID,
+		PublicID:     c.publicID(key),
+		Type:         string(deliveryType(visibility)),
 		ResourceType: "image",
 	})
 
@@ -67,12 +86,50 @@ func (c *CloudinaryService) DeleteProfileImage(publicID string) error {
 	return nil
 }
 
-func (c *CloudinaryService) UploadFromMultipartFile(fileHeader *multipart.FileHeader, userID string) (string, error) {
-	file, err := fileHeader.Open()
+// URL returns the delivery URL of the image. Public Cloudinary URLs do not
+// expire, so ttl only applies to private images, which get a signed download
+// URL.
+func (c *CloudinaryService) URL(ctx context.Context, key string, visibility Visibility, ttl time.Duration) (string, error) {
+	if visibility == Private {
+		expiresAt := time.Now().Add(ttl)
+		downloadURL, err := c.client.Upload.PrivateDownloadURL(uploader.PrivateDownloadURLParams{
+			PublicID:     c.publicID(key),
+			Format:       privateFormat(key),
+			DeliveryType: string(api.Private),
+			ExpiresAt:    &expiresAt,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to sign Cloudinary URL: %w", err)
+		}
+		return downloadURL, nil
+	}
+
+	image, err := c.client.Image(c.publicID(key))
 	if err != nil {
-		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+		return "", fmt.Errorf("failed to build Cloudinary URL: %w", err)
 	}
-	defer file.Close()
+	image.Config.URL.Secure = true
+
+	return image.String()
+}
+
+func (c *CloudinaryService) publicID(key string) string {
+	name, _ := keyFormat(key)
+	return cloudinaryRootFolder + "/" + name
+}
 
-	return c.UploadProfileImage(file, fileHeader.Filename, userID)
+// privateFormat returns the format a private object is stored and delivered
+// in, keeping PDFs as PDFs.
+func privateFormat(key string) string {
+	if _, format := keyFormat(key); format != "" {
+		return format
+	}
+	return cloudinaryPrivateFormat
+}
+
+func deliveryType(visibility Visibility) api.DeliveryType {
+	if visibility == Private {
+		return api.Private
+	}
+	return api.Upload
 }
@@ -0,0 +1,525 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// DisputeConfig controls dispute deadlines. A case can be opened up to
+// FilingWindow after its delivery was delivered or cancelled. Admins have
+// ReviewWindow to pick up a new case and ResolveWindow to settle one under
+// review; a party asked for a response has ResponseWindow to give it. Missed
+// deadlines are enforced every SweepInterval.
+type DisputeConfig struct {
+	FilingWindow   time.Duration
+	ReviewWindow   time.Duration
+	ResponseWindow time.Duration
+	ResolveWindow  time.Duration
+	SweepInterval  time.Duration
+}
+
+// disputeSweepBatch caps the cases handled in one deadline sweep.
+const disputeSweepBatch = 100
+
+var errDisputeFinished = apperrors.Conflict(apperrors.CodeDisputeFinished, "Dispute is already finished")
+
+// DisputeService runs dispute cases between the requester and traveler of a
+// delivery, from filing through the admin queue to resolution.
+type DisputeService struct {
+	disputes   repositories.DisputeRepository
+	deliveries repositories.DeliveryRepository
+	trips      repositories.TripRepository
+	ledger     repositories.LedgerRepository
+	users      repositories.UserRepository
+	tx         repositories.Transactor
+	cfg        DisputeConfig
+}
+
+func NewDisputeService(
+	disputes repositories.DisputeRepository,
+	deliveries repositories.DeliveryRepository,
+	trips repositories.TripRepository,
+	ledger repositories.LedgerRepository,
+	users repositories.UserRepository,
+	tx repositories.Transactor,
+	cfg DisputeConfig,
+) *DisputeService {
+	return &DisputeService{
+		disputes:   disputes,
+		deliveries: deliveries,
+		trips:      trips,
+		ledger:     ledger,
+		users:      users,
+		tx:         tx,
+		cfg:        cfg,
+	}
+}
+
+// Open files a case by the requester or traveler of a matched delivery
+// against the other party.
+func (s *DisputeService) Open(ctx context.Context, user *models.User, req models.CreateDisputeRequest) (*models.Dispute, error) {
+	delivery, err := s.deliveries.GetByID(ctx, req.DeliveryRequestID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.MatchedTripID == nil {
+		return nil, apperrors.Conflict(apperrors.CodeRequestNotMatched, "Disputes can only be opened about matched deliveries")
+	}
+	trip, err := s.trips.GetByID(ctx, *delivery.MatchedTripID)
+	if err != nil {
+		return nil, err
+	}
+
+	var respondentID uuid.UUID
+	switch user.ID {
+	case delivery.UserID:
+		respondentID = trip.TravelerID
+	case trip.TravelerID:
+		respondentID = delivery.UserID
+	default:
+		return nil, apperrors.Forbidden(apperrors.CodeForbidden, "Only the requester or traveler can open a dispute about a delivery")
+	}
+
+	finished := delivery.Status == models.DeliveryDelivered || delivery.Status == models.DeliveryCancelled
+	if finished && time.Since(delivery.UpdatedAt) > s.cfg.FilingWindow {
+		return nil, apperrors.Conflict(apperrors.CodeDisputeWindowClosed, "The time to open a dispute about this delivery has passed")
+	}
+
+	deadline := time.Now().Add(s.cfg.ReviewWindow)
+	dispute := &models.Dispute{
+		ID:                uuid.New(),
+		DeliveryRequestID: delivery.ID,
+		OpenedBy:          user.ID,
+		RespondentID:      respondentID,
+		Category:          req.Category,
+		Description:       req.Description,
+		Status:            models.DisputeOpen,
+		DeadlineAt:        &deadline,
+	}
+	if err := s.disputes.Create(ctx, dispute); err != nil {
+		return nil, err
+	}
+	metrics.Disputes.WithLabelValues(metrics.DisputeOpened).Inc()
+
+	return s.disputes.GetByID(ctx, dispute.ID)
+}
+
+// GetCase returns a case to its parties and admins. Others get not found so
+// case IDs reveal nothing.
+func (s *DisputeService) GetCase(ctx context.Context, user *models.User, id uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.disputes.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !dispute.IsParty(user.ID) && !user.IsAdmin() {
+		return nil, apperrors.NotFound(apperrors.CodeDisputeNotFound, "Dispute not found")
+	}
+	return dispute, nil
+}
+
+// Get returns a case with its history and attachments. Internal notes and
+// the delivery's ledger transactions are shown to admins only.
+func (s *DisputeService) Get(ctx context.Context, user *models.User, id uuid.UUID) (*models.DisputeDetail, error) {
+	dispute, err := s.GetCase(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &models.DisputeDetail{Dispute: dispute}
+	if detail.Notes, err = s.disputes.ListNotes(ctx, id, user.IsAdmin()); err != nil {
+		return nil, err
+	}
+	if detail.Attachments, err = s.disputes.ListAttachments(ctx, id); err != nil {
+		return nil, err
+	}
+	if user.IsAdmin() {
+		if detail.LedgerTransactions, err = s.ledger.ListTransactions(ctx, dispute.DeliveryRequestID); err != nil {
+			return nil, err
+		}
+	}
+	return detail, nil
+}
+
+// ListForUser returns the cases the user is a party to.
+func (s *DisputeService) ListForUser(ctx context.Context, user *models.User, limit, offset int) ([]*models.Dispute, int, error) {
+	return s.disputes.ListForUser(ctx, user.ID, limit, offset)
+}
+
+// List returns the admin queue.
+func (s *DisputeService) List(ctx context.Context, filter models.DisputeFilter, limit, offset int) ([]*models.Dispute, int, error) {
+	return s.disputes.List(ctx, filter, limit, offset)
+}
+
+// AddMessage adds a note both parties and admins can see. A party's reply to
+// a case awaiting their response sends it back to review.
+func (s *DisputeService) AddMessage(ctx context.Context, user *models.User, id uuid.UUID, body string) (*models.DisputeNote, error) {
+	var note *models.DisputeNote
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		dispute, err := s.lockCase(ctx, user, id)
+		if err != nil {
+			return err
+		}
+
+		note = &models.DisputeNote{DisputeID: id, AuthorID: &user.ID, Body: body}
+		if err := s.disputes.AddNote(ctx, note); err != nil {
+			return err
+		}
+
+		if dispute.Status == models.DisputeAwaitingResponse && dispute.IsParty(user.ID) {
+			deadline := time.Now().Add(s.cfg.ResolveWindow)
+			dispute.Status = models.DisputeInReview
+			dispute.DeadlineAt = &deadline
+			return s.disputes.Update(ctx, dispute)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// AddNote adds an internal note, seen by admins only.
+func (s *DisputeService) AddNote(ctx context.Context, admin *models.User, id uuid.UUID, body string) (*models.DisputeNote, error) {
+	if _, err := s.disputes.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	note := &models.DisputeNote{DisputeID: id, AuthorID: &admin.ID, Internal: true, Body: body}
+	if err := s.disputes.AddNote(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// Assign gives a case to an admin, adminID or else the caller. Assigning an
+// open case puts it under review.
+func (s *DisputeService) Assign(ctx context.Context, admin *models.User, id uuid.UUID, adminID *uuid.UUID) (*models.Dispute, error) {
+	assigneeID := admin.ID
+	if adminID != nil {
+		assigneeID = *adminID
+	}
+	assignee, err := s.users.GetByID(ctx, assigneeID)
+	if err != nil {
+		return nil, err
+	}
+	if !assignee.IsAdmin() {
+		return nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Disputes can only be assigned to admins")
+	}
+
+	var dispute *models.Dispute
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if dispute, err = s.disputes.Lock(ctx, id); err != nil {
+			return err
+		}
+		if dispute.Status.IsFinal() {
+			return errDisputeFinished
+		}
+
+		dispute.AssignedTo = &assignee.ID
+		if dispute.Status == models.DisputeOpen {
+			deadline := time.Now().Add(s.cfg.ResolveWindow)
+			dispute.Status = models.DisputeInReview
+			dispute.DeadlineAt = &deadline
+			dispute.Escalated = false
+		}
+		if err := s.disputes.Update(ctx, dispute); err != nil {
+			return err
+		}
+		return s.disputes.AddNote(ctx, &models.DisputeNote{
+			DisputeID: id,
+			AuthorID:  &admin.ID,
+			Internal:  true,
+			Body:      fmt.Sprintf("Assigned to %s %s", assignee.FirstName, assignee.LastName),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+// UpdateStatus moves a case between review, awaiting a response and closed,
+// restarting its deadline. The admin's note, if any, is shown to the
+// parties.
+func (s *DisputeService) UpdateStatus(ctx context.Context, admin *models.User, id uuid.UUID, req models.UpdateDisputeStatusRequest) (*models.Dispute, error) {
+	var dispute *models.Dispute
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if dispute, err = s.disputes.Lock(ctx, id); err != nil {
+			return err
+		}
+		if dispute.Status.IsFinal() {
+			return errDisputeFinished
+		}
+		if !dispute.Status.CanTransitionTo(req.Status) {
+			return apperrors.Conflict(apperrors.CodeInvalidTransition,
+				fmt.Sprintf("Cannot move a dispute from %s to %s", dispute.Status, req.Status))
+		}
+
+		previous := dispute.Status
+		dispute.Status = req.Status
+		dispute.Escalated = false
+		dispute.DeadlineAt = nil
+		switch req.Status {
+		case models.DisputeInReview:
+			deadline := time.Now().Add(s.cfg.ResolveWindow)
+			dispute.DeadlineAt = &deadline
+		case models.DisputeAwaitingResponse:
+			deadline := time.Now().Add(s.cfg.ResponseWindow)
+			dispute.DeadlineAt = &deadline
+		}
+		if err := s.disputes.Update(ctx, dispute); err != nil {
+			return err
+		}
+
+		if err := s.addSystemNote(ctx, id, false, fmt.Sprintf("Status changed from %s to %s", previous, req.Status)); err != nil {
+			return err
+		}
+		if req.Note != "" {
+			return s.disputes.AddNote(ctx, &models.DisputeNote{DisputeID: id, AuthorID: &admin.ID, Body: req.Note})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status == models.DisputeClosed {
+		metrics.Disputes.WithLabelValues(metrics.DisputeClosed).Inc()
+	}
+	return dispute, nil
+}
+
+// Resolve settles a case. Refunds return the delivery fee, or part of it, to
+// the requester through the ledger; warnings and bans are recorded against a
+// party, the respondent unless another is named.
+func (s *DisputeService) Resolve(ctx context.Context, admin *models.User, id uuid.UUID, req models.ResolveDisputeRequest) (*models.Dispute, error) {
+	if req.SanctionedUserID != nil && !req.Resolution.Sanctions() {
+		return nil, apperrors.InvalidInput(apperrors.CodeValidationFailed, "sanctionedUserId is only allowed for warnings and bans")
+	}
+	if req.RefundAmount != nil && req.Resolution != models.ResolutionPartialRefund {
+		return nil, apperrors.InvalidInput(apperrors.CodeValidationFailed, "refundAmount is only allowed for partial refunds")
+	}
+
+	var dispute *models.Dispute
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if dispute, err = s.disputes.Lock(ctx, id); err != nil {
+			return err
+		}
+		if dispute.Status.IsFinal() {
+			return errDisputeFinished
+		}
+
+		switch req.Resolution {
+		case models.ResolutionRefund, models.ResolutionPartialRefund:
+			if err := s.refund(ctx, dispute, req); err != nil {
+				return err
+			}
+		case models.ResolutionWarning, models.ResolutionBan:
+			target := dispute.RespondentID
+			if req.SanctionedUserID != nil {
+				target = *req.SanctionedUserID
+			}
+			if !dispute.IsParty(target) {
+				return apperrors.InvalidInput(apperrors.CodeValidationFailed, "sanctionedUserId must be a party to the dispute")
+			}
+			if req.Resolution == models.ResolutionBan {
+				if err := s.users.Ban(ctx, target); err != nil {
+					return err
+				}
+			}
+			dispute.SanctionedUserID = &target
+		}
+
+		now := time.Now()
+		resolution := req.Resolution
+		dispute.Status = models.DisputeResolved
+		dispute.Resolution = &resolution
+		dispute.ResolutionNote = &req.Note
+		dispute.ResolvedBy = &admin.ID
+		dispute.ResolvedAt = &now
+		dispute.Escalated = false
+		dispute.DeadlineAt = nil
+		if err := s.disputes.Update(ctx, dispute); err != nil {
+			return err
+		}
+		return s.disputes.AddNote(ctx, &models.DisputeNote{
+			DisputeID: id,
+			AuthorID:  &admin.ID,
+			Body:      fmt.Sprintf("Resolved: %s. %s", req.Resolution, req.Note),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.Disputes.WithLabelValues(string(req.Resolution)).Inc()
+
+	logging.FromContext(ctx).Info("dispute resolved",
+		"dispute_id", id,
+		"resolution", req.Resolution,
+		"admin_id", admin.ID,
+	)
+	return dispute, nil
+}
+
+// refund posts the ledger transaction for a refund resolution.
+func (s *DisputeService) refund(ctx context.Context, dispute *models.Dispute, req models.ResolveDisputeRequest) error {
+	delivery, err := s.deliveries.GetByID(ctx, dispute.DeliveryRequestID)
+	if err != nil {
+		return err
+	}
+	if delivery.MatchedTripID == nil {
+		return apperrors.Conflict(apperrors.CodeNothingToRefund, "Delivery has no matched trip to refund")
+	}
+	trip, err := s.trips.GetByID(ctx, *delivery.MatchedTripID)
+	if err != nil {
+		return err
+	}
+
+	amount := models.PesewasFromCedis(delivery.PaymentAmount)
+	if req.Resolution == models.ResolutionPartialRefund {
+		if *req.RefundAmount > amount {
+			return apperrors.InvalidInput(apperrors.CodeValidationFailed,
+				fmt.Sprintf("refundAmount must be at most the delivery fee of %d pesewas", amount))
+		}
+		amount = *req.RefundAmount
+	}
+
+	txn, err := s.ledger.DisputeRefund(ctx, dispute.ID, delivery.ID, trip.TravelerID, amount)
+	if err != nil {
+		return err
+	}
+	if txn == nil {
+		return apperrors.Conflict(apperrors.CodeNothingToRefund, "The delivery fee was never paid or was already refunded")
+	}
+	dispute.RefundAmount = &amount
+	dispute.LedgerTransactionID = &txn.ID
+	return nil
+}
+
+// EnforceDeadlines acts on cases whose deadline has passed: open and
+// in-review cases are escalated, and cases awaiting a response go back to
+// review. Cases that fail are logged and skipped, to be retried on the next
+// sweep. It returns the number of cases changed.
+func (s *DisputeService) EnforceDeadlines(ctx context.Context) (int, error) {
+	ids, err := s.disputes.ListOverdue(ctx, time.Now(), disputeSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	logger := logging.FromContext(ctx)
+	changed := 0
+	for _, id := range ids {
+		var event string
+		err := s.tx.InTx(ctx, func(ctx context.Context) error {
+			dispute, err := s.disputes.Lock(ctx, id)
+			if err != nil {
+				return err
+			}
+			// An admin may have acted since the case was listed
+			if dispute.Status.IsFinal() || dispute.DeadlineAt == nil || dispute.DeadlineAt.After(time.Now()) {
+				return nil
+			}
+
+			var internal bool
+			var body string
+			switch dispute.Status {
+			case models.DisputeAwaitingResponse:
+				deadline := time.Now().Add(s.cfg.ResolveWindow)
+				dispute.Status = models.DisputeInReview
+				dispute.DeadlineAt = &deadline
+				event, body = metrics.DisputeResponseExpired, "No response was given in time; the dispute is back under review"
+			default:
+				dispute.Escalated = true
+				dispute.DeadlineAt = nil
+				event, internal = metrics.DisputeEscalated, true
+				body = fmt.Sprintf("Escalated: deadline missed while %s", dispute.Status)
+			}
+			if err := s.disputes.Update(ctx, dispute); err != nil {
+				return err
+			}
+			return s.addSystemNote(ctx, id, internal, body)
+		})
+		if err != nil {
+			// One failing case must not hold up the rest of the batch
+			logger.Error("failed to enforce dispute deadline", "dispute_id", id, "error", err)
+			metrics.Disputes.WithLabelValues(metrics.DisputeDeadlineFailed).Inc()
+			continue
+		}
+		if event != "" {
+			metrics.Disputes.WithLabelValues(event).Inc()
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// RunDeadlineEnforcer calls EnforceDeadlines every SweepInterval until ctx is
+// done.
+func (s *DisputeService) RunDeadlineEnforcer(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	logger := logging.FromContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := s.EnforceDeadlines(ctx)
+		if err != nil {
+			logger.Error("dispute deadline enforcement failed", "error", err)
+		} else if changed > 0 {
+			logger.Info("enforced dispute deadlines", "disputes", changed)
+		}
+	}
+}
+
+// lockCase locks a case a party or admin is adding to, which must not be
+// finished.
+func (s *DisputeService) lockCase(ctx context.Context, user *models.User, id uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.disputes.Lock(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !dispute.IsParty(user.ID) && !user.IsAdmin() {
+		return nil, apperrors.NotFound(apperrors.CodeDisputeNotFound, "Dispute not found")
+	}
+	if dispute.Status.IsFinal() {
+		return nil, errDisputeFinished
+	}
+	return dispute, nil
+}
+
+// CheckOpen returns a case a party or admin may add an attachment to.
+func (s *DisputeService) CheckOpen(ctx context.Context, user *models.User, id uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.GetCase(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status.IsFinal() {
+		return nil, errDisputeFinished
+	}
+	return dispute, nil
+}
+
+// AddAttachment records a file stored for a case.
+func (s *DisputeService) AddAttachment(ctx context.Context, attachment *models.DisputeAttachment) error {
+	return s.disputes.AddAttachment(ctx, attachment)
+}
+
+func (s *DisputeService) addSystemNote(ctx context.Context, id uuid.UUID, internal bool, body string) error {
+	return s.disputes.AddNote(ctx, &models.DisputeNote{DisputeID: id, Internal: internal, Body: body})
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// fakeDisputes keeps cases in a map. Locking a case in broken fails.
+type fakeDisputes struct {
+	repositories.DisputeRepository
+	cases  map[uuid.UUID]*models.Dispute
+	order  []uuid.UUID
+	broken map[uuid.UUID]bool
+	notes  []*models.DisputeNote
+}
+
+func (f *fakeDisputes) add(dispute *models.Dispute) {
+	f.cases[dispute.ID] = dispute
+	f.order = append(f.order, dispute.ID)
+}
+
+func (f *fakeDisputes) ListOverdue(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	for _, id := range f.order {
+		dispute := f.cases[id]
+		if !dispute.Status.IsFinal() && dispute.DeadlineAt != nil && dispute.DeadlineAt.Before(now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeDisputes) Lock(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	if f.broken[id] {
+		return nil, errors.New("connection reset")
+	}
+	copied := *f.cases[id]
+	return &copied, nil
+}
+
+func (f *fakeDisputes) Update(ctx context.Context, dispute *models.Dispute) error {
+	f.cases[dispute.ID] = dispute
+	return nil
+}
+
+func (f *fakeDisputes) AddNote(ctx context.Context, note *models.DisputeNote) error {
+	f.notes = append(f.notes, note)
+	return nil
+}
+
+func TestEnforceDeadlinesContinuesPastFailures(t *testing.T) {
+	disputes := &fakeDisputes{cases: map[uuid.UUID]*models.Dispute{}, broken: map[uuid.UUID]bool{}}
+	passed := time.Now().Add(-time.Hour)
+	newCase := func(status models.DisputeStatus) *models.Dispute {
+		deadline := passed
+		dispute := &models.Dispute{ID: uuid.New(), Status: status, DeadlineAt: &deadline}
+		disputes.add(dispute)
+		return dispute
+	}
+	broken := newCase(models.DisputeOpen)
+	disputes.broken[broken.ID] = true
+	open := newCase(models.DisputeOpen)
+	awaiting := newCase(models.DisputeAwaitingResponse)
+
+	service := NewDisputeService(disputes, nil, nil, nil, nil, fakeTx{}, DisputeConfig{ResolveWindow: 72 * time.Hour})
+	changed, err := service.EnforceDeadlines(context.Background())
+	if err != nil {
+		t.Fatalf("EnforceDeadlines() error = %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("EnforceDeadlines() changed %d cases, want 2", changed)
+	}
+
+	if got := disputes.cases[broken.ID]; got.Escalated {
+		t.Error("failing case was escalated")
+	}
+	if got := disputes.cases[open.ID]; !got.Escalated || got.DeadlineAt != nil {
+		t.Errorf("open case: escalated = %t, deadline = %v, want escalated without a deadline", got.Escalated, got.DeadlineAt)
+	}
+	if got := disputes.cases[awaiting.ID]; got.Status != models.DisputeInReview || got.DeadlineAt == nil || !got.DeadlineAt.After(time.Now()) {
+		t.Errorf("awaiting case: status = %s, deadline = %v, want in review with a new deadline", got.Status, got.DeadlineAt)
+	}
+	if len(disputes.notes) != 2 {
+		t.Errorf("added %d notes, want 2", len(disputes.notes))
+	}
+}
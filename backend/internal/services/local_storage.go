@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStore keeps uploads on the local filesystem. Files are served by the
+// API itself through URLs signed with an HMAC and an expiry time, so public
+// and private objects are handled the same way.
+type LocalStore struct {
+	root          string
+	publicBaseURL string
+	signingSecret []byte
+	defaultTTL    time.Duration
+}
+
+func NewLocalStore(root, publicBaseURL, signingSecret string, defaultTTL time.Duration) (*LocalStore, error) {
+	if signingSecret == "" {
+		return nil, fmt.Errorf("local storage requires a signing secret")
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalStore{
+		root:          root,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+		signingSecret: []byte(signingSecret),
+		defaultTTL:    defaultTTL,
+	}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*StoredObject, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial upload
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	if opts.Visibility == Private {
+		return &StoredObject{Key: key}, nil
+	}
+
+	signedURL, err := s.URL(ctx, key, opts.Visibility, s.defaultTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoredObject{Key: key, URL: signedURL}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string, visibility Visibility) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) URL(ctx context.Context, key string, visibility Visibility, ttl time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(key, expires))
+
+	return fmt.Sprintf("%s/files/%s?%s", s.publicBaseURL, key, query.Encode()), nil
+}
+
+// Open verifies a signed URL's expiry and signature and opens the file it
+// points at.
+func (s *LocalStore) Open(key, expires, signature string) (*os.File, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, ErrObjectNotFound
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return nil, ErrObjectNotFound
+	}
+
+	filePath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return file, nil
+}
+
+func (s *LocalStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps a key to a location under the storage root, rejecting keys that
+// would escape it.
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LockoutConfig controls how failed sign-ins are throttled. After
+// FreeAttempts failures for an account, each further attempt must wait
+// BaseDelay, doubling per failure up to MaxDelay. MaxFailures failures lock
+// the account for LockoutDuration, and IPMaxFailures failures from one IP
+// across all accounts lock that IP. Failures are forgotten LockoutDuration
+// after the first one. A zero MaxFailures disables throttling.
+type LockoutConfig struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	MaxFailures     int
+	IPMaxFailures   int
+	LockoutDuration time.Duration
+}
+
+// LoginBlock reports why sign-in attempts are currently refused.
+type LoginBlock struct {
+	// Locked is set for a lockout, as opposed to a progressive delay.
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (b LoginBlock) Blocked() bool {
+	return b.RetryAfter > 0
+}
+
+// LoginThrottle tracks failed sign-ins per account and per IP in Redis.
+type LoginThrottle struct {
+	redisClient *redis.Client
+	cfg         LockoutConfig
+}
+
+func NewLoginThrottle(rdb *redis.Client, cfg LockoutConfig) *LoginThrottle {
+	return &LoginThrottle{redisClient: rdb, cfg: cfg}
+}
+
+// loginAttemptScript refuses a sign-in attempt while the account or IP is
+// locked or the account must wait, and otherwise counts the attempt as a
+// failure in the same step, so parallel guesses cannot all get in before
+// any is counted. ARGV[5..] are the waits, in milliseconds, after each
+// failure beyond the free ones. Returns {locked, retry_after_ms}.
+var loginAttemptScript = redis.NewScript(`
+local lock = math.max(redis.call('PTTL', KEYS[1]), redis.call('PTTL', KEYS[2]))
+if lock > 0 then
+	return {1, lock}
+end
+local wait = redis.call('PTTL', KEYS[3])
+if wait > 0 then
+	return {0, wait}
+end
+
+local lockout = tonumber(ARGV[1])
+local max_failures = tonumber(ARGV[2])
+local ip_max_failures = tonumber(ARGV[3])
+local free = tonumber(ARGV[4])
+
+local failures = redis.call('INCR', KEYS[4])
+if failures == 1 then
+	redis.call('PEXPIRE', KEYS[4], lockout)
+end
+local ip_failures = redis.call('INCR', KEYS[5])
+if ip_failures == 1 then
+	redis.call('PEXPIRE', KEYS[5], lockout)
+end
+
+if failures >= max_failures then
+	redis.call('SET', KEYS[1], 1, 'PX', lockout)
+elseif failures > free then
+	local wait_ms = tonumber(ARGV[4 + failures - free])
+	if wait_ms > 0 then
+		redis.call('SET', KEYS[3], 1, 'PX', wait_ms)
+	end
+end
+if ip_max_failures > 0 and ip_failures >= ip_max_failures then
+	redis.call('SET', KEYS[2], 1, 'PX', lockout)
+end
+return {0, 0}
+`)
+
+// loginSuccessScript clears an account's failures and takes one attempt
+// back off the IP's count, leaving the count's expiry alone.
+var loginSuccessScript = redis.NewScript(`
+redis.call('DEL', KEYS[1], KEYS[2], KEYS[3])
+if tonumber(redis.call('GET', KEYS[4]) or '0') > 0 then
+	redis.call('DECR', KEYS[4])
+end
+return 1
+`)
+
+// Attempt reports whether a sign-in attempt for email from ip must be
+// refused. An attempt that is let through counts as a failure until
+// RecordSuccess says otherwise.
+func (t *LoginThrottle) Attempt(ctx context.Context, email, ip string) (LoginBlock, error) {
+	if t.cfg.MaxFailures <= 0 {
+		return LoginBlock{}, nil
+	}
+	email = strings.ToLower(email)
+
+	args := []interface{}{t.cfg.LockoutDuration.Milliseconds(), t.cfg.MaxFailures, t.cfg.IPMaxFailures, t.cfg.FreeAttempts}
+	for failures := t.cfg.FreeAttempts + 1; failures < t.cfg.MaxFailures; failures++ {
+		args = append(args, t.delay(failures).Milliseconds())
+	}
+	values, err := loginAttemptScript.Run(ctx, t.redisClient, []string{
+		"login:lock:acct:" + email,
+		"login:lock:ip:" + ip,
+		"login:wait:acct:" + email,
+		"login:fail:acct:" + email,
+		"login:fail:ip:" + ip,
+	}, args...).Int64Slice()
+	if err != nil {
+		return LoginBlock{}, fmt.Errorf("failed to check login attempt: %w", err)
+	}
+	if len(values) != 2 {
+		return LoginBlock{}, fmt.Errorf("failed to check login attempt: unexpected script result %v", values)
+	}
+	return LoginBlock{Locked: values[0] == 1, RetryAfter: time.Duration(values[1]) * time.Millisecond}, nil
+}
+
+// RecordSuccess clears the failures counted against email, including the
+// attempt that succeeded, and takes that attempt back off the IP's count.
+// Earlier failures counted against the IP stand, so one valid account cannot
+// be used to reset them.
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, email, ip string) error {
+	if t.cfg.MaxFailures <= 0 {
+		return nil
+	}
+	email = strings.ToLower(email)
+	err := loginSuccessScript.Run(ctx, t.redisClient, []string{
+		"login:fail:acct:" + email,
+		"login:wait:acct:" + email,
+		"login:lock:acct:" + email,
+		"login:fail:ip:" + ip,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+// delay is how long the account must wait after its failures'th failure.
+func (t *LoginThrottle) delay(failures int) time.Duration {
+	d := t.cfg.BaseDelay
+	for i := t.cfg.FreeAttempts + 1; i < failures && d < t.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, t.cfg.MaxDelay)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedis connects to the disposable Redis named by TEST_REDIS_URL, or
+// skips the test when it is not set. Tests use their own keys, so they can
+// share the server.
+func testRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("parse TEST_REDIS_URL: %v", err)
+	}
+	client := redis.NewClient(opts)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestLoginThrottleDelay(t *testing.T) {
+	throttle := NewLoginThrottle(nil, LockoutConfig{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		MaxFailures:  10,
+	})
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 4, want: time.Second},
+		{failures: 5, want: 2 * time.Second},
+		{failures: 6, want: 4 * time.Second},
+		{failures: 9, want: 32 * time.Second},
+		{failures: 10, want: time.Minute},
+		{failures: 40, want: time.Minute},
+	}
+	for _, tt := range tests {
+		if got := throttle.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleAttempt(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+
+	// attempts is a sequence of sign-ins, each for an account and IP of the
+	// test's own; a true succeeded attempt is reported as a success
+	type attempt struct {
+		account, ip int
+		succeeded   bool
+		wait        time.Duration
+		wantBlocked bool
+		wantLocked  bool
+	}
+	tests := []struct {
+		name     string
+		cfg      LockoutConfig
+		attempts []attempt
+	}{
+		{
+			name:     "free attempts then a wait",
+			cfg:      LockoutConfig{FreeAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour, MaxFailures: 10, LockoutDuration: time.Hour},
+			attempts: []attempt{{}, {}, {}, {wantBlocked: true}},
+		},
+		{
+			name:     "wait passes",
+			cfg:      LockoutConfig{FreeAttempts: 0, BaseDelay: 50 * time.Millisecond, MaxDelay: 50 * time.Millisecond, MaxFailures: 10, LockoutDuration: time.Hour},
+			attempts: []attempt{{}, {wantBlocked: true}, {wait: 100 * time.Millisecond}},
+		},
+		{
+			name:     "account locked",
+			cfg:      LockoutConfig{FreeAttempts: 10, MaxFailures: 3, LockoutDuration: time.Hour},
+			attempts: []attempt{{}, {}, {}, {wantBlocked: true, wantLocked: true}, {ip: 1, wantBlocked: true, wantLocked: true}},
+		},
+		{
+			name:     "success clears the account",
+			cfg:      LockoutConfig{FreeAttempts: 10, MaxFailures: 3, LockoutDuration: time.Hour},
+			attempts: []attempt{{}, {}, {succeeded: true}, {}, {}, {}, {wantBlocked: true, wantLocked: true}},
+		},
+		{
+			name:     "IP locked across accounts",
+			cfg:      LockoutConfig{FreeAttempts: 10, MaxFailures: 10, IPMaxFailures: 3, LockoutDuration: time.Hour},
+			attempts: []attempt{{account: 0}, {account: 1}, {account: 2}, {account: 3, wantBlocked: true, wantLocked: true}, {account: 3, ip: 1}},
+		},
+		{
+			name:     "successes do not count against the IP",
+			cfg:      LockoutConfig{FreeAttempts: 10, MaxFailures: 10, IPMaxFailures: 2, LockoutDuration: time.Hour},
+			attempts: []attempt{{account: 0, succeeded: true}, {account: 1, succeeded: true}, {account: 2}, {account: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := NewLoginThrottle(rdb, tt.cfg)
+			prefix := uuid.NewString()
+			email := func(i int) string { return prefix + "-" + string(rune('a'+i)) + "@st.knust.edu.gh" }
+			ip := func(i int) string { return prefix + "-ip-" + string(rune('a'+i)) }
+
+			for i, a := range tt.attempts {
+				time.Sleep(a.wait)
+				block, err := throttle.Attempt(ctx, email(a.account), ip(a.ip))
+				if err != nil {
+					t.Fatalf("attempt %d: Attempt() error = %v", i, err)
+				}
+				if block.Blocked() != a.wantBlocked || block.Locked != a.wantLocked {
+					t.Errorf("attempt %d: Attempt() = %+v, want blocked %t, locked %t", i, block, a.wantBlocked, a.wantLocked)
+				}
+				if a.succeeded {
+					if err := throttle.RecordSuccess(ctx, email(a.account), ip(a.ip)); err != nil {
+						t.Fatalf("attempt %d: RecordSuccess() error = %v", i, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLoginThrottleParallelAttempts(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	throttle := NewLoginThrottle(rdb, LockoutConfig{
+		FreeAttempts:    3,
+		BaseDelay:       time.Hour,
+		MaxDelay:        time.Hour,
+		MaxFailures:     10,
+		LockoutDuration: time.Hour,
+	})
+	email := uuid.NewString() + "@st.knust.edu.gh"
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	allowed := 0
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			block, err := throttle.Attempt(ctx, email, uuid.NewString())
+			if err != nil {
+				t.Errorf("Attempt() error = %v", err)
+				return
+			}
+			if !block.Blocked() {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The free attempts and the one that starts the wait get through
+	if allowed != 4 {
+		t.Errorf("%d parallel attempts allowed, want 4", allowed)
+	}
+}
@@ -0,0 +1,446 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+	"campus-connect/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrProviderUnavailable wraps failures to reach the payment provider or
+	// server errors from it. The state of the charge is unknown.
+	ErrProviderUnavailable = errors.New("payment provider unavailable")
+	// ErrChargeDeclined wraps the provider refusing a charge or OTP.
+	ErrChargeDeclined = errors.New("charge declined")
+	// ErrChargeNotFound is returned when the provider has no charge with
+	// the reference.
+	ErrChargeNotFound = errors.New("charge not found")
+	// ErrInvalidWebhookSignature is returned for webhooks that were not
+	// signed by the provider.
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// DeclineError carries the provider's explanation for a declined charge.
+type DeclineError struct {
+	Reason string
+}
+
+func (e *DeclineError) Error() string {
+	return "charge declined: " + e.Reason
+}
+
+func (e *DeclineError) Unwrap() error {
+	return ErrChargeDeclined
+}
+
+// PaymentProvider charges mobile money wallets.
+type PaymentProvider interface {
+	Name() string
+	// InitiateCharge starts a charge. The payer usually approves it with a
+	// prompt on their phone, so the result is normally still pending.
+	InitiateCharge(ctx context.Context, req ChargeRequest) (*ChargeResult, error)
+	// SubmitOTP continues a charge that needs a one-time code from the payer.
+	SubmitOTP(ctx context.Context, reference, otp string) (*ChargeResult, error)
+	// VerifyCharge fetches the current state of a charge.
+	VerifyCharge(ctx context.Context, reference string) (*ChargeResult, error)
+	// ParseWebhook authenticates a webhook and returns the charge it reports
+	// on, or nil for events that are not about charges.
+	ParseWebhook(body []byte, header http.Header) (*ChargeResult, error)
+}
+
+type ChargeRequest struct {
+	Reference   string
+	Amount      models.Pesewas
+	Currency    string
+	Email       string
+	Network     models.MobileMoneyNetwork
+	PhoneNumber string // E.164
+	Metadata    map[string]string
+}
+
+// ChargeResult is the state of a charge at the provider.
+type ChargeResult struct {
+	Reference string
+	Status    models.PaymentStatus
+	Amount    models.Pesewas
+	Currency  string
+	// DisplayText tells the payer what to do next, e.g. approve a prompt.
+	DisplayText   string
+	FailureReason string
+	PaidAt        *time.Time
+}
+
+// PaymentConfig controls mobile money payments. Charges still pending
+// ReconcileAfter after they were started are checked with the provider every
+// ReconcileInterval, and marked abandoned once AbandonAfter has passed.
+type PaymentConfig struct {
+	Currency          string
+	ReconcileInterval time.Duration
+	ReconcileAfter    time.Duration
+	AbandonAfter      time.Duration
+}
+
+// errPaymentFinished rolls back settlement of a payment that another request
+// finished first.
+var errPaymentFinished = errors.New("payment already finished")
+
+// PaymentService charges requesters for matched deliveries and settles the
+// charges, crediting their ledger wallet and holding the fee in escrow, as the
+// provider reports on them.
+type PaymentService struct {
+	provider   PaymentProvider
+	payments   repositories.PaymentRepository
+	deliveries repositories.DeliveryRepository
+	trips      repositories.TripRepository
+	ledger     repositories.LedgerRepository
+	tx         repositories.Transactor
+	cfg        PaymentConfig
+}
+
+func NewPaymentService(
+	provider PaymentProvider,
+	payments repositories.PaymentRepository,
+	deliveries repositories.DeliveryRepository,
+	trips repositories.TripRepository,
+	ledger repositories.LedgerRepository,
+	tx repositories.Transactor,
+	cfg PaymentConfig,
+) *PaymentService {
+	return &PaymentService{
+		provider:   provider,
+		payments:   payments,
+		deliveries: deliveries,
+		trips:      trips,
+		ledger:     ledger,
+		tx:         tx,
+		cfg:        cfg,
+	}
+}
+
+// Initiate charges user's mobile money wallet for the fee of one of their
+// matched delivery requests.
+func (s *PaymentService) Initiate(ctx context.Context, user *models.User, req models.InitiatePaymentRequest) (*models.Payment, error) {
+	phone, err := utils.NormalizeGhanaPhone(req.PhoneNumber)
+	if err != nil {
+		return nil, apperrors.InvalidInput(apperrors.CodeInvalidPhoneNumber, "Invalid Ghanaian phone number")
+	}
+
+	delivery, err := s.deliveries.GetByID(ctx, req.DeliveryRequestID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.UserID != user.ID {
+		return nil, apperrors.Forbidden(apperrors.CodeNotRequestOwner, "You can only pay for your own delivery requests")
+	}
+	if delivery.MatchedTripID == nil || delivery.Status == models.DeliveryCancelled {
+		return nil, apperrors.Conflict(apperrors.CodeRequestNotMatched, "Delivery request must be matched before it can be paid for")
+	}
+	amount := models.PesewasFromCedis(delivery.PaymentAmount)
+	if amount <= 0 {
+		return nil, apperrors.InvalidInput(apperrors.CodeBadRequest, "Delivery request has no fee to pay")
+	}
+
+	id := uuid.New()
+	payment := &models.Payment{
+		ID:                id,
+		DeliveryRequestID: delivery.ID,
+		UserID:            user.ID,
+		Provider:          s.provider.Name(),
+		Reference:         "cc-" + id.String(),
+		Amount:            amount,
+		Currency:          s.cfg.Currency,
+		Network:           req.Network,
+		PhoneNumber:       phone,
+		Status:            models.PaymentPending,
+	}
+	if err := s.payments.Create(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	result, err := s.provider.InitiateCharge(ctx, ChargeRequest{
+		Reference:   payment.Reference,
+		Amount:      payment.Amount,
+		Currency:    payment.Currency,
+		Email:       user.Email,
+		Network:     payment.Network,
+		PhoneNumber: payment.PhoneNumber,
+		Metadata: map[string]string{
+			"delivery_request_id": delivery.ID.String(),
+			"user_id":             user.ID.String(),
+		},
+	})
+	if errors.Is(err, ErrChargeDeclined) {
+		result = &ChargeResult{Reference: payment.Reference, Status: models.PaymentFailed, FailureReason: declineReason(err)}
+	} else if err != nil {
+		// Fail the payment so the payer can try again. Should the charge
+		// have reached the provider and go through, its webhook still
+		// credits the payer
+		failed := &ChargeResult{Reference: payment.Reference, Status: models.PaymentFailed, FailureReason: "Charge could not be started"}
+		if _, applyErr := s.apply(ctx, payment, failed); applyErr != nil {
+			logging.FromContext(ctx).Error("failed to mark payment failed", "reference", payment.Reference, "error", applyErr)
+		}
+		return nil, fmt.Errorf("failed to initiate charge %s: %w", payment.Reference, err)
+	}
+
+	if payment, err = s.apply(ctx, payment, result); err != nil {
+		return nil, err
+	}
+	if payment.Status == models.PaymentFailed {
+		return nil, apperrors.InvalidInput(apperrors.CodePaymentDeclined, "Payment was declined: "+*payment.FailureReason)
+	}
+	return payment, nil
+}
+
+// SubmitOTP passes the one-time code the payer received to the provider.
+func (s *PaymentService) SubmitOTP(ctx context.Context, user *models.User, reference, otp string) (*models.Payment, error) {
+	payment, err := s.Get(ctx, user, reference)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status.IsFinal() {
+		return nil, apperrors.Conflict(apperrors.CodePaymentFinished, "Payment is already "+string(payment.Status))
+	}
+
+	result, err := s.provider.SubmitOTP(ctx, reference, otp)
+	if errors.Is(err, ErrChargeDeclined) {
+		return nil, apperrors.InvalidInput(apperrors.CodeInvalidCode, declineReason(err))
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to submit OTP for %s: %w", reference, err)
+	}
+	return s.apply(ctx, payment, result)
+}
+
+// Get returns one of user's payments.
+func (s *PaymentService) Get(ctx context.Context, user *models.User, reference string) (*models.Payment, error) {
+	payment, err := s.payments.GetByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if payment.UserID != user.ID {
+		return nil, apperrors.NotFound(apperrors.CodePaymentNotFound, "Payment not found")
+	}
+	return payment, nil
+}
+
+// HandleWebhook settles the charge a provider webhook reports on. Repeated
+// and out-of-date webhooks change nothing.
+func (s *PaymentService) HandleWebhook(ctx context.Context, body []byte, header http.Header) error {
+	result, err := s.provider.ParseWebhook(body, header)
+	if err != nil || result == nil {
+		return err
+	}
+
+	payment, err := s.payments.GetByReference(ctx, result.Reference)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			// Not one of ours, e.g. a charge made from the provider dashboard
+			logging.FromContext(ctx).Warn("webhook for unknown payment", "reference", result.Reference)
+			return nil
+		}
+		return err
+	}
+	_, err = s.apply(ctx, payment, result)
+	return err
+}
+
+// Reconcile checks pending charges with the provider, settling those it has
+// finished and abandoning those the payer never approved. It returns the
+// number of payments settled.
+func (s *PaymentService) Reconcile(ctx context.Context) (int, error) {
+	now := time.Now()
+	pending, err := s.payments.ListPending(ctx, now.Add(-s.cfg.ReconcileAfter), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	logger := logging.FromContext(ctx)
+	settled := 0
+	for _, payment := range pending {
+		result, err := s.provider.VerifyCharge(ctx, payment.Reference)
+		switch {
+		case errors.Is(err, ErrChargeNotFound):
+			// Starting the charge failed before the provider recorded it
+			result = &ChargeResult{
+				Reference:     payment.Reference,
+				Status:        models.PaymentAbandoned,
+				FailureReason: "Charge did not reach the payment provider",
+			}
+		case err != nil:
+			logger.Warn("failed to verify pending charge", "reference", payment.Reference, "error", err)
+			continue
+		}
+
+		if !result.Status.IsFinal() && now.Sub(payment.CreatedAt) > s.cfg.AbandonAfter {
+			result.Status = models.PaymentAbandoned
+			result.FailureReason = "Charge was not approved in time"
+		}
+
+		updated, err := s.apply(ctx, payment, result)
+		if err != nil {
+			logger.Warn("failed to settle pending charge", "reference", payment.Reference, "error", err)
+			continue
+		}
+		if updated.Status.IsFinal() {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+// RunReconciler calls Reconcile every ReconcileInterval until ctx is done.
+func (s *PaymentService) RunReconciler(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ReconcileInterval)
+	defer ticker.Stop()
+
+	logger := logging.FromContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		settled, err := s.Reconcile(ctx)
+		if err != nil {
+			logger.Error("payment reconciliation failed", "error", err)
+		} else if settled > 0 {
+			logger.Info("reconciled pending payments", "settled", settled)
+		}
+	}
+}
+
+// apply records the provider's view of a charge. Finishing a payment and
+// crediting the payer's wallet happen together, and only once. Money the
+// provider collected is always credited, even after the payment failed or
+// was abandoned; a charge for a different amount than asked is left pending
+// for an admin.
+func (s *PaymentService) apply(ctx context.Context, payment *models.Payment, result *ChargeResult) (*models.Payment, error) {
+	logger := logging.FromContext(ctx)
+	late := payment.Status.IsFinal() && result.Status == models.PaymentSucceeded
+	if payment.Status.IsFinal() && (!late || payment.LedgerTransactionID != nil) {
+		return payment, nil
+	}
+
+	if result.Status == models.PaymentSucceeded && (result.Amount != payment.Amount || result.Currency != payment.Currency) {
+		// Money moved, but not what we asked for. Alert until an admin
+		// settles it with the provider; the payment stays as it is
+		logger.Error("charge amount does not match payment",
+			"reference", payment.Reference, "status", payment.Status,
+			"expected", payment.Amount, "expected_currency", payment.Currency,
+			"charged", result.Amount, "charged_currency", result.Currency)
+		metrics.PaymentAnomalies.WithLabelValues("amount_mismatch").Inc()
+		return payment, nil
+	}
+
+	if !result.Status.IsFinal() {
+		if result.DisplayText == "" {
+			return payment, nil
+		}
+		if err := s.payments.UpdatePending(ctx, payment.Reference, &result.DisplayText); err != nil {
+			return nil, err
+		}
+		payment.DisplayText = &result.DisplayText
+		return payment, nil
+	}
+
+	finished := *payment
+	finished.Status = result.Status
+	finished.FailureReason = nil
+	if result.FailureReason != "" {
+		finished.FailureReason = &result.FailureReason
+	}
+	if result.Status == models.PaymentSucceeded {
+		paidAt := time.Now()
+		if result.PaidAt != nil {
+			paidAt = *result.PaidAt
+		}
+		finished.PaidAt = &paidAt
+	}
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if finished.Status == models.PaymentSucceeded {
+			txn, err := s.ledger.Deposit(ctx, payment.DeliveryRequestID, payment.UserID, payment.Amount, payment.Reference)
+			if err != nil {
+				return err
+			}
+			finished.LedgerTransactionID = &txn.ID
+			if err := s.fund(ctx, payment); err != nil {
+				return err
+			}
+		}
+		ok, err := s.payments.Finish(ctx, &finished)
+		if err == nil && !ok {
+			err = errPaymentFinished
+		}
+		return err
+	})
+	if errors.Is(err, errPaymentFinished) {
+		return s.payments.GetByReference(ctx, payment.Reference)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if late {
+		// The payer may have paid again meanwhile; if so the payment keeps
+		// its status and the second charge should be refunded
+		logger.Error("charge succeeded after its payment finished",
+			"reference", payment.Reference, "previous_status", payment.Status, "status", finished.Status)
+		metrics.PaymentAnomalies.WithLabelValues("late_success").Inc()
+	}
+	if !late || finished.Status == models.PaymentSucceeded {
+		metrics.Payments.WithLabelValues(string(finished.Status)).Inc()
+	}
+	return &finished, nil
+}
+
+// fund holds a paid fee in escrow for its delivery request, releasing it to
+// the traveler at once if the delivery was already made. Fees for cancelled
+// requests stay in the payer's wallet.
+func (s *PaymentService) fund(ctx context.Context, payment *models.Payment) error {
+	delivery, err := s.deliveries.GetByID(ctx, payment.DeliveryRequestID)
+	if err != nil {
+		return err
+	}
+	switch delivery.Status {
+	case models.DeliveryMatched, models.DeliveryInTransit, models.DeliveryDelivered:
+	default:
+		return nil
+	}
+	// Locks the request, so it cannot be cancelled or delivered before the
+	// hold; if it changed since it was read, settlement is retried later
+	if err := s.deliveries.TransitionStatus(ctx, delivery.ID, delivery.Status, delivery.Status); err != nil {
+		return err
+	}
+	if _, err := s.ledger.Hold(ctx, delivery.ID, payment.UserID, payment.Amount); err != nil {
+		return err
+	}
+	if delivery.Status != models.DeliveryDelivered || delivery.MatchedTripID == nil {
+		return nil
+	}
+	trip, err := s.trips.GetByID(ctx, *delivery.MatchedTripID)
+	if err != nil {
+		return err
+	}
+	_, err = s.ledger.Release(ctx, delivery.ID, trip.TravelerID)
+	return err
+}
+
+func declineReason(err error) string {
+	var declined *DeclineError
+	if errors.As(err, &declined) && declined.Reason != "" {
+		return declined.Reason
+	}
+	return "Charge was declined"
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// fakePayments keeps payments in memory with the repository's rules for
+// finishing them.
+type fakePayments struct {
+	repositories.PaymentRepository
+	byReference map[string]*models.Payment
+}
+
+func (f *fakePayments) GetByReference(ctx context.Context, reference string) (*models.Payment, error) {
+	payment, ok := f.byReference[reference]
+	if !ok {
+		return nil, apperrors.NotFound(apperrors.CodePaymentNotFound, "Payment not found")
+	}
+	copied := *payment
+	return &copied, nil
+}
+
+func (f *fakePayments) UpdatePending(ctx context.Context, reference string, displayText *string) error {
+	if payment := f.byReference[reference]; payment.Status == models.PaymentPending {
+		payment.DisplayText = displayText
+	}
+	return nil
+}
+
+func (f *fakePayments) Finish(ctx context.Context, payment *models.Payment) (bool, error) {
+	stored := f.byReference[payment.Reference]
+	late := (stored.Status == models.PaymentFailed || stored.Status == models.PaymentAbandoned) &&
+		payment.Status == models.PaymentSucceeded && stored.LedgerTransactionID == nil
+	if stored.Status != models.PaymentPending && !late {
+		return false, nil
+	}
+
+	status := payment.Status
+	if late {
+		for _, other := range f.byReference {
+			if other != stored && other.DeliveryRequestID == stored.DeliveryRequestID &&
+				(other.Status == models.PaymentPending || other.Status == models.PaymentSucceeded) {
+				status = stored.Status
+			}
+		}
+	}
+	if status == payment.Status {
+		stored.FailureReason = payment.FailureReason
+	}
+	stored.Status = status
+	stored.LedgerTransactionID = payment.LedgerTransactionID
+	stored.PaidAt = payment.PaidAt
+	payment.Status = status
+	return true, nil
+}
+
+// fakeLedger records deposits by reference and the escrow of each delivery.
+type fakeLedger struct {
+	repositories.LedgerRepository
+	deposits map[string]*models.LedgerTransaction
+	held     map[uuid.UUID]models.Pesewas
+	released map[uuid.UUID]uuid.UUID
+}
+
+func (f *fakeLedger) Deposit(ctx context.Context, deliveryID, payerID uuid.UUID, amount models.Pesewas, reference string) (*models.LedgerTransaction, error) {
+	if txn, ok := f.deposits[reference]; ok {
+		return txn, nil
+	}
+	txn := &models.LedgerTransaction{ID: uuid.New(), Kind: models.LedgerDeposit}
+	f.deposits[reference] = txn
+	return txn, nil
+}
+
+func (f *fakeLedger) Hold(ctx context.Context, deliveryID, payerID uuid.UUID, amount models.Pesewas) (*models.LedgerTransaction, error) {
+	if f.held[deliveryID] == 0 {
+		f.held[deliveryID] = amount
+	}
+	return &models.LedgerTransaction{ID: uuid.New(), Kind: models.LedgerHold}, nil
+}
+
+func (f *fakeLedger) Release(ctx context.Context, deliveryID, travelerID uuid.UUID) (*models.LedgerTransaction, error) {
+	if f.held[deliveryID] == 0 {
+		return nil, nil
+	}
+	f.held[deliveryID] = 0
+	f.released[deliveryID] = travelerID
+	return &models.LedgerTransaction{ID: uuid.New(), Kind: models.LedgerRelease}, nil
+}
+
+type fakeDeliveries struct {
+	repositories.DeliveryRepository
+	requests map[uuid.UUID]*models.DeliveryRequest
+}
+
+func (f *fakeDeliveries) GetByID(ctx context.Context, id uuid.UUID) (*models.DeliveryRequest, error) {
+	request, ok := f.requests[id]
+	if !ok {
+		return nil, apperrors.NotFound(apperrors.CodeRequestNotFound, "Delivery request not found")
+	}
+	copied := *request
+	return &copied, nil
+}
+
+func (f *fakeDeliveries) TransitionStatus(ctx context.Context, id uuid.UUID, from, to models.DeliveryStatus) error {
+	request := f.requests[id]
+	if request.Status != from {
+		return apperrors.Conflict(apperrors.CodeInvalidTransition, "Delivery request status has changed, please reload")
+	}
+	request.Status = to
+	return nil
+}
+
+type fakeTrips struct {
+	repositories.TripRepository
+	trips map[uuid.UUID]*models.Trip
+}
+
+func (f *fakeTrips) GetByID(ctx context.Context, id uuid.UUID) (*models.Trip, error) {
+	return f.trips[id], nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// paymentFixture is a payment service with one matched delivery request and
+// its pending payment.
+type paymentFixture struct {
+	service  *PaymentService
+	payments *fakePayments
+	ledger   *fakeLedger
+	delivery *models.DeliveryRequest
+	trip     *models.Trip
+	payment  *models.Payment
+}
+
+func newPaymentFixture(t *testing.T) *paymentFixture {
+	t.Helper()
+	trip := &models.Trip{ID: uuid.New(), TravelerID: uuid.New()}
+	delivery := &models.DeliveryRequest{
+		ID:            uuid.New(),
+		UserID:        uuid.New(),
+		PaymentAmount: 15,
+		Status:        models.DeliveryMatched,
+		MatchedTripID: &trip.ID,
+	}
+	payment := &models.Payment{
+		ID:                uuid.New(),
+		DeliveryRequestID: delivery.ID,
+		UserID:            delivery.UserID,
+		Reference:         "cc-" + uuid.NewString(),
+		Amount:            1500,
+		Currency:          "GHS",
+		Status:            models.PaymentPending,
+		CreatedAt:         time.Now(),
+	}
+
+	f := &paymentFixture{
+		payments: &fakePayments{byReference: map[string]*models.Payment{payment.Reference: payment}},
+		ledger: &fakeLedger{
+			deposits: map[string]*models.LedgerTransaction{},
+			held:     map[uuid.UUID]models.Pesewas{},
+			released: map[uuid.UUID]uuid.UUID{},
+		},
+		delivery: delivery,
+		trip:     trip,
+		payment:  payment,
+	}
+	f.service = NewPaymentService(
+		NewPaystackProvider("", testPaystackSecret),
+		f.payments,
+		&fakeDeliveries{requests: map[uuid.UUID]*models.DeliveryRequest{delivery.ID: delivery}},
+		&fakeTrips{trips: map[uuid.UUID]*models.Trip{trip.ID: trip}},
+		f.ledger,
+		fakeTx{},
+		PaymentConfig{Currency: "GHS"},
+	)
+	return f
+}
+
+// webhook delivers a signed Paystack webhook for the fixture's payment.
+func (f *paymentFixture) webhook(t *testing.T, status string, amount int64) error {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"event": "charge." + status,
+		"data": map[string]interface{}{
+			"reference": f.payment.Reference,
+			"status":    status,
+			"amount":    amount,
+			"currency":  "GHS",
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal webhook: %v", err)
+	}
+	return f.service.HandleWebhook(context.Background(), body, signedHeader(testPaystackSecret, body))
+}
+
+func TestHandleWebhook(t *testing.T) {
+	type event struct {
+		status string
+		amount int64
+	}
+	tests := []struct {
+		name           string
+		deliveryStatus models.DeliveryStatus
+		events         []event
+		wantStatus     models.PaymentStatus
+		wantDeposits   int
+		wantHeld       models.Pesewas
+		wantReleased   bool
+	}{
+		{
+			name:         "success",
+			events:       []event{{"success", 1500}},
+			wantStatus:   models.PaymentSucceeded,
+			wantDeposits: 1,
+			wantHeld:     1500,
+		},
+		{
+			name:         "repeated success",
+			events:       []event{{"success", 1500}, {"success", 1500}, {"success", 1500}},
+			wantStatus:   models.PaymentSucceeded,
+			wantDeposits: 1,
+			wantHeld:     1500,
+		},
+		{
+			name:         "failure after success",
+			events:       []event{{"success", 1500}, {"failed", 1500}},
+			wantStatus:   models.PaymentSucceeded,
+			wantDeposits: 1,
+			wantHeld:     1500,
+		},
+		{
+			name:         "success after abandonment",
+			events:       []event{{"abandoned", 1500}, {"success", 1500}},
+			wantStatus:   models.PaymentSucceeded,
+			wantDeposits: 1,
+			wantHeld:     1500,
+		},
+		{
+			name:       "success for another amount",
+			events:     []event{{"success", 1000}},
+			wantStatus: models.PaymentPending,
+		},
+		{
+			name:           "success after delivery",
+			deliveryStatus: models.DeliveryDelivered,
+			events:         []event{{"success", 1500}},
+			wantStatus:     models.PaymentSucceeded,
+			wantDeposits:   1,
+			wantReleased:   true,
+		},
+		{
+			name:           "success after cancellation",
+			deliveryStatus: models.DeliveryCancelled,
+			events:         []event{{"success", 1500}},
+			wantStatus:     models.PaymentSucceeded,
+			wantDeposits:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPaymentFixture(t)
+			if tt.deliveryStatus != "" {
+				f.delivery.Status = tt.deliveryStatus
+			}
+
+			for _, e := range tt.events {
+				if err := f.webhook(t, e.status, e.amount); err != nil {
+					t.Fatalf("HandleWebhook(%s) error = %v", e.status, err)
+				}
+			}
+
+			if got := f.payments.byReference[f.payment.Reference].Status; got != tt.wantStatus {
+				t.Errorf("payment status = %s, want %s", got, tt.wantStatus)
+			}
+			if got := len(f.ledger.deposits); got != tt.wantDeposits {
+				t.Errorf("deposits = %d, want %d", got, tt.wantDeposits)
+			}
+			if got := f.ledger.held[f.delivery.ID]; got != tt.wantHeld {
+				t.Errorf("held = %s, want %s", got, tt.wantHeld)
+			}
+			if traveler, ok := f.ledger.released[f.delivery.ID]; ok != tt.wantReleased || (ok && traveler != f.trip.TravelerID) {
+				t.Errorf("released to %v (%t), want released: %t", traveler, ok, tt.wantReleased)
+			}
+		})
+	}
+}
+
+func TestHandleWebhookLateSuccessAfterRepayment(t *testing.T) {
+	f := newPaymentFixture(t)
+	if err := f.webhook(t, "abandoned", 1500); err != nil {
+		t.Fatalf("HandleWebhook(abandoned) error = %v", err)
+	}
+	retry := &models.Payment{
+		ID:                uuid.New(),
+		DeliveryRequestID: f.delivery.ID,
+		UserID:            f.delivery.UserID,
+		Reference:         "cc-" + uuid.NewString(),
+		Amount:            1500,
+		Currency:          "GHS",
+		Status:            models.PaymentPending,
+	}
+	f.payments.byReference[retry.Reference] = retry
+
+	if err := f.webhook(t, "success", 1500); err != nil {
+		t.Fatalf("HandleWebhook(success) error = %v", err)
+	}
+
+	// The money is credited, but the retry keeps its place
+	stored := f.payments.byReference[f.payment.Reference]
+	if stored.Status != models.PaymentAbandoned || stored.LedgerTransactionID == nil {
+		t.Errorf("late payment = %s with ledger transaction %v, want abandoned with one", stored.Status, stored.LedgerTransactionID)
+	}
+	if got := len(f.ledger.deposits); got != 1 {
+		t.Errorf("deposits = %d, want 1", got)
+	}
+
+	if err := f.webhook(t, "success", 1500); err != nil {
+		t.Fatalf("repeated HandleWebhook(success) error = %v", err)
+	}
+	if got := len(f.ledger.deposits); got != 1 {
+		t.Errorf("deposits after repeat = %d, want 1", got)
+	}
+}
+
+func TestHandleWebhookRejectsUnsigned(t *testing.T) {
+	f := newPaymentFixture(t)
+	body := []byte(`{"event":"charge.success","data":{"reference":"` + f.payment.Reference + `","status":"success","amount":1500,"currency":"GHS"}}`)
+
+	err := f.service.HandleWebhook(context.Background(), body, signedHeader("sk_test_other", body))
+	if !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Fatalf("HandleWebhook() error = %v, want %v", err, ErrInvalidWebhookSignature)
+	}
+	if got := f.payments.byReference[f.payment.Reference].Status; got != models.PaymentPending {
+		t.Errorf("payment status = %s, want pending", got)
+	}
+	if len(f.ledger.deposits) != 0 {
+		t.Errorf("unsigned webhook credited the wallet")
+	}
+}
+
+func TestHandleWebhookUnknownPayment(t *testing.T) {
+	f := newPaymentFixture(t)
+	body := []byte(`{"event":"charge.success","data":{"reference":"dashboard-1","status":"success","amount":1500,"currency":"GHS"}}`)
+
+	if err := f.service.HandleWebhook(context.Background(), body, signedHeader(testPaystackSecret, body)); err != nil {
+		t.Fatalf("HandleWebhook() error = %v, want nil", err)
+	}
+	if len(f.ledger.deposits) != 0 {
+		t.Errorf("webhook for an unknown payment credited a wallet")
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/models"
+	"campus-connect/internal/tracing"
+)
+
+// DefaultPaystackURL is the Paystack API. cmd/fakepay serves the same API
+// for local testing.
+const DefaultPaystackURL = "https://api.paystack.co"
+
+// PaystackSignatureHeader carries the HMAC-SHA512 of a webhook body, keyed
+// with the secret key.
+const PaystackSignatureHeader = "X-Paystack-Signature"
+
+// paystackNetworks maps our network names to Paystack's mobile money
+// provider codes.
+var paystackNetworks = map[models.MobileMoneyNetwork]string{
+	models.NetworkMTN:        "mtn",
+	models.NetworkTelecel:    "vod",
+	models.NetworkAirtelTigo: "atl",
+}
+
+// PaystackProvider charges mobile money wallets through the Paystack charge
+// API and receives Paystack webhooks.
+type PaystackProvider struct {
+	baseURL    string
+	secretKey  string
+	httpClient *http.Client
+}
+
+func NewPaystackProvider(baseURL, secretKey string) *PaystackProvider {
+	if baseURL == "" {
+		baseURL = DefaultPaystackURL
+	}
+	return &PaystackProvider{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		secretKey: secretKey,
+		httpClient: &http.Client{
+			Transport: tracing.NewTransport(nil),
+			Timeout:   30 * time.Second,
+		},
+	}
+}
+
+func (p *PaystackProvider) Name() string {
+	return "paystack"
+}
+
+// paystackResponse is the envelope of every Paystack API response.
+type paystackResponse struct {
+	Status  bool                 `json:"status"`
+	Message string               `json:"message"`
+	Data    *paystackTransaction `json:"data"`
+}
+
+type paystackTransaction struct {
+	Reference       string     `json:"reference"`
+	Status          string     `json:"status"`
+	Amount          int64      `json:"amount"`
+	Currency        string     `json:"currency"`
+	DisplayText     string     `json:"display_text"`
+	GatewayResponse string     `json:"gateway_response"`
+	PaidAt          *time.Time `json:"paid_at"`
+}
+
+type paystackWebhook struct {
+	Event string               `json:"event"`
+	Data  *paystackTransaction `json:"data"`
+}
+
+func (p *PaystackProvider) InitiateCharge(ctx context.Context, req ChargeRequest) (*ChargeResult, error) {
+	provider, ok := paystackNetworks[req.Network]
+	if !ok {
+		return nil, &DeclineError{Reason: fmt.Sprintf("unsupported network %q", req.Network)}
+	}
+
+	body := map[string]interface{}{
+		"email":     req.Email,
+		"amount":    int64(req.Amount),
+		"currency":  req.Currency,
+		"reference": req.Reference,
+		"mobile_money": map[string]string{
+			// Paystack expects the local form, e.g. 0241234567
+			"phone":    "0" + strings.TrimPrefix(req.PhoneNumber, "+233"),
+			"provider": provider,
+		},
+		"metadata": req.Metadata,
+	}
+	return p.call(ctx, "charge", http.MethodPost, "/charge", body, req.Reference)
+}
+
+func (p *PaystackProvider) SubmitOTP(ctx context.Context, reference, otp string) (*ChargeResult, error) {
+	body := map[string]string{"reference": reference, "otp": otp}
+	return p.call(ctx, "submit_otp", http.MethodPost, "/charge/submit_otp", body, reference)
+}
+
+func (p *PaystackProvider) VerifyCharge(ctx context.Context, reference string) (*ChargeResult, error) {
+	return p.call(ctx, "verify", http.MethodGet, "/transaction/verify/"+url.PathEscape(reference), nil, reference)
+}
+
+func (p *PaystackProvider) ParseWebhook(body []byte, header http.Header) (*ChargeResult, error) {
+	signature, err := hex.DecodeString(header.Get(PaystackSignatureHeader))
+	if err != nil || !hmac.Equal(signature, paystackMAC(p.secretKey, body)) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	var event paystackWebhook
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid paystack webhook: %w", err)
+	}
+	if !strings.HasPrefix(event.Event, "charge.") || event.Data == nil || event.Data.Reference == "" {
+		return nil, nil
+	}
+	return event.Data.result(), nil
+}
+
+// SignPaystackWebhook returns the signature header value for a webhook body.
+func SignPaystackWebhook(secretKey string, body []byte) string {
+	return hex.EncodeToString(paystackMAC(secretKey, body))
+}
+
+func paystackMAC(secretKey string, body []byte) []byte {
+	mac := hmac.New(sha512.New, []byte(secretKey))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// call makes a Paystack API request. Client errors are declines, or for
+// lookups a missing charge; anything else leaves the charge state unknown.
+func (p *PaystackProvider) call(ctx context.Context, operation, method, path string, payload interface{}, reference string) (*ChargeResult, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.secretKey)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	result, err := p.do(req, operation, reference)
+	metrics.ObserveExternalCall("paystack", operation, start, err)
+	return result, err
+}
+
+func (p *PaystackProvider) do(req *http.Request, operation, reference string) (*ChargeResult, error) {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	var envelope paystackResponse
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&envelope)
+
+	switch {
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: paystack %s: status %d", ErrProviderUnavailable, operation, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		if operation == "verify" {
+			return nil, ErrChargeNotFound
+		}
+		return nil, &DeclineError{Reason: envelope.Message}
+	case decodeErr != nil:
+		return nil, fmt.Errorf("%w: paystack %s: invalid response: %v", ErrProviderUnavailable, operation, decodeErr)
+	case !envelope.Status || envelope.Data == nil:
+		return nil, &DeclineError{Reason: envelope.Message}
+	}
+
+	if envelope.Data.Reference == "" {
+		envelope.Data.Reference = reference
+	}
+	return envelope.Data.result(), nil
+}
+
+// result maps a Paystack transaction status to a payment status.
+func (t *paystackTransaction) result() *ChargeResult {
+	result := &ChargeResult{
+		Reference:   t.Reference,
+		Amount:      models.Pesewas(t.Amount),
+		Currency:    t.Currency,
+		DisplayText: t.DisplayText,
+		PaidAt:      t.PaidAt,
+	}
+	switch t.Status {
+	case "success":
+		result.Status = models.PaymentSucceeded
+	case "failed", "reversed":
+		result.Status = models.PaymentFailed
+		result.FailureReason = t.GatewayResponse
+	case "abandoned":
+		result.Status = models.PaymentAbandoned
+		result.FailureReason = t.GatewayResponse
+	case "send_otp":
+		result.Status = models.PaymentPending
+		if result.DisplayText == "" {
+			result.DisplayText = "Enter the one-time code sent to your phone"
+		}
+	default:
+		// pending, ongoing, processing, queued and pay_offline
+		result.Status = models.PaymentPending
+	}
+	return result
+}
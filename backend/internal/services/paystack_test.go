@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"campus-connect/internal/models"
+)
+
+const testPaystackSecret = "sk_test_secret"
+
+func signedHeader(secret string, body []byte) http.Header {
+	header := http.Header{}
+	header.Set(PaystackSignatureHeader, SignPaystackWebhook(secret, body))
+	return header
+}
+
+func TestPaystackParseWebhook(t *testing.T) {
+	provider := NewPaystackProvider("", testPaystackSecret)
+	charge := []byte(`{"event":"charge.success","data":{"reference":"cc-1","status":"success","amount":1500,"currency":"GHS"}}`)
+
+	tests := []struct {
+		name       string
+		body       []byte
+		header     http.Header
+		wantErr    error
+		wantResult *ChargeResult
+	}{
+		{
+			name:       "signed charge",
+			body:       charge,
+			header:     signedHeader(testPaystackSecret, charge),
+			wantResult: &ChargeResult{Reference: "cc-1", Status: models.PaymentSucceeded, Amount: 1500, Currency: "GHS"},
+		},
+		{
+			name:    "signed with another key",
+			body:    charge,
+			header:  signedHeader("sk_test_other", charge),
+			wantErr: ErrInvalidWebhookSignature,
+		},
+		{
+			name:    "body changed after signing",
+			body:    []byte(`{"event":"charge.success","data":{"reference":"cc-1","status":"success","amount":150000,"currency":"GHS"}}`),
+			header:  signedHeader(testPaystackSecret, charge),
+			wantErr: ErrInvalidWebhookSignature,
+		},
+		{
+			name:    "no signature",
+			body:    charge,
+			header:  http.Header{},
+			wantErr: ErrInvalidWebhookSignature,
+		},
+		{
+			name:    "signature not hex",
+			body:    charge,
+			header:  http.Header{PaystackSignatureHeader: []string{"not-hex"}},
+			wantErr: ErrInvalidWebhookSignature,
+		},
+		{
+			name:   "event not about a charge",
+			body:   []byte(`{"event":"transfer.success","data":{"reference":"tr-1","status":"success"}}`),
+			header: signedHeader(testPaystackSecret, []byte(`{"event":"transfer.success","data":{"reference":"tr-1","status":"success"}}`)),
+		},
+		{
+			name:       "abandoned charge",
+			body:       []byte(`{"event":"charge.failed","data":{"reference":"cc-2","status":"abandoned","gateway_response":"Timed out"}}`),
+			header:     signedHeader(testPaystackSecret, []byte(`{"event":"charge.failed","data":{"reference":"cc-2","status":"abandoned","gateway_response":"Timed out"}}`)),
+			wantResult: &ChargeResult{Reference: "cc-2", Status: models.PaymentAbandoned, FailureReason: "Timed out"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := provider.ParseWebhook(tt.body, tt.header)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantResult == nil {
+				if result != nil {
+					t.Errorf("ParseWebhook() = %+v, want nil", result)
+				}
+				return
+			}
+			if result == nil || result.Reference != tt.wantResult.Reference || result.Status != tt.wantResult.Status ||
+				result.Amount != tt.wantResult.Amount || result.Currency != tt.wantResult.Currency ||
+				result.FailureReason != tt.wantResult.FailureReason {
+				t.Errorf("ParseWebhook() = %+v, want %+v", result, tt.wantResult)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"campus-connect/internal/logging"
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/models"
+	"campus-connect/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// PricingConfig controls price estimates. They are drawn from deliveries
+// completed within Lookback, and fall back to base rates unless those add up
+// to at least MinSamples similar deliveries.
+type PricingConfig struct {
+	Lookback   time.Duration
+	MinSamples int
+}
+
+// pricingSampleLimit bounds the completed deliveries an estimate looks at.
+const pricingSampleLimit = 500
+
+// Base rates in cedis, used when there is too little history. Trips carry
+// items of any size, so they use the rate for no size.
+var (
+	baseRates = map[models.ItemSize]float64{
+		"":                    15,
+		models.ItemSizeSmall:  10,
+		models.ItemSizeMedium: 20,
+		models.ItemSizeLarge:  35,
+	}
+	priorityFactors = map[models.Priority]float64{
+		models.PriorityLow:    0.85,
+		models.PriorityNormal: 1,
+		models.PriorityHigh:   1.25,
+		models.PriorityUrgent: 1.6,
+	}
+	// rushFactor applies to deliveries asked for less than a day ahead.
+	rushFactor = 1.2
+)
+
+var priorityRanks = map[models.Priority]int{
+	models.PriorityLow:    0,
+	models.PriorityNormal: 1,
+	models.PriorityHigh:   2,
+	models.PriorityUrgent: 3,
+}
+
+// PricingService suggests prices for delivery requests and trips from the
+// prices of similar completed deliveries, and flags prices far outside them.
+type PricingService struct {
+	repo repositories.PricingRepository
+	cfg  PricingConfig
+}
+
+func NewPricingService(repo repositories.PricingRepository, cfg PricingConfig) *PricingService {
+	return &PricingService{repo: repo, cfg: cfg}
+}
+
+type weightedPrice struct {
+	amount float64
+	weight float64
+}
+
+// Estimate suggests a price range for the delivery. Completed deliveries are
+// weighted by how closely their route, item size, priority and lead time
+// match, and the range is the weighted middle half of their prices.
+func (s *PricingService) Estimate(ctx context.Context, q models.PriceQuery) (*models.PriceEstimate, error) {
+	samples, err := s.repo.ListSamples(ctx, q.PickupLocation, q.DropoffLocation,
+		time.Now().Add(-s.cfg.Lookback), pricingSampleLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make([]weightedPrice, 0, len(samples))
+	var sum, sumSquares float64
+	for _, sample := range samples {
+		w := similarity(q, sample)
+		prices = append(prices, weightedPrice{amount: sample.PaymentAmount, weight: w})
+		sum += w
+		sumSquares += w * w
+	}
+
+	// The effective sample size discounts samples that are poor matches
+	if len(prices) == 0 || sum*sum/sumSquares < float64(s.cfg.MinSamples) {
+		return defaultEstimate(q), nil
+	}
+
+	sort.Slice(prices, func(i, j int) bool { return prices[i].amount < prices[j].amount })
+	return newEstimate(
+		weightedQuantile(prices, sum, 0.25),
+		weightedQuantile(prices, sum, 0.5),
+		weightedQuantile(prices, sum, 0.75),
+		models.PriceBasisHistory,
+		len(prices),
+	), nil
+}
+
+// CheckPrice estimates the price of a delivery request or trip just created
+// and flags it for review if its price is an outlier.
+func (s *PricingService) CheckPrice(
+	ctx context.Context,
+	subject models.PriceFlagSubject,
+	subjectID, userID uuid.UUID,
+	q models.PriceQuery,
+	amount float64,
+) (*models.PriceEstimate, models.PriceOutlier, error) {
+	estimate, err := s.Estimate(ctx, q)
+	if err != nil {
+		return nil, "", err
+	}
+
+	outlier := estimate.Outlier(amount)
+	if outlier == "" {
+		return estimate, "", nil
+	}
+
+	flag := &models.PriceFlag{
+		ID:          uuid.New(),
+		SubjectType: subject,
+		SubjectID:   subjectID,
+		UserID:      userID,
+		Amount:      amount,
+		Outlier:     outlier,
+		Low:         estimate.Low,
+		Median:      estimate.Median,
+		High:        estimate.High,
+		Basis:       estimate.Basis,
+	}
+	if err := s.repo.CreateFlag(ctx, flag); err != nil {
+		return estimate, outlier, fmt.Errorf("failed to flag price: %w", err)
+	}
+	metrics.PriceOutliers.WithLabelValues(string(subject), string(outlier)).Inc()
+	logging.FromContext(ctx).Info("flagged outlying price",
+		"subject_type", subject, "subject_id", subjectID, "amount", amount,
+		"outlier", outlier, "low", estimate.Low, "high", estimate.High)
+	return estimate, outlier, nil
+}
+
+func (s *PricingService) ListFlags(ctx context.Context, limit, offset int) ([]*models.PriceFlag, int, error) {
+	return s.repo.ListFlags(ctx, limit, offset)
+}
+
+func defaultEstimate(q models.PriceQuery) *models.PriceEstimate {
+	median := baseRates[q.ItemSize]
+	if f, ok := priorityFactors[q.Priority]; ok {
+		median *= f
+	}
+	if q.LeadTime > 0 && q.LeadTime < 24*time.Hour {
+		median *= rushFactor
+	}
+	return newEstimate(median*0.8, median, median*1.3, models.PriceBasisDefault, 0)
+}
+
+// newEstimate rounds the range to half cedis and sets the bounds beyond
+// which prices are outliers: 1.5 times the range past either end, the range
+// being taken as at least a fifth of the median, and no lower than a quarter
+// of the median.
+func newEstimate(low, median, high float64, basis models.PriceBasis, sampleSize int) *models.PriceEstimate {
+	spread := math.Max(high-low, median/5)
+	return &models.PriceEstimate{
+		Low:           roundToHalf(low),
+		Median:        roundToHalf(median),
+		High:          roundToHalf(high),
+		Basis:         basis,
+		SampleSize:    sampleSize,
+		MinReasonable: math.Max(low-1.5*spread, median/4),
+		MaxReasonable: high + 1.5*spread,
+	}
+}
+
+func roundToHalf(cedis float64) float64 {
+	return math.Max(math.Round(cedis*2)/2, 0.5)
+}
+
+// weightedQuantile returns the price below which the fraction q of the
+// total weight lies. prices must be sorted by amount.
+func weightedQuantile(prices []weightedPrice, total, q float64) float64 {
+	target := q * total
+	var cumulative float64
+	for _, p := range prices {
+		cumulative += p.weight
+		if cumulative >= target {
+			return p.amount
+		}
+	}
+	return prices[len(prices)-1].amount
+}
+
+// similarity weighs how closely a completed delivery matches the query,
+// from 1 for the same route, size, priority and lead time down towards 0.
+func similarity(q models.PriceQuery, s *models.PriceSample) float64 {
+	pickup, dropoff := normalizeLocation(q.PickupLocation), normalizeLocation(q.DropoffLocation)
+	samplePickup, sampleDropoff := normalizeLocation(s.PickupLocation), normalizeLocation(s.DropoffLocation)
+
+	var w float64
+	switch {
+	case samplePickup == pickup && sampleDropoff == dropoff:
+		w = 1
+	case samplePickup == dropoff && sampleDropoff == pickup:
+		w = 0.7
+	default:
+		w = 0.3
+	}
+
+	if q.ItemSize != "" && s.ItemSize != q.ItemSize {
+		w *= 0.4
+	}
+	if q.Priority != "" {
+		diff := priorityRanks[q.Priority] - priorityRanks[s.Priority]
+		if diff < 0 {
+			diff = -diff
+		}
+		w *= 1 - 0.3*float64(diff)
+	}
+	if q.LeadTime > 0 && leadBucket(q.LeadTime) != leadBucket(s.LeadTime) {
+		w *= 0.7
+	}
+	return w
+}
+
+// leadBucket groups lead times into same day, within three days and later.
+func leadBucket(d time.Duration) int {
+	switch {
+	case d < 24*time.Hour:
+		return 0
+	case d < 72*time.Hour:
+		return 1
+	}
+	return 2
+}
+
+// normalizeLocation compares locations as the repository does.
+func normalizeLocation(location string) string {
+	return strings.ToLower(strings.TrimSpace(location))
+}
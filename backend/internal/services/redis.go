@@ -0,0 +1,25 @@
+package services
+
+import (
+	"log/slog"
+
+	"campus-connect/internal/metrics"
+
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisClient returns a Redis client instrumented with metrics and
+// tracing. The connection is established lazily on first use.
+func NewRedisClient(addr, password string, db int) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+	rdb.AddHook(metrics.RedisHook{})
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		slog.Warn("failed to instrument Redis tracing", "error", err)
+	}
+	return rdb
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+
+	"campus-connect/internal/logging"
+)
+
+// SMSProvider delivers text messages to E.164 formatted phone numbers.
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, message string) error
+}
+
+// LogSMSProvider is a stub provider that writes messages to the server log
+// instead of sending them. It is used until a real SMS gateway is configured.
+type LogSMSProvider struct {
+	senderID string
+}
+
+func NewLogSMSProvider(senderID string) *LogSMSProvider {
+	return &LogSMSProvider{senderID: senderID}
+}
+
+func (p *LogSMSProvider) SendSMS(ctx context.Context, to, message string) error {
+	logging.FromContext(ctx).Info("SMS not sent, logging instead",
+		"sender_id", p.senderID, "to", to, "message", message)
+	return nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"campus-connect/internal/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// EarningsStatement is a traveler's earnings and payouts over [From, To),
+// rendered for download as CSV or PDF.
+type EarningsStatement struct {
+	TravelerName string
+	From         time.Time
+	To           time.Time
+	GeneratedAt  time.Time
+	Earnings     []*models.Earning
+	Totals       models.EarningsTotals
+	// Payouts approved during the period
+	Payouts  []*models.Payout
+	Balances *models.EarningsBalances
+}
+
+// amount formats p in cedis without the currency sign, which PDF core fonts
+// cannot draw.
+func amount(p models.Pesewas) string {
+	return strconv.FormatFloat(p.Cedis(), 'f', 2, 64)
+}
+
+// csvText guards a user-supplied cell against formula injection: spreadsheets
+// run cells starting with =, +, -, @, tab or carriage return as formulas, so
+// those are prefixed with a quote to be read as text.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// WriteCSV writes one row per released fee or dispute refund followed by a
+// totals row.
+// Amounts are in cedis.
+func (s *EarningsStatement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"released_at", "delivery_request_id", "trip_id", "item", "pickup", "dropoff", "gross_ghs", "fee_ghs", "net_ghs"})
+	for _, e := range s.Earnings {
+		cw.Write([]string{
+			e.ReleasedAt.UTC().Format(time.RFC3339),
+			e.DeliveryRequestID.String(),
+			e.TripID.String(),
+			csvText(e.ItemDescription),
+			csvText(e.PickupLocation),
+			csvText(e.DropoffLocation),
+			amount(e.Gross),
+			amount(e.Fee),
+			amount(e.Net),
+		})
+	}
+	cw.Write([]string{"total", strconv.Itoa(s.Totals.Deliveries) + " deliveries", "", "", "", "",
+		amount(s.Totals.Gross), amount(s.Totals.Fees), amount(s.Totals.Net)})
+	cw.Flush()
+	return cw.Error()
+}
+
+// WritePDF writes an A4 statement with the period's earnings, payouts and
+// the balances as of GeneratedAt.
+func (s *EarningsStatement) WritePDF(w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Campus Connect earnings statement", true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.AliasNbPages("")
+	// Core fonts are cp1252; translate names and descriptions into it
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 8, "Earnings statement", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, tr(s.TravelerName), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, fmt.Sprintf("Period: %s to %s", s.From.Format(time.DateOnly),
+		s.To.AddDate(0, 0, -1).Format(time.DateOnly)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "Generated: "+s.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "Amounts in GHS", "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{22, 70, 30, 29, 29}
+	row := func(style string, cells ...string) {
+		pdf.SetFont("Helvetica", style, 9)
+		for i, c := range cells {
+			align := "R"
+			if i < 2 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 6, c, "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	row("B", "Date", "Delivery", "Gross", "Platform fee", "Net")
+	for _, e := range s.Earnings {
+		desc := e.ItemDescription
+		if r := []rune(desc); len(r) > 40 {
+			desc = string(r[:37]) + "..."
+		}
+		row("", e.ReleasedAt.UTC().Format(time.DateOnly), tr(desc), amount(e.Gross), amount(e.Fee), amount(e.Net))
+	}
+	row("B", "Total", fmt.Sprintf("%d deliveries", s.Totals.Deliveries),
+		amount(s.Totals.Gross), amount(s.Totals.Fees), amount(s.Totals.Net))
+
+	if len(s.Payouts) > 0 {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 7, "Payouts", "", 1, "L", false, 0, "")
+		row("B", "Date", "Sent to", "", "Reference", "Amount")
+		for _, p := range s.Payouts {
+			ref := ""
+			if p.TransferReference != nil {
+				ref = *p.TransferReference
+			}
+			row("", p.ReviewedAt.UTC().Format(time.DateOnly), string(p.Network)+" "+p.PhoneNumber, "", tr(ref), amount(p.Amount))
+		}
+	}
+
+	if s.Balances != nil {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 7, "Balances", "", 1, "L", false, 0, "")
+		for _, b := range []struct {
+			label  string
+			amount models.Pesewas
+		}{
+			{"Held in escrow", s.Balances.Pending},
+			{"Available to pay out", s.Balances.Available},
+			{"Payouts requested", s.Balances.Requested},
+			{"Paid out", s.Balances.PaidOut},
+		} {
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.CellFormat(60, 6, b.label, "", 0, "L", false, 0, "")
+			pdf.CellFormat(30, 6, amount(b.amount), "", 1, "R", false, 0, "")
+		}
+	}
+
+	return pdf.Output(w)
+}
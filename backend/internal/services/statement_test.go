@@ -0,0 +1,59 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		name string
+		cell string
+		want string
+	}{
+		{name: "plain text", cell: "Textbooks", want: "Textbooks"},
+		{name: "empty", cell: "", want: ""},
+		{name: "equals", cell: `=HYPERLINK("http://evil.example")`, want: `'=HYPERLINK("http://evil.example")`},
+		{name: "plus", cell: "+233241234567", want: "'+233241234567"},
+		{name: "minus", cell: "-1+1", want: "'-1+1"},
+		{name: "at", cell: "@SUM(A1)", want: "'@SUM(A1)"},
+		{name: "tab", cell: "\t=1", want: "'\t=1"},
+		{name: "carriage return", cell: "\r=1", want: "'\r=1"},
+		{name: "formula character later on", cell: "Hall 7 = Unity", want: "Hall 7 = Unity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statement := &EarningsStatement{
+				Earnings: []*models.Earning{{
+					DeliveryRequestID: uuid.New(),
+					TripID:            uuid.New(),
+					ItemDescription:   tt.cell,
+					PickupLocation:    tt.cell,
+					DropoffLocation:   tt.cell,
+					ReleasedAt:        time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+				}},
+			}
+
+			var buf bytes.Buffer
+			if err := statement.WriteCSV(&buf); err != nil {
+				t.Fatalf("WriteCSV() error = %v", err)
+			}
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatalf("read CSV: %v", err)
+			}
+			row := records[1]
+			for i, column := range []string{"item", "pickup", "dropoff"} {
+				if got := row[3+i]; got != tt.want {
+					t.Errorf("%s = %q, want %q", column, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"time"
+)
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrInvalidKey     = errors.New("invalid object key")
+)
+
+// Visibility controls who can fetch a stored object.
+type Visibility int
+
+const (
+	// Public objects may be served from permanent public URLs.
+	Public Visibility = iota
+	// Private objects are only reachable through signed URLs that expire.
+	Private
+)
+
+// ObjectStore stores uploaded files under caller-chosen keys such as
+// "profiles/<userID>/<uuid>". Callers pass the same visibility to Delete and
+// URL that they used for Put.
+type ObjectStore interface {
+	// Put stores the contents of body under key, replacing any existing object.
+	Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (*StoredObject, error)
+	// Delete removes the object stored under key.
+	Delete(ctx context.Context, key string, visibility Visibility) error
+	// URL returns a URL the object can be fetched from. Private objects, and
+	// all objects on backends that serve files themselves, get a signed URL
+	// that stops working after ttl.
+	URL(ctx context.Context, key string, visibility Visibility, ttl time.Duration) (string, error)
+}
+
+// keyExtensions maps the content types accepted for upload to the extension
+// their keys end in, so backends can recover an object's format from its key.
+var keyExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// KeyExtension returns the extension, dot included, that keys for objects of
+// contentType should end in. Unknown types get none.
+func KeyExtension(contentType string) string {
+	return keyExtensions[contentType]
+}
+
+// keyFormat splits a key into its name and the format named by its extension.
+// Keys without a known extension have no format.
+func keyFormat(key string) (name, format string) {
+	ext := path.Ext(key)
+	for _, known := range keyExtensions {
+		if ext == known {
+			return key[:len(key)-len(ext)], ext[1:]
+		}
+	}
+	return key, ""
+}
+
+type PutOptions struct {
+	ContentType string
+	Visibility  Visibility
+}
+
+type StoredObject struct {
+	Key string
+	// URL the object can be fetched from right after upload. For backends
+	// without public URLs this is a signed URL that expires. It is empty for
+	// private objects; use ObjectStore.URL to mint a short-lived link.
+	URL string
+}
+
+type StorageConfig struct {
+	// Driver selects the backend: "cloudinary" or "local". When empty,
+	// Cloudinary is used if credentials are configured, otherwise local disk.
+	Driver        string
+	LocalDir      string
+	PublicBaseURL string
+	SigningSecret string
+	URLTTL        time.Duration
+}
+
+// NewObjectStore builds the object store selected by cfg.
+func NewObjectStore(cfg StorageConfig, cloudinaryCfg CloudinaryConfig) (ObjectStore, error) {
+	driver := cfg.Driver
+	if driver == "" {
+		if cloudinaryCfg.CloudName != "" && cloudinaryCfg.APIKey != "" && cloudinaryCfg.APISecret != "" {
+			driver = "cloudinary"
+		} else {
+			slog.Warn("Cloudinary credentials not provided, storing uploads on local disk")
+			driver = "local"
+		}
+	}
+
+	switch driver {
+	case "cloudinary":
+		return NewCloudinaryService(cloudinaryCfg)
+	case "local":
+		return NewLocalStore(cfg.LocalDir, cfg.PublicBaseURL, cfg.SigningSecret, cfg.URLTTL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"campus-connect/internal/logging"
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// TrackingConfig controls live location sharing. A delivery's latest ping
+// expires PingTTL after it is recorded, so a location is not shown for long
+// after the traveler's client stops sending.
+type TrackingConfig struct {
+	PingTTL time.Duration
+}
+
+// Typical speeds in metres per second, used for ETAs when the device does
+// not report a usable speed. Campus traffic is slow, so cars and motorcycles
+// are given city speeds.
+var travelSpeeds = map[models.TransportMethod]float64{
+	models.TransportCar:        8.3,
+	models.TransportMotorcycle: 8.3,
+	models.TransportBicycle:    4.5,
+	models.TransportWalking:    1.4,
+	models.TransportPublic:     5.5,
+}
+
+const (
+	// routeFactor converts straight-line distances to typical route lengths.
+	routeFactor = 1.3
+	// minReportedSpeed is the slowest reported speed trusted for an ETA;
+	// below it the traveler is taken to be stopped for a moment.
+	minReportedSpeed = 1.0
+	defaultSpeed     = 5.5
+)
+
+// trackingStoppedTTL is how long a stopped delivery refuses pings. A
+// delivery never returns to transit, so this only needs to outlast pings
+// already on their way when it stopped.
+const trackingStoppedTTL = 24 * time.Hour
+
+// ErrTrackingStopped is returned for a ping that arrives after sharing
+// stopped for the delivery.
+var ErrTrackingStopped = errors.New("tracking stopped")
+
+// recordScript stores and publishes a tracking event unless the delivery's
+// stopped marker is set, so a ping racing Stop cannot bring back the
+// location it deleted. Returns 1 if the event was recorded.
+var recordScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('PUBLISH', ARGV[3], ARGV[1])
+return 1
+`)
+
+// TrackingService shares a traveler's live location for deliveries in
+// transit. The latest event of each delivery is kept in Redis, and events
+// are published on a channel per delivery for subscribers.
+type TrackingService struct {
+	redisClient *redis.Client
+	cfg         TrackingConfig
+}
+
+func NewTrackingService(rdb *redis.Client, cfg TrackingConfig) *TrackingService {
+	return &TrackingService{redisClient: rdb, cfg: cfg}
+}
+
+func trackingKey(requestID uuid.UUID) string {
+	return "tracking:last:" + requestID.String()
+}
+
+func trackingStoppedKey(requestID uuid.UUID) string {
+	return "tracking:stopped:" + requestID.String()
+}
+
+func trackingChannel(requestID uuid.UUID) string {
+	return "tracking:events:" + requestID.String()
+}
+
+// Record stores ping as the delivery's latest location, with the distance
+// and ETA to the dropoff, and publishes it to subscribers. It returns
+// ErrTrackingStopped if sharing has stopped for the delivery.
+func (s *TrackingService) Record(
+	ctx context.Context,
+	request *models.DeliveryRequest,
+	transport models.TransportMethod,
+	ping *models.LocationPing,
+) (*models.TrackingEvent, error) {
+	event := &models.TrackingEvent{
+		Type:              models.TrackingLocation,
+		DeliveryRequestID: request.ID,
+		Location:          ping,
+	}
+	if request.DropoffLatitude != nil && request.DropoffLongitude != nil {
+		here := models.GeoPoint{Latitude: ping.Latitude, Longitude: ping.Longitude}
+		distance := here.DistanceTo(models.GeoPoint{Latitude: *request.DropoffLatitude, Longitude: *request.DropoffLongitude})
+		eta := ping.RecordedAt.Add(travelTime(distance, transport, ping.Speed))
+		event.DistanceRemaining = &distance
+		event.ETA = &eta
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tracking event: %w", err)
+	}
+	recorded, err := recordScript.Run(ctx, s.redisClient,
+		[]string{trackingKey(request.ID), trackingStoppedKey(request.ID)},
+		data, s.cfg.PingTTL.Milliseconds(), trackingChannel(request.ID),
+	).Int()
+	if err != nil {
+		return nil, fmt.Errorf("failed to record location: %w", err)
+	}
+	if recorded == 0 {
+		return nil, ErrTrackingStopped
+	}
+	return event, nil
+}
+
+// Latest returns the delivery's latest location event, or nil if there is
+// none or it has expired.
+func (s *TrackingService) Latest(ctx context.Context, requestID uuid.UUID) (*models.TrackingEvent, error) {
+	data, err := s.redisClient.Get(ctx, trackingKey(requestID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	event := &models.TrackingEvent{}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("failed to decode tracking event: %w", err)
+	}
+	return event, nil
+}
+
+// Stop ends sharing for a delivery that is no longer in transit, deleting
+// its location and telling subscribers the status it reached. Later pings
+// are refused.
+func (s *TrackingService) Stop(ctx context.Context, requestID uuid.UUID, status models.DeliveryStatus) error {
+	data, err := json.Marshal(&models.TrackingEvent{
+		Type:              models.TrackingStopped,
+		DeliveryRequestID: requestID,
+		Status:            &status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode tracking event: %w", err)
+	}
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, trackingStoppedKey(requestID), string(status), trackingStoppedTTL)
+	pipe.Del(ctx, trackingKey(requestID))
+	pipe.Publish(ctx, trackingChannel(requestID), data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to stop tracking: %w", err)
+	}
+	return nil
+}
+
+// TrackingSubscription receives a delivery's tracking events until closed.
+type TrackingSubscription struct {
+	pubsub *redis.PubSub
+	events chan *models.TrackingEvent
+}
+
+// Subscribe starts receiving the delivery's tracking events. Events
+// published after it returns are not missed, so callers can subscribe and
+// then send the latest location without a gap.
+func (s *TrackingService) Subscribe(ctx context.Context, requestID uuid.UUID) (*TrackingSubscription, error) {
+	pubsub := s.redisClient.Subscribe(ctx, trackingChannel(requestID))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to tracking: %w", err)
+	}
+
+	sub := &TrackingSubscription{pubsub: pubsub, events: make(chan *models.TrackingEvent)}
+	go func() {
+		defer close(sub.events)
+		for msg := range pubsub.Channel() {
+			event := &models.TrackingEvent{}
+			if err := json.Unmarshal([]byte(msg.Payload), event); err != nil {
+				logging.FromContext(ctx).Error("failed to decode tracking event", "channel", msg.Channel, "error", err)
+				continue
+			}
+			select {
+			case sub.events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// Events is closed when the subscription is closed.
+func (s *TrackingSubscription) Events() <-chan *models.TrackingEvent {
+	return s.events
+}
+
+func (s *TrackingSubscription) Close() error {
+	return s.pubsub.Close()
+}
+
+// travelTime estimates how long covering distance takes, preferring the
+// device's reported speed to the typical speed of the transport method.
+func travelTime(distance float64, transport models.TransportMethod, reported *float64) time.Duration {
+	speed, ok := travelSpeeds[transport]
+	if !ok {
+		speed = defaultSpeed
+	}
+	if reported != nil && *reported >= minReportedSpeed {
+		speed = *reported
+	}
+	return time.Duration(distance * routeFactor / speed * float64(time.Second))
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"campus-connect/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestTravelTime(t *testing.T) {
+	speed := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name      string
+		distance  float64
+		transport models.TransportMethod
+		reported  *float64
+		want      time.Duration
+	}{
+		{name: "walking", distance: 1400, transport: models.TransportWalking, want: 1300 * time.Second},
+		{name: "car", distance: 8300, transport: models.TransportCar, want: 1300 * time.Second},
+		{name: "unknown transport", distance: 5500, transport: "hovercraft", want: 1300 * time.Second},
+		{name: "reported speed", distance: 1000, transport: models.TransportWalking, reported: speed(2), want: 650 * time.Second},
+		{name: "reported stop", distance: 1400, transport: models.TransportWalking, reported: speed(0.2), want: 1300 * time.Second},
+		{name: "arrived", distance: 0, transport: models.TransportBicycle, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := travelTime(tt.distance, tt.transport, tt.reported)
+			if diff := got - tt.want; diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("travelTime(%v, %s) = %s, want %s", tt.distance, tt.transport, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackingRecord(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	tracking := NewTrackingService(rdb, TrackingConfig{PingTTL: time.Minute})
+
+	// Kumasi, with the dropoff about 1.1 km due north
+	lat, lng := 6.6745, -1.5716
+	dropLat := lat + 0.01
+	request := &models.DeliveryRequest{ID: uuid.New(), DropoffLatitude: &dropLat, DropoffLongitude: &lng}
+	recordedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	ping := &models.LocationPing{Latitude: lat, Longitude: lng, RecordedAt: recordedAt}
+
+	event, err := tracking.Record(ctx, request, models.TransportWalking, ping)
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if event.DistanceRemaining == nil || math.Abs(*event.DistanceRemaining-1112) > 5 {
+		t.Fatalf("DistanceRemaining = %v, want about 1112 m", event.DistanceRemaining)
+	}
+	wantETA := recordedAt.Add(travelTime(*event.DistanceRemaining, models.TransportWalking, nil))
+	if event.ETA == nil || !event.ETA.Equal(wantETA) {
+		t.Errorf("ETA = %v, want %s", event.ETA, wantETA)
+	}
+
+	latest, err := tracking.Latest(ctx, request.ID)
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if latest == nil || latest.ETA == nil || !latest.ETA.Equal(wantETA) {
+		t.Errorf("Latest() = %+v, want the recorded event", latest)
+	}
+
+	if err := tracking.Stop(ctx, request.ID, models.DeliveryDelivered); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if latest, err := tracking.Latest(ctx, request.ID); err != nil || latest != nil {
+		t.Errorf("Latest() after Stop = %+v, %v, want none", latest, err)
+	}
+	if _, err := tracking.Record(ctx, request, models.TransportWalking, ping); !errors.Is(err, ErrTrackingStopped) {
+		t.Errorf("Record() after Stop error = %v, want %v", err, ErrTrackingStopped)
+	}
+}
+
+func TestTrackingRecordWithoutDropoff(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	tracking := NewTrackingService(rdb, TrackingConfig{PingTTL: time.Minute})
+
+	request := &models.DeliveryRequest{ID: uuid.New()}
+	event, err := tracking.Record(ctx, request, models.TransportCar, &models.LocationPing{Latitude: 6.67, Longitude: -1.57, RecordedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if event.DistanceRemaining != nil || event.ETA != nil {
+		t.Errorf("Record() = %+v, want no distance or ETA without dropoff coordinates", event)
+	}
+}
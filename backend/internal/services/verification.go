@@ -5,47 +5,104 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"net/http"
 	"time"
 
+	"campus-connect/internal/metrics"
+	"campus-connect/internal/tracing"
+
 	"github.com/redis/go-redis/v9"
 )
 
 type VerificationService struct {
 	redisClient *redis.Client
+	httpClient  *http.Client
 	brevoAPIKey string
 	senderName  string
 	senderEmail string
 }
 
-func NewVerificationService(redisAddr, redisPassword string, redisDB int, brevoAPIKey, senderName, senderEmail string) *VerificationService {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     redisAddr,
-		Password: redisPassword,
-		DB:       redisDB,
-	})
-	return &VerificationService{redisClient: rdb, brevoAPIKey: brevoAPIKey, senderName: senderName, senderEmail: senderEmail}
+func NewVerificationService(rdb *redis.Client, brevoAPIKey, senderName, senderEmail string) *VerificationService {
+	return &VerificationService{
+		redisClient: rdb,
+		httpClient:  &http.Client{Transport: tracing.NewTransport(nil)},
+		brevoAPIKey: brevoAPIKey,
+		senderName:  senderName,
+		senderEmail: senderEmail,
+	}
 }
 
 func (vs *VerificationService) StoreCode(ctx context.Context, email, code string, ttl time.Duration) error {
-	key := fmt.Sprintf("verify:%s", email)
-	return vs.redisClient.Set(ctx, key, code, ttl).Err()
+	return vs.storeCode(ctx, fmt.Sprintf("verify:%s", email), code, ttl)
 }
 
 func (vs *VerificationService) ValidateCode(ctx context.Context, email, code string) (bool, error) {
-	key := fmt.Sprintf("verify:%s", email)
-	val, err := vs.redisClient.Get(ctx, key).Result()
+	return vs.validateCode(ctx, fmt.Sprintf("verify:%s", email), code)
+}
+
+// StorePhoneCode stores an OTP for the given user and phone number. The phone
+// number is part of the key so a code cannot be used after the number changes.
+func (vs *VerificationService) StorePhoneCode(ctx context.Context, userID, phone, code string, ttl time.Duration) error {
+	return vs.storeCode(ctx, fmt.Sprintf("verify:phone:%s:%s", userID, phone), code, ttl)
+}
+
+func (vs *VerificationService) ValidatePhoneCode(ctx context.Context, userID, phone, code string) (bool, error) {
+	return vs.validateCode(ctx, fmt.Sprintf("verify:phone:%s:%s", userID, phone), code)
+}
+
+// MaxCodeAttempts is how many wrong guesses a verification code survives.
+// The code is deleted on the last one, so a new code has to be requested.
+const MaxCodeAttempts = 5
+
+// validateCodeScript checks a code and counts wrong guesses in one step, so
+// parallel guesses cannot get past the limit. KEYS[1] holds the code and
+// KEYS[2] the wrong guesses, which expire with the code. It returns 1 when
+// the code matched, deleting it so it is used once.
+var validateCodeScript = redis.NewScript(`
+local code = redis.call('GET', KEYS[1])
+if not code then
+	return 0
+end
+if code == ARGV[1] then
+	redis.call('DEL', KEYS[1], KEYS[2])
+	return 1
+end
+local misses = redis.call('INCR', KEYS[2])
+if misses == 1 then
+	local ttl = redis.call('PTTL', KEYS[1])
+	if ttl > 0 then
+		redis.call('PEXPIRE', KEYS[2], ttl)
+	end
+end
+if misses >= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1], KEYS[2])
+end
+return 0
+`)
+
+func attemptsKey(key string) string {
+	return key + ":attempts"
+}
+
+// storeCode stores a new code and resets the wrong guesses counted against
+// the one it replaces.
+func (vs *VerificationService) storeCode(ctx context.Context, key, code string, ttl time.Duration) error {
+	pipe := vs.redisClient.TxPipeline()
+	pipe.Set(ctx, key, code, ttl)
+	pipe.Del(ctx, attemptsKey(key))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// validateCode reports whether code matches the stored one. A match uses the
+// code up; MaxCodeAttempts wrong guesses delete it.
+func (vs *VerificationService) validateCode(ctx context.Context, key, code string) (bool, error) {
+	matched, err := validateCodeScript.Run(ctx, vs.redisClient, []string{key, attemptsKey(key)}, code, MaxCodeAttempts).Int()
 	if err != nil {
-		if err == redis.Nil {
-			return false, nil
-		}
 		return false, err
 	}
-	if val != code {
-		return false, nil
-	}
-	_ = vs.redisClient.Del(ctx, key).Err()
-	return true, nil
+	return matched == 1, nil
 }
 
 type brevoEmail struct {
@@ -61,7 +118,25 @@ type brevoEmail struct {
 	HTMLContent string `json:"htmlContent"`
 }
 
-func (vs *VerificationService) SendVerificationEmail(email, name, code string) error {
+func (vs *VerificationService) SendVerificationEmail(ctx context.Context, email, name, code string) error {
+	subject := "Verify your CampusConnect email"
+	content := fmt.Sprintf("<html><body><p>Hello %s,</p><p>Your verification code is <strong>%s</strong>. It expires in 10 minutes.</p></body></html>", name, code)
+	return vs.sendEmail(ctx, email, name, subject, content)
+}
+
+// SendNewDeviceEmail tells a user about a sign-in from a device their account
+// has not signed in from before.
+func (vs *VerificationService) SendNewDeviceEmail(ctx context.Context, email, name, ip, userAgent string, at time.Time) error {
+	subject := "New sign-in to your CampusConnect account"
+	content := fmt.Sprintf("<html><body><p>Hello %s,</p>"+
+		"<p>Your account was signed in to from a new device.</p>"+
+		"<p>Time: %s<br>IP address: %s<br>Device: %s</p>"+
+		"<p>If this was you, you can ignore this email. Otherwise, change your password now.</p></body></html>",
+		html.EscapeString(name), at.UTC().Format("2 Jan 2006 15:04 MST"), html.EscapeString(ip), html.EscapeString(userAgent))
+	return vs.sendEmail(ctx, email, name, subject, content)
+}
+
+func (vs *VerificationService) sendEmail(ctx context.Context, email, name, subject, content string) error {
 	payload := brevoEmail{}
 	payload.Sender.Name = vs.senderName
 	payload.Sender.Email = vs.senderEmail
@@ -69,11 +144,11 @@ func (vs *VerificationService) SendVerificationEmail(email, name, code string) e
 		Email string `json:"email"`
 		Name  string `json:"name"`
 	}{Email: email, Name: name})
-	payload.Subject = "Verify your CampusConnect email"
-	payload.HTMLContent = fmt.Sprintf("<html><body><p>Hello %s,</p><p>Your verification code is <strong>%s</strong>. It expires in 10 minutes.</p></body></html>", name, code)
+	payload.Subject = subject
+	payload.HTMLContent = content
 
 	body, _ := json.Marshal(payload)
-	req, err := http.NewRequest("POST", "https://api.brevo.com/v3/smtp/email", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.brevo.com/v3/smtp/email", bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
@@ -81,13 +156,14 @@ func (vs *VerificationService) SendVerificationEmail(email, name, code string) e
 	req.Header.Set("content-type", "application/json")
 	req.Header.Set("api-key", vs.brevoAPIKey)
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("brevo send failed: status %d", resp.StatusCode)
+	start := time.Now()
+	resp, err := vs.httpClient.Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			err = fmt.Errorf("brevo send failed: status %d", resp.StatusCode)
+		}
 	}
-	return nil
+	metrics.ObserveExternalCall("brevo", "send_email", start, err)
+	return err
 }
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateCode(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	verifier := NewVerificationService(rdb, "", "", "")
+
+	tests := []struct {
+		name  string
+		wrong int
+		code  string
+		want  bool
+	}{
+		{name: "right code", code: "123456", want: true},
+		{name: "wrong code", code: "654321"},
+		{name: "right code after wrong ones", wrong: MaxCodeAttempts - 1, code: "123456", want: true},
+		{name: "right code once deleted", wrong: MaxCodeAttempts, code: "123456"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := uuid.NewString() + "@st.knust.edu.gh"
+			if err := verifier.StoreCode(ctx, email, "123456", time.Minute); err != nil {
+				t.Fatalf("StoreCode() error = %v", err)
+			}
+			for i := 0; i < tt.wrong; i++ {
+				if ok, err := verifier.ValidateCode(ctx, email, "000000"); err != nil || ok {
+					t.Fatalf("ValidateCode(wrong code) = %t, %v", ok, err)
+				}
+			}
+
+			ok, err := verifier.ValidateCode(ctx, email, tt.code)
+			if err != nil {
+				t.Fatalf("ValidateCode() error = %v", err)
+			}
+			if ok != tt.want {
+				t.Errorf("ValidateCode() = %t, want %t", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCodeUsedOnce(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	verifier := NewVerificationService(rdb, "", "", "")
+	userID, phone := uuid.NewString(), "+233241234567"
+
+	if err := verifier.StorePhoneCode(ctx, userID, phone, "123456", time.Minute); err != nil {
+		t.Fatalf("StorePhoneCode() error = %v", err)
+	}
+	if ok, err := verifier.ValidatePhoneCode(ctx, userID, phone, "123456"); err != nil || !ok {
+		t.Fatalf("ValidatePhoneCode() = %t, %v, want true", ok, err)
+	}
+	if ok, err := verifier.ValidatePhoneCode(ctx, userID, phone, "123456"); err != nil || ok {
+		t.Errorf("ValidatePhoneCode(used code) = %t, %v, want false", ok, err)
+	}
+}
+
+func TestStoreCodeResetsAttempts(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	verifier := NewVerificationService(rdb, "", "", "")
+	email := uuid.NewString() + "@st.knust.edu.gh"
+
+	if err := verifier.StoreCode(ctx, email, "123456", time.Minute); err != nil {
+		t.Fatalf("StoreCode() error = %v", err)
+	}
+	for i := 0; i < MaxCodeAttempts-1; i++ {
+		verifier.ValidateCode(ctx, email, "000000")
+	}
+	if err := verifier.StoreCode(ctx, email, "246810", time.Minute); err != nil {
+		t.Fatalf("StoreCode() error = %v", err)
+	}
+
+	// A single wrong guess against the new code must not delete it
+	if ok, err := verifier.ValidateCode(ctx, email, "000000"); err != nil || ok {
+		t.Fatalf("ValidateCode(wrong code) = %t, %v", ok, err)
+	}
+	if ok, err := verifier.ValidateCode(ctx, email, "246810"); err != nil || !ok {
+		t.Errorf("ValidateCode(new code) = %t, %v, want true", ok, err)
+	}
+}
+
+func TestValidateCodeParallelGuesses(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	verifier := NewVerificationService(rdb, "", "", "")
+	email := uuid.NewString() + "@st.knust.edu.gh"
+
+	if err := verifier.StoreCode(ctx, email, "123456", time.Minute); err != nil {
+		t.Fatalf("StoreCode() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := verifier.ValidateCode(ctx, email, "000000"); err != nil {
+				t.Errorf("ValidateCode() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ok, err := verifier.ValidateCode(ctx, email, "123456"); err != nil || ok {
+		t.Errorf("ValidateCode() after parallel wrong guesses = %t, %v, want false", ok, err)
+	}
+}
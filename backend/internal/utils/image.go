@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+	ErrInvalidImage         = errors.New("invalid image")
+	ErrImageDimensions      = errors.New("image dimensions out of range")
+)
+
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+type ImageLimits struct {
+	MinWidth  int
+	MinHeight int
+	MaxWidth  int
+	MaxHeight int
+}
+
+// ValidateImage sniffs the content type of data and checks its dimensions
+// against limits. It returns the detected content type. The client-supplied
+// Content-Type is never trusted.
+func ValidateImage(data []byte, limits ImageLimits) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedImageTypes[contentType] {
+		return "", ErrUnsupportedImageType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrInvalidImage
+	}
+
+	if cfg.Width < limits.MinWidth || cfg.Height < limits.MinHeight ||
+		cfg.Width > limits.MaxWidth || cfg.Height > limits.MaxHeight {
+		return "", ErrImageDimensions
+	}
+
+	return contentType, nil
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidPhoneNumber = errors.New("invalid Ghanaian phone number")
+
+// ghanaNumberPrefixes lists the leading digits of valid national significant
+// numbers: 2x and 5x for mobile networks, 3x for fixed lines.
+var ghanaNumberPrefixes = "235"
+
+// NormalizeGhanaPhone converts a Ghanaian phone number written in any of the
+// common local forms (0241234567, 233241234567, 00233241234567,
+// +233 24 123 4567) into E.164 format (+233241234567). A leading + must be
+// followed by the 233 country code.
+func NormalizeGhanaPhone(raw string) (string, error) {
+	var digits strings.Builder
+	plus := false
+	for i, c := range strings.TrimSpace(raw) {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c == '+' && i == 0:
+			plus = true
+		case c == ' ' || c == '-' || c == '(' || c == ')' || c == '.':
+		default:
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case plus:
+		var ok bool
+		if number, ok = strings.CutPrefix(number, "233"); !ok {
+			return "", ErrInvalidPhoneNumber
+		}
+	case strings.HasPrefix(number, "00233"):
+		number = number[5:]
+	case strings.HasPrefix(number, "233"):
+		number = number[3:]
+	case strings.HasPrefix(number, "0"):
+		number = number[1:]
+	}
+
+	if len(number) != 9 || !strings.ContainsRune(ghanaNumberPrefixes, rune(number[0])) {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	return "+233" + number, nil
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeGhanaPhone(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		// Documented forms
+		{raw: "0241234567", want: "+233241234567"},
+		{raw: "233241234567", want: "+233241234567"},
+		{raw: "00233241234567", want: "+233241234567"},
+		{raw: "+233241234567", want: "+233241234567"},
+		{raw: "+233 24 123 4567", want: "+233241234567"},
+		{raw: " 024-123-4567 ", want: "+233241234567"},
+		{raw: "(024) 123.4567", want: "+233241234567"},
+		{raw: "0551234567", want: "+233551234567"},
+		{raw: "0302123456", want: "+233302123456"},
+
+		// A + must be followed by the country code
+		{raw: "+0241234567", wantErr: true},
+		{raw: "+00233241234567", wantErr: true},
+		{raw: "+2330241234567", wantErr: true},
+		{raw: "+44 7700 900123", wantErr: true},
+
+		{raw: "", wantErr: true},
+		{raw: "024123456", wantErr: true},
+		{raw: "02412345678", wantErr: true},
+		{raw: "0141234567", wantErr: true},
+		{raw: "0241234567+", wantErr: true},
+		{raw: "024 123 456a", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeGhanaPhone(tt.raw)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidPhoneNumber) {
+				t.Errorf("NormalizeGhanaPhone(%q) = %q, %v, want %v", tt.raw, got, err, ErrInvalidPhoneNumber)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeGhanaPhone(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"campus-connect/internal/apperrors"
+	"campus-connect/internal/logging"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document. Code is the stable,
+// machine-readable identifier clients should branch on. Error and Message
+// repeat the title and detail for clients written against the older
+// {"error", "message"} body.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	Code      apperrors.Code `json:"code"`
+	RequestID string         `json:"requestId,omitempty"`
+	Errors    []FieldError   `json:"errors,omitempty"`
+
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+// WriteProblem fills in the fields of p derived from the request and status,
+// then writes it as application/problem+json.
+func WriteProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Code == "" {
+		p.Code = codeForStatus(p.Status)
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.RequestID = chimiddleware.GetReqID(r.Context())
+	}
+	p.Error = p.Title
+	p.Message = p.Detail
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+
+	if err := json.NewEncoder(w).Encode(p); err != nil && r != nil {
+		logging.FromContext(r.Context()).Warn("failed to write problem response", "error", err)
+	}
+}
+
+// WriteError translates err into a problem response. Domain errors from
+// apperrors keep their code and message; validation errors list every
+// invalid field; anything else is reported as an opaque 500.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *apperrors.Error
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &appErr):
+		WriteProblem(w, r, &Problem{
+			Status: statusForKind(appErr.Kind),
+			Code:   appErr.Code,
+			Detail: appErr.Message,
+		})
+	case errors.As(err, &validationErr):
+		WriteProblem(w, r, &Problem{
+			Status: http.StatusBadRequest,
+			Code:   apperrors.CodeValidationFailed,
+			Detail: validationErr.Fields[0].Message,
+			Errors: validationErr.Fields,
+		})
+	default:
+		WriteInternalError(w, r, err, "Internal server error")
+	}
+}
+
+// WriteInternalError logs err with the request's logger and writes a 500
+// response with message, which must not reveal err to the client.
+func WriteInternalError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	logging.FromContext(r.Context()).Error(message, "error", err)
+	WriteErrorResponse(w, r, http.StatusInternalServerError, message)
+}
+
+func statusForKind(kind error) int {
+	switch kind {
+	case apperrors.ErrInvalidInput:
+		return http.StatusBadRequest
+	case apperrors.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case apperrors.ErrForbidden:
+		return http.StatusForbidden
+	case apperrors.ErrNotFound:
+		return http.StatusNotFound
+	case apperrors.ErrConflict:
+		return http.StatusConflict
+	case apperrors.ErrRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func codeForStatus(status int) apperrors.Code {
+	switch status {
+	case http.StatusBadRequest:
+		return apperrors.CodeBadRequest
+	case http.StatusUnauthorized:
+		return apperrors.CodeUnauthorized
+	case http.StatusForbidden:
+		return apperrors.CodeForbidden
+	case http.StatusNotFound:
+		return apperrors.CodeNotFound
+	case http.StatusConflict:
+		return apperrors.CodeConflict
+	case http.StatusGone:
+		return apperrors.CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return apperrors.CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return apperrors.CodeUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return apperrors.CodeRateLimited
+	case http.StatusBadGateway:
+		return apperrors.CodeUpstream
+	default:
+		return apperrors.CodeInternal
+	}
+}
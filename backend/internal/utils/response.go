@@ -5,11 +5,6 @@ import (
 	"net/http"
 )
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-}
-
 type SuccessResponse struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
@@ -24,12 +19,15 @@ func WriteJSONResponse(w http.ResponseWriter, statusCode int, data interface{})
 	}
 }
 
-func WriteErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	response := ErrorResponse{
-		Error:   http.StatusText(statusCode),
-		Message: message,
-	}
-	WriteJSONResponse(w, statusCode, response)
+// WriteErrorResponse writes a problem response with the generic code for
+// statusCode. Prefer WriteError with an apperrors.Error when the failure has
+// a more specific code.
+func WriteErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	WriteProblem(w, r, &Problem{
+		Status: statusCode,
+		Code:   codeForStatus(statusCode),
+		Detail: message,
+	})
 }
 
 func WriteSuccessResponse(w http.ResponseWriter, message string, data interface{}) {
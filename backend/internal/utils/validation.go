@@ -2,50 +2,237 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"strings"
+
+	"campus-connect/internal/apperrors"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// MaxJSONBodyBytes caps the size of JSON request bodies.
+const MaxJSONBodyBytes = 1 << 20
+
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// UnknownFieldError reports a JSON field the target type does not have.
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return "unknown field " + e.Field
+}
+
+// unknownFieldPrefix starts the message encoding/json gives for fields
+// rejected by DisallowUnknownFields, which has no error type of its own.
+const unknownFieldPrefix = "json: unknown field "
+
 var validate *validator.Validate
 
+// Enum is implemented by string enum types so the "enum" validation tag can
+// check values against their defined constants.
+type Enum interface {
+	IsValid() bool
+}
+
 func init() {
 	validate = validator.New()
+
+	// Report JSON field names rather than Go struct field names
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	validate.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+		value, ok := fl.Field().Interface().(Enum)
+		return ok && value.IsValid()
+	})
+}
+
+// FieldError describes a single invalid field in a request payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError carries every field error found in a request payload.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
 }
 
 func ValidateStruct(s interface{}) error {
-	return validate.Struct(s)
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fields := make([]FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		fields[i] = FieldError{
+			Field:   fieldErr.Field(),
+			Rule:    fieldErr.Tag(),
+			Message: fieldErrorMessage(fieldErr),
+		}
+	}
+	return &ValidationError{Fields: fields}
 }
 
+// DecodeAndValidate decodes a JSON body of at most MaxJSONBodyBytes into v,
+// rejecting unknown fields and trailing data, then validates it.
 func DecodeAndValidate(r *http.Request, v interface{}) error {
-	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+	decoder := json.NewDecoder(&limitedBody{r: r.Body, remaining: MaxJSONBodyBytes})
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return ErrBodyTooLarge
+		}
+		if field, ok := strings.CutPrefix(err.Error(), unknownFieldPrefix); ok {
+			return &UnknownFieldError{Field: field}
+		}
 		return fmt.Errorf("invalid JSON: %w", err)
 	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return ErrBodyTooLarge
+		}
+		return fmt.Errorf("invalid JSON: unexpected data after the request body")
+	}
 
-	if err := ValidateStruct(v); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+	return ValidateStruct(v)
+}
+
+// limitedBody reads at most remaining bytes from r and fails with
+// ErrBodyTooLarge if there is more.
+type limitedBody struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Read one more byte to tell a body of exactly the limit from a
+		// longer one
+		var probe [1]byte
+		if n, err := l.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
 	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
 
-	return nil
+// WriteDecodeError writes the response for an error returned by
+// DecodeAndValidate or ValidateStruct.
+func WriteDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *ValidationError
+	var unknownFieldErr *UnknownFieldError
+	switch {
+	case errors.As(err, &validationErr):
+		WriteError(w, r, err)
+	case errors.Is(err, ErrBodyTooLarge):
+		WriteErrorResponse(w, r, http.StatusRequestEntityTooLarge, "Request body is too large")
+	case errors.As(err, &unknownFieldErr):
+		WriteProblem(w, r, &Problem{
+			Status: http.StatusBadRequest,
+			Code:   apperrors.CodeUnknownField,
+			Detail: fmt.Sprintf("Unknown field %s", unknownFieldErr.Field),
+		})
+	default:
+		WriteProblem(w, r, &Problem{
+			Status: http.StatusBadRequest,
+			Code:   apperrors.CodeInvalidJSON,
+			Detail: "Invalid request body",
+		})
+	}
 }
 
-func FormatValidationError(err error) string {
-	if validationErrors, ok := err.(validator.ValidationErrors); ok {
-		for _, fieldErr := range validationErrors {
-			switch fieldErr.Tag() {
-			case "required":
-				return fmt.Sprintf("%s is required", fieldErr.Field())
-			case "email":
-				return fmt.Sprintf("%s must be a valid email", fieldErr.Field())
-			case "min":
-				return fmt.Sprintf("%s must be at least %s characters", fieldErr.Field(), fieldErr.Param())
-			case "gt":
-				return fmt.Sprintf("%s must be greater than %s", fieldErr.Field(), fieldErr.Param())
-			default:
-				return fmt.Sprintf("%s failed validation (%s)", fieldErr.Field(), fieldErr.Tag())
-			}
+func fieldErrorMessage(fieldErr validator.FieldError) string {
+	field := fieldErr.Field()
+	switch fieldErr.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "required_without":
+		return fmt.Sprintf("%s is required unless %s is given", field, jsonFieldName(fieldErr.Param()))
+	case "required_with":
+		return fmt.Sprintf("%s is required with %s", field, jsonFieldName(fieldErr.Param()))
+	case "required_if":
+		if other, value, ok := strings.Cut(fieldErr.Param(), " "); ok {
+			return fmt.Sprintf("%s is required when %s is %s", field, jsonFieldName(other), value)
+		}
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email", field)
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at least %s characters", field, fieldErr.Param())
 		}
+		return fmt.Sprintf("%s must be at least %s", field, fieldErr.Param())
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at most %s characters", field, fieldErr.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", field, fieldErr.Param())
+	case "len":
+		return fmt.Sprintf("%s must be exactly %s characters", field, fieldErr.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fieldErr.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", field, fieldErr.Param())
+	case "lte":
+		return fmt.Sprintf("%s must be at most %s", field, fieldErr.Param())
+	case "numeric":
+		return fmt.Sprintf("%s must contain only digits", field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, fieldErr.Param())
+	case "enum":
+		return fmt.Sprintf("%s has an invalid value %q", field, fmt.Sprint(fieldErr.Value()))
+	case "datetime":
+		if fieldErr.Param() == "2006-01-02" {
+			return fmt.Sprintf("%s must be a date in YYYY-MM-DD format", field)
+		}
+		return fmt.Sprintf("%s must match the format %s", field, fieldErr.Param())
+	default:
+		return fmt.Sprintf("%s failed validation (%s)", field, fieldErr.Tag())
+	}
+}
+
+// jsonFieldName spells a Go field name given as a validation parameter as
+// its JSON name, in which IDs are spelled "Id".
+func jsonFieldName(name string) string {
+	if strings.HasSuffix(name, "ID") {
+		name = strings.TrimSuffix(name, "ID") + "Id"
 	}
-	return err.Error()
+	return strings.ToLower(name[:1]) + name[1:]
 }
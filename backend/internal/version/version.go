@@ -0,0 +1,33 @@
+// Package version reports the build version of the server. Release builds
+// set the variables through linker flags, e.g.
+//
+//	go build -ldflags "-X campus-connect/internal/version.Version=v1.4.0 \
+//	    -X campus-connect/internal/version.Commit=$(git rev-parse --short HEAD)"
+package version
+
+import (
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+func init() {
+	if Commit != "" {
+		return
+	}
+	// Fall back to the VCS revision the Go toolchain embeds in local builds
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				Commit = setting.Value
+			}
+		}
+	}
+	if Commit == "" {
+		Commit = "unknown"
+	}
+}
@@ -0,0 +1,3 @@
+-- Track the object store key of the current profile image so it can be
+-- removed when the image is replaced or deleted
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_image_key TEXT;
@@ -0,0 +1,3 @@
+DELETE FROM user_verification_documents WHERE url IS NULL;
+ALTER TABLE user_verification_documents ALTER COLUMN url SET NOT NULL;
+ALTER TABLE user_verification_documents DROP COLUMN IF EXISTS storage_key;
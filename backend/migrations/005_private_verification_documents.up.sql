@@ -0,0 +1,4 @@
+-- Verification documents are private objects addressed by storage key; URLs
+-- are minted on demand and never stored
+ALTER TABLE user_verification_documents ADD COLUMN IF NOT EXISTS storage_key TEXT;
+ALTER TABLE user_verification_documents ALTER COLUMN url DROP NOT NULL;
@@ -0,0 +1,17 @@
+-- Sign-in attempts, for account history and new-device detection. user_id is
+-- NULL when the email did not match an account.
+CREATE TABLE IF NOT EXISTS login_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    device_hash CHAR(64) NOT NULL, -- sha256 of the user agent
+    success BOOLEAN NOT NULL,
+    failure_reason VARCHAR(50),
+    new_device BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_events_user_created ON login_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_events_user_device ON login_events(user_id, device_hash) WHERE success;
@@ -0,0 +1,55 @@
+-- Double-entry ledger for delivery fees. Amounts are integer pesewas
+-- (1 GHS = 100 pesewas). Each transaction's postings sum to zero; a posting's
+-- amount is added to its account's balance.
+CREATE TYPE ledger_account_type AS ENUM ('wallet', 'escrow', 'platform');
+CREATE TYPE ledger_transaction_kind AS ENUM ('hold', 'release', 'refund');
+
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type ledger_account_type NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE RESTRICT, -- set for wallets only
+    balance BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((type = 'wallet') = (user_id IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_wallet ON ledger_accounts(user_id) WHERE type = 'wallet';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_system ON ledger_accounts(type) WHERE user_id IS NULL;
+
+INSERT INTO ledger_accounts (type) VALUES ('escrow'), ('platform');
+
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind ledger_transaction_kind NOT NULL,
+    delivery_request_id UUID NOT NULL REFERENCES delivery_requests(id) ON DELETE RESTRICT,
+    idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_delivery ON ledger_transactions(delivery_request_id, created_at);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES ledger_transactions(id) ON DELETE RESTRICT,
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL CHECK (amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_transaction ON ledger_postings(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account ON ledger_postings(account_id);
+
+-- Reject, at commit, any transaction whose postings do not sum to zero
+CREATE OR REPLACE FUNCTION check_ledger_transaction_balanced()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM ledger_postings WHERE transaction_id = NEW.transaction_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger transaction % does not balance', NEW.transaction_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_ledger_transaction_balanced();
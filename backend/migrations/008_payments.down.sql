@@ -0,0 +1,4 @@
+-- Enum values cannot be dropped; 'provider' and 'deposit' stay unused
+DROP TRIGGER IF EXISTS update_payments_updated_at ON payments;
+DROP TABLE IF EXISTS payments;
+DROP TYPE IF EXISTS payment_status;
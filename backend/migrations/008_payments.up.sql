@@ -0,0 +1,34 @@
+-- Mobile money payments for delivery fees. A successful payment is recorded
+-- in the ledger as a deposit from the provider account into the payer's
+-- wallet. The provider account is seeded by the next migration, because a
+-- new enum value cannot be used in the transaction that adds it.
+ALTER TYPE ledger_account_type ADD VALUE IF NOT EXISTS 'provider';
+ALTER TYPE ledger_transaction_kind ADD VALUE IF NOT EXISTS 'deposit';
+
+CREATE TYPE payment_status AS ENUM ('pending', 'success', 'failed', 'abandoned');
+
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_request_id UUID NOT NULL REFERENCES delivery_requests(id) ON DELETE RESTRICT,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    provider VARCHAR(50) NOT NULL,
+    reference VARCHAR(100) NOT NULL UNIQUE,
+    amount BIGINT NOT NULL CHECK (amount > 0), -- pesewas
+    currency CHAR(3) NOT NULL,
+    network VARCHAR(20) NOT NULL,
+    phone_number VARCHAR(20) NOT NULL,
+    status payment_status NOT NULL DEFAULT 'pending',
+    display_text TEXT,
+    failure_reason TEXT,
+    ledger_transaction_id UUID REFERENCES ledger_transactions(id),
+    paid_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one charge in progress or paid per delivery request
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_delivery_active ON payments(delivery_request_id) WHERE status IN ('pending', 'success');
+CREATE INDEX IF NOT EXISTS idx_payments_pending ON payments(created_at) WHERE status = 'pending';
+
+CREATE TRIGGER update_payments_updated_at BEFORE UPDATE ON payments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
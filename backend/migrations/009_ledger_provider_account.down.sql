@@ -0,0 +1 @@
+DELETE FROM ledger_accounts WHERE type = 'provider' AND user_id IS NULL;
@@ -0,0 +1 @@
+INSERT INTO ledger_accounts (type) VALUES ('provider');
@@ -0,0 +1,5 @@
+-- The 'payout' enum value cannot be dropped, and delivery_request_id stays
+-- nullable while payout ledger transactions exist
+DROP TRIGGER IF EXISTS update_payouts_updated_at ON payouts;
+DROP TABLE IF EXISTS payouts;
+DROP TYPE IF EXISTS payout_status;
@@ -0,0 +1,29 @@
+-- Payout requests from travelers, approved or rejected by admins. An approved
+-- payout debits the traveler's ledger wallet; such transactions belong to no
+-- delivery request.
+ALTER TYPE ledger_transaction_kind ADD VALUE IF NOT EXISTS 'payout';
+ALTER TABLE ledger_transactions ALTER COLUMN delivery_request_id DROP NOT NULL;
+
+CREATE TYPE payout_status AS ENUM ('pending', 'approved', 'rejected');
+
+CREATE TABLE IF NOT EXISTS payouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL CHECK (amount > 0), -- pesewas
+    network VARCHAR(20) NOT NULL,
+    phone_number VARCHAR(20) NOT NULL,
+    status payout_status NOT NULL DEFAULT 'pending',
+    transfer_reference VARCHAR(100), -- set by the admin who sent the money
+    rejection_reason TEXT,
+    ledger_transaction_id UUID REFERENCES ledger_transactions(id),
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payouts_user_created ON payouts(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payouts_status_created ON payouts(status, created_at);
+
+CREATE TRIGGER update_payouts_updated_at BEFORE UPDATE ON payouts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
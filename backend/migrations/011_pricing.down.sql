@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS price_flags;
+DROP INDEX IF EXISTS idx_delivery_requests_delivered_dropoff;
+DROP INDEX IF EXISTS idx_delivery_requests_delivered_pickup;
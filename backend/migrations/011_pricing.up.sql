@@ -0,0 +1,22 @@
+-- Price estimates look up delivered requests by normalized route
+CREATE INDEX IF NOT EXISTS idx_delivery_requests_delivered_pickup
+    ON delivery_requests (lower(btrim(pickup_location)), updated_at DESC) WHERE status = 'delivered';
+CREATE INDEX IF NOT EXISTS idx_delivery_requests_delivered_dropoff
+    ON delivery_requests (lower(btrim(dropoff_location)), updated_at DESC) WHERE status = 'delivered';
+
+-- Delivery requests and trips created with a price far outside the estimate
+CREATE TABLE IF NOT EXISTS price_flags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subject_type VARCHAR(20) NOT NULL, -- delivery_request or trip
+    subject_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL,
+    outlier VARCHAR(10) NOT NULL, -- low or high
+    suggested_low DECIMAL(10,2) NOT NULL,
+    suggested_median DECIMAL(10,2) NOT NULL,
+    suggested_high DECIMAL(10,2) NOT NULL,
+    basis VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_flags_created ON price_flags(created_at DESC);
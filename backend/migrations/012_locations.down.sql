@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_trips_to_location;
+DROP INDEX IF EXISTS idx_trips_from_location;
+DROP INDEX IF EXISTS idx_delivery_requests_dropoff_location;
+DROP INDEX IF EXISTS idx_delivery_requests_pickup_location;
+ALTER TABLE trips DROP COLUMN IF EXISTS to_location_id, DROP COLUMN IF EXISTS from_location_id;
+ALTER TABLE delivery_requests DROP COLUMN IF EXISTS dropoff_location_id, DROP COLUMN IF EXISTS pickup_location_id;
+DROP TABLE IF EXISTS location_aliases;
+DROP TABLE IF EXISTS locations;
+DROP TYPE IF EXISTS location_category;
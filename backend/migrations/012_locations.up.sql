@@ -0,0 +1,149 @@
+-- Catalogue of places deliveries and trips start or end at, so that "Conti"
+-- and "Unity Hall" are the same place. Coordinates are approximate.
+CREATE TYPE location_category AS ENUM ('hall', 'hostel', 'faculty', 'landmark', 'town');
+
+CREATE TABLE IF NOT EXISTS locations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    category location_category NOT NULL,
+    latitude DOUBLE PRECISION NOT NULL CHECK (latitude BETWEEN -90 AND 90),
+    longitude DOUBLE PRECISION NOT NULL CHECK (longitude BETWEEN -180 AND 180),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_name ON locations(lower(name));
+
+-- Aliases are stored lowercased and trimmed, as lookups compare them
+CREATE TABLE IF NOT EXISTS location_aliases (
+    alias VARCHAR(255) PRIMARY KEY,
+    location_id UUID NOT NULL REFERENCES locations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_location_aliases_location ON location_aliases(location_id);
+
+INSERT INTO locations (slug, name, category, latitude, longitude) VALUES
+    ('unity-hall', 'Unity Hall', 'hall', 6.6747, -1.5665),
+    ('university-hall', 'University Hall', 'hall', 6.6804, -1.5727),
+    ('queen-elizabeth-ii-hall', 'Queen Elizabeth II Hall', 'hall', 6.6769, -1.5717),
+    ('independence-hall', 'Independence Hall', 'hall', 6.6780, -1.5691),
+    ('republic-hall', 'Republic Hall', 'hall', 6.6786, -1.5712),
+    ('africa-hall', 'Africa Hall', 'hall', 6.6729, -1.5671),
+    ('hall-seven', 'Otumfuo Osei Tutu II Hall', 'hall', 6.6757, -1.5630),
+    ('gaza-hostel', 'SRC Hostel', 'hostel', 6.6731, -1.5615),
+    ('brunei-hostel', 'Brunei Hostel', 'hostel', 6.6706, -1.5633),
+    ('college-of-engineering', 'College of Engineering', 'faculty', 6.6743, -1.5683),
+    ('college-of-science', 'College of Science', 'faculty', 6.6733, -1.5712),
+    ('knust-school-of-business', 'KNUST School of Business', 'faculty', 6.6765, -1.5655),
+    ('college-of-art', 'College of Art and Built Environment', 'faculty', 6.6780, -1.5650),
+    ('college-of-health-sciences', 'College of Health Sciences', 'faculty', 6.6728, -1.5746),
+    ('college-of-agriculture', 'College of Agriculture and Natural Resources', 'faculty', 6.6795, -1.5630),
+    ('college-of-humanities', 'College of Humanities and Social Sciences', 'faculty', 6.6722, -1.5661),
+    ('knust-campus', 'KNUST Campus', 'landmark', 6.6745, -1.5716),
+    ('prempeh-ii-library', 'Prempeh II Library', 'landmark', 6.6752, -1.5725),
+    ('great-hall', 'Great Hall', 'landmark', 6.6739, -1.5743),
+    ('central-cafeteria', 'Central Cafeteria', 'landmark', 6.6760, -1.5705),
+    ('commercial-area', 'Commercial Area', 'landmark', 6.6758, -1.5692),
+    ('knust-hospital', 'KNUST Hospital', 'landmark', 6.6820, -1.5680),
+    ('tech-junction', 'Tech Junction', 'landmark', 6.6880, -1.5690),
+    ('ayeduase', 'Ayeduase', 'town', 6.6690, -1.5600),
+    ('kotei', 'Kotei', 'town', 6.6630, -1.5560),
+    ('bomso', 'Bomso', 'town', 6.6830, -1.5800),
+    ('ayigya', 'Ayigya', 'town', 6.6880, -1.5610),
+    ('kentinkrono', 'Kentinkrono', 'town', 6.6900, -1.5550),
+    ('adum', 'Adum', 'town', 6.6920, -1.6260),
+    ('kejetia', 'Kejetia', 'town', 6.6980, -1.6240),
+    ('kumasi', 'Kumasi', 'town', 6.6885, -1.6244),
+    ('kumasi-airport', 'Kumasi Airport', 'landmark', 6.7146, -1.5908),
+    ('ejisu', 'Ejisu', 'town', 6.7210, -1.4650),
+    ('obuasi', 'Obuasi', 'town', 6.2012, -1.6913),
+    ('accra', 'Accra', 'town', 5.6037, -0.1870),
+    ('accra-mall', 'Accra Mall', 'landmark', 5.6219, -0.1734),
+    ('tema', 'Tema', 'town', 5.6698, -0.0166),
+    ('cape-coast', 'Cape Coast', 'town', 5.1053, -1.2466),
+    ('takoradi', 'Takoradi', 'town', 4.8845, -1.7554),
+    ('sunyani', 'Sunyani', 'town', 7.3399, -2.3268),
+    ('tamale', 'Tamale', 'town', 9.4034, -0.8424)
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO location_aliases (alias, location_id)
+SELECT a.alias, l.id
+FROM (VALUES
+    ('unity-hall', 'conti'),
+    ('unity-hall', 'continental'),
+    ('unity-hall', 'unity'),
+    ('university-hall', 'katanga'),
+    ('university-hall', 'katz'),
+    ('queen-elizabeth-ii-hall', 'queens'),
+    ('queen-elizabeth-ii-hall', 'queens hall'),
+    ('queen-elizabeth-ii-hall', 'queen''s hall'),
+    ('queen-elizabeth-ii-hall', 'qe hall'),
+    ('independence-hall', 'indece'),
+    ('independence-hall', 'indy'),
+    ('republic-hall', 'repub'),
+    ('republic-hall', 'republic'),
+    ('africa-hall', 'africa'),
+    ('hall-seven', 'hall 7'),
+    ('hall-seven', 'hall seven'),
+    ('hall-seven', 'otumfuo hall'),
+    ('gaza-hostel', 'gaza'),
+    ('gaza-hostel', 'gaza hostel'),
+    ('brunei-hostel', 'brunei'),
+    ('college-of-engineering', 'coe'),
+    ('college-of-engineering', 'engineering'),
+    ('college-of-engineering', 'petroleum building'),
+    ('college-of-science', 'cos'),
+    ('college-of-science', 'science'),
+    ('knust-school-of-business', 'ksb'),
+    ('knust-school-of-business', 'business school'),
+    ('college-of-art', 'cabe'),
+    ('college-of-art', 'art'),
+    ('college-of-health-sciences', 'chs'),
+    ('college-of-health-sciences', 'pharmacy'),
+    ('college-of-agriculture', 'canr'),
+    ('college-of-agriculture', 'agric'),
+    ('college-of-humanities', 'cohss'),
+    ('college-of-humanities', 'social sciences'),
+    ('knust-campus', 'knust'),
+    ('knust-campus', 'campus'),
+    ('knust-campus', 'tech'),
+    ('prempeh-ii-library', 'main library'),
+    ('prempeh-ii-library', 'library'),
+    ('central-cafeteria', 'cafeteria'),
+    ('commercial-area', 'comm area'),
+    ('knust-hospital', 'university hospital'),
+    ('tech-junction', 'knust junction'),
+    ('kejetia', 'kejetia market'),
+    ('kumasi', 'kumasi central'),
+    ('kumasi-airport', 'prempeh i airport'),
+    ('kumasi-airport', 'kumasi international airport')
+) AS a(slug, alias)
+JOIN locations l ON l.slug = a.slug
+ON CONFLICT (alias) DO NOTHING;
+
+ALTER TABLE delivery_requests
+    ADD COLUMN IF NOT EXISTS pickup_location_id UUID REFERENCES locations(id),
+    ADD COLUMN IF NOT EXISTS dropoff_location_id UUID REFERENCES locations(id);
+ALTER TABLE trips
+    ADD COLUMN IF NOT EXISTS from_location_id UUID REFERENCES locations(id),
+    ADD COLUMN IF NOT EXISTS to_location_id UUID REFERENCES locations(id);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_requests_pickup_location ON delivery_requests(pickup_location_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_requests_dropoff_location ON delivery_requests(dropoff_location_id);
+CREATE INDEX IF NOT EXISTS idx_trips_from_location ON trips(from_location_id);
+CREATE INDEX IF NOT EXISTS idx_trips_to_location ON trips(to_location_id);
+
+-- Link existing free text that names a catalogued place
+CREATE TEMPORARY TABLE location_names ON COMMIT DROP AS
+    SELECT lower(name) AS name, id FROM locations
+    UNION
+    SELECT alias, location_id FROM location_aliases;
+
+UPDATE delivery_requests dr SET pickup_location_id = n.id
+FROM location_names n WHERE n.name = lower(btrim(dr.pickup_location));
+UPDATE delivery_requests dr SET dropoff_location_id = n.id
+FROM location_names n WHERE n.name = lower(btrim(dr.dropoff_location));
+UPDATE trips t SET from_location_id = n.id
+FROM location_names n WHERE n.name = lower(btrim(t.from_location));
+UPDATE trips t SET to_location_id = n.id
+FROM location_names n WHERE n.name = lower(btrim(t.to_location));
@@ -0,0 +1,11 @@
+DROP FUNCTION IF EXISTS haversine_meters(DOUBLE PRECISION, DOUBLE PRECISION, DOUBLE PRECISION, DOUBLE PRECISION);
+DROP INDEX IF EXISTS idx_trips_to_geo;
+DROP INDEX IF EXISTS idx_trips_from_geo;
+DROP INDEX IF EXISTS idx_delivery_requests_dropoff_geo;
+DROP INDEX IF EXISTS idx_delivery_requests_pickup_geo;
+ALTER TABLE trips
+    DROP COLUMN IF EXISTS to_longitude, DROP COLUMN IF EXISTS to_latitude,
+    DROP COLUMN IF EXISTS from_longitude, DROP COLUMN IF EXISTS from_latitude;
+ALTER TABLE delivery_requests
+    DROP COLUMN IF EXISTS dropoff_longitude, DROP COLUMN IF EXISTS dropoff_latitude,
+    DROP COLUMN IF EXISTS pickup_longitude, DROP COLUMN IF EXISTS pickup_latitude;
@@ -0,0 +1,37 @@
+-- Coordinates of where deliveries and trips start and end, for proximity
+-- search. Distances use the haversine formula in plain SQL, so PostGIS is
+-- not needed; the latitude indexes narrow searches to a bounding box first.
+ALTER TABLE delivery_requests
+    ADD COLUMN IF NOT EXISTS pickup_latitude DOUBLE PRECISION CHECK (pickup_latitude BETWEEN -90 AND 90),
+    ADD COLUMN IF NOT EXISTS pickup_longitude DOUBLE PRECISION CHECK (pickup_longitude BETWEEN -180 AND 180),
+    ADD COLUMN IF NOT EXISTS dropoff_latitude DOUBLE PRECISION CHECK (dropoff_latitude BETWEEN -90 AND 90),
+    ADD COLUMN IF NOT EXISTS dropoff_longitude DOUBLE PRECISION CHECK (dropoff_longitude BETWEEN -180 AND 180);
+ALTER TABLE trips
+    ADD COLUMN IF NOT EXISTS from_latitude DOUBLE PRECISION CHECK (from_latitude BETWEEN -90 AND 90),
+    ADD COLUMN IF NOT EXISTS from_longitude DOUBLE PRECISION CHECK (from_longitude BETWEEN -180 AND 180),
+    ADD COLUMN IF NOT EXISTS to_latitude DOUBLE PRECISION CHECK (to_latitude BETWEEN -90 AND 90),
+    ADD COLUMN IF NOT EXISTS to_longitude DOUBLE PRECISION CHECK (to_longitude BETWEEN -180 AND 180);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_requests_pickup_geo ON delivery_requests(pickup_latitude, pickup_longitude) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_delivery_requests_dropoff_geo ON delivery_requests(dropoff_latitude, dropoff_longitude) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_trips_from_geo ON trips(from_latitude, from_longitude) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_trips_to_geo ON trips(to_latitude, to_longitude) WHERE status = 'active';
+
+-- Great-circle distance in metres between two points given in degrees
+CREATE OR REPLACE FUNCTION haversine_meters(lat1 DOUBLE PRECISION, lng1 DOUBLE PRECISION, lat2 DOUBLE PRECISION, lng2 DOUBLE PRECISION)
+RETURNS DOUBLE PRECISION AS $$
+    SELECT 2 * 6371008.8 * asin(least(1, sqrt(
+        power(sin(radians(lat2 - lat1) / 2), 2)
+        + cos(radians(lat1)) * cos(radians(lat2)) * power(sin(radians(lng2 - lng1) / 2), 2)
+    )))
+$$ LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE;
+
+-- Rows linked to the catalogue take its coordinates
+UPDATE delivery_requests dr SET pickup_latitude = l.latitude, pickup_longitude = l.longitude
+FROM locations l WHERE l.id = dr.pickup_location_id;
+UPDATE delivery_requests dr SET dropoff_latitude = l.latitude, dropoff_longitude = l.longitude
+FROM locations l WHERE l.id = dr.dropoff_location_id;
+UPDATE trips t SET from_latitude = l.latitude, from_longitude = l.longitude
+FROM locations l WHERE l.id = t.from_location_id;
+UPDATE trips t SET to_latitude = l.latitude, to_longitude = l.longitude
+FROM locations l WHERE l.id = t.to_location_id;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS delivery_proofs;
+DROP TYPE IF EXISTS delivery_proof_kind;
+DROP TABLE IF EXISTS handover_codes;
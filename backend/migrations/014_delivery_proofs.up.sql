@@ -0,0 +1,41 @@
+-- One-time PIN the requester gives the traveler at handover. The traveler
+-- enters it to mark the delivery delivered.
+CREATE TABLE IF NOT EXISTS handover_codes (
+    delivery_request_id UUID PRIMARY KEY REFERENCES delivery_requests(id) ON DELETE CASCADE,
+    code VARCHAR(6) NOT NULL,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TYPE delivery_proof_kind AS ENUM ('handover_code', 'photo', 'requester_confirmation');
+
+-- Evidence that a delivery happened, kept for disputes
+CREATE TABLE IF NOT EXISTS delivery_proofs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_request_id UUID NOT NULL REFERENCES delivery_requests(id) ON DELETE CASCADE,
+    kind delivery_proof_kind NOT NULL,
+    submitted_by UUID NOT NULL REFERENCES users(id),
+    storage_key TEXT,
+    content_type VARCHAR(100),
+    latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    accuracy DOUBLE PRECISION CHECK (accuracy >= 0),
+    captured_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((kind = 'photo') = (storage_key IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_proofs_request ON delivery_proofs(delivery_request_id, created_at);
+
+-- Deliveries matched before handover codes existed get one now. Codes are
+-- guessable PINs, so they come from pgcrypto's secure random bytes rather
+-- than random(); 48 bits keep the bias of reducing them to 6 digits
+-- negligible.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+INSERT INTO handover_codes (delivery_request_id, code)
+SELECT id, lpad((('x' || encode(gen_random_bytes(6), 'hex'))::BIT(48)::BIGINT % 1000000)::TEXT, 6, '0')
+FROM delivery_requests
+WHERE status IN ('matched', 'in_transit')
+ON CONFLICT (delivery_request_id) DO NOTHING;
@@ -0,0 +1,9 @@
+-- The 'dispute_refund' enum value cannot be dropped
+DROP TABLE IF EXISTS dispute_attachments;
+DROP TABLE IF EXISTS dispute_notes;
+DROP TRIGGER IF EXISTS update_disputes_updated_at ON disputes;
+DROP TABLE IF EXISTS disputes;
+DROP TYPE IF EXISTS dispute_resolution;
+DROP TYPE IF EXISTS dispute_status;
+DROP TYPE IF EXISTS dispute_category;
+ALTER TABLE users DROP COLUMN IF EXISTS banned_at;
@@ -0,0 +1,67 @@
+-- Cases opened by either party of a delivery and worked by admins. A refund
+-- resolution posts a dispute_refund ledger transaction; a ban sets
+-- users.banned_at, which blocks sign-in.
+ALTER TYPE ledger_transaction_kind ADD VALUE IF NOT EXISTS 'dispute_refund';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TYPE dispute_category AS ENUM ('lost_item', 'damaged_item', 'no_show', 'late_delivery', 'abuse', 'payment', 'other');
+CREATE TYPE dispute_status AS ENUM ('open', 'in_review', 'awaiting_response', 'resolved', 'closed');
+CREATE TYPE dispute_resolution AS ENUM ('refund', 'partial_refund', 'warning', 'ban', 'no_action');
+
+CREATE TABLE IF NOT EXISTS disputes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_request_id UUID NOT NULL REFERENCES delivery_requests(id) ON DELETE CASCADE,
+    opened_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    respondent_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category dispute_category NOT NULL,
+    description TEXT NOT NULL,
+    status dispute_status NOT NULL DEFAULT 'open',
+    assigned_to UUID REFERENCES users(id) ON DELETE SET NULL,
+    escalated BOOLEAN NOT NULL DEFAULT FALSE,
+    deadline_at TIMESTAMP WITH TIME ZONE, -- when the current status times out
+    resolution dispute_resolution,
+    refund_amount BIGINT CHECK (refund_amount > 0), -- pesewas
+    resolution_note TEXT,
+    sanctioned_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ledger_transaction_id UUID REFERENCES ledger_transactions(id),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((status = 'resolved') = (resolution IS NOT NULL))
+);
+
+-- One unfinished case per delivery request and party
+CREATE UNIQUE INDEX IF NOT EXISTS idx_disputes_active
+    ON disputes(delivery_request_id, opened_by) WHERE status NOT IN ('resolved', 'closed');
+CREATE INDEX IF NOT EXISTS idx_disputes_queue ON disputes(status, escalated DESC, created_at);
+CREATE INDEX IF NOT EXISTS idx_disputes_deadline ON disputes(deadline_at) WHERE deadline_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_disputes_opened_by ON disputes(opened_by, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_disputes_respondent ON disputes(respondent_id, created_at DESC);
+
+CREATE TRIGGER update_disputes_updated_at BEFORE UPDATE ON disputes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- The case history. Internal notes are seen by admins only; author_id is NULL
+-- for notes written by the system, such as status changes.
+CREATE TABLE IF NOT EXISTS dispute_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dispute_id UUID NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    internal BOOLEAN NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispute_notes_dispute ON dispute_notes(dispute_id, created_at);
+
+CREATE TABLE IF NOT EXISTS dispute_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dispute_id UUID NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+    uploaded_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispute_attachments_dispute ON dispute_attachments(dispute_id, created_at);
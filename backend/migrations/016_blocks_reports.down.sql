@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS update_reports_updated_at ON reports;
+DROP TABLE IF EXISTS reports;
+DROP TYPE IF EXISTS report_status;
+DROP TYPE IF EXISTS report_reason;
+DROP TYPE IF EXISTS report_target;
+DROP TABLE IF EXISTS user_blocks;
@@ -0,0 +1,43 @@
+-- A block hides each user's trips and requests from the other and stops them
+-- joining or offering to each other's trips, whichever of them blocked.
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked ON user_blocks(blocked_id);
+
+CREATE TYPE report_target AS ENUM ('trip', 'delivery_request', 'message', 'user');
+CREATE TYPE report_reason AS ENUM ('spam', 'harassment', 'scam', 'inappropriate', 'safety', 'other');
+CREATE TYPE report_status AS ENUM ('open', 'actioned', 'dismissed');
+
+-- Reports feed the admins' moderation queue. target_id is a trip, delivery
+-- request, dispute message or user, which is not enforced by a foreign key;
+-- reported_user_id is who posted it, kept if the content is deleted.
+CREATE TABLE IF NOT EXISTS reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_type report_target NOT NULL,
+    target_id UUID NOT NULL,
+    reported_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason report_reason NOT NULL,
+    details TEXT,
+    status report_status NOT NULL DEFAULT 'open',
+    review_note TEXT,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One open report per reporter and target
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_open
+    ON reports(reporter_id, target_type, target_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_reports_queue ON reports(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_reports_reported_user ON reports(reported_user_id);
+
+CREATE TRIGGER update_reports_updated_at BEFORE UPDATE ON reports
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
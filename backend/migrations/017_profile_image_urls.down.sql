@@ -0,0 +1,2 @@
+-- Cleared URLs are not restored; they are minted from profile_image_key.
+SELECT 1;
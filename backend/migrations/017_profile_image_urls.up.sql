@@ -0,0 +1,3 @@
+-- Profile image URLs are minted from profile_image_key when read; stored
+-- copies may be signed links that have already expired
+UPDATE users SET profile_image = NULL WHERE profile_image_key IS NOT NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_login_events_unmatched_ip;
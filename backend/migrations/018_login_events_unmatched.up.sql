@@ -0,0 +1,2 @@
+-- Supports capping failed sign-ins for unknown emails per IP
+CREATE INDEX IF NOT EXISTS idx_login_events_unmatched_ip ON login_events(ip_address, created_at) WHERE user_id IS NULL;
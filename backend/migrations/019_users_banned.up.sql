@@ -0,0 +1,2 @@
+-- Supports loading the banned users checked on every authenticated request
+CREATE INDEX IF NOT EXISTS idx_users_banned ON users(id) WHERE banned_at IS NOT NULL;